@@ -0,0 +1,39 @@
+//go:build headless
+// +build headless
+
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/xlab/teg-workshop/render"
+	"github.com/xlab/teg-workshop/tegview"
+)
+
+func init() {
+	extra = append(extra, &command{"image", "draw the model as a SVG, PDF or TikZ picture", image})
+}
+
+func image(args []string) error {
+	o := newOptions("image", false)
+	format := o.String("format", "svg", "one of "+strings.Join(render.PictureFormats, ", "))
+	scale := o.Float64("scale", 1, "zoom of the picture")
+	out := o.String("o", "", "output file, the standard output by default")
+	m, err := o.load(args)
+	if err != nil {
+		return err
+	}
+	if len(*out) < 1 {
+		return tegview.WritePicture(os.Stdout, m.Model(), *format, *scale)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err = tegview.WritePicture(f, m.Model(), *format, *scale); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,333 @@
+// Command teg analyzes the models saved by the editor without starting it.
+//
+//	teg validate [-json] model.json
+//	teg transfer [-json] model.json
+//	teg cycle [-json] model.json
+//	teg daters [-json] [-n events] model.json
+//	teg simulate [-json] [-steps n] model.json
+//	teg control [-json] [-p] [-f] [-o file] model.json
+//	teg compare [-json] model.json other.json
+//	teg export [-format json|pnml|latex|dot] [-o file] model.json
+//	teg layout [-dot layout.json] [-format json|pnml] [-o file] model.json
+//	teg image [-format svg|pdf|tikz] [-scale f] [-o file] model.json
+//
+// The models are read from PNML when their names end with .pnml.
+// The layout command arranges the model anew, at the positions dot
+// gave to the export with -Tjson or in layers from left to right.
+// The control command prints the greatest causal precompensator P and
+// output feedback F which keep the transfer, -o writes the model of
+// the graph realizing them. The compare command tells whether the
+// transfers of two models are equal or ordered, and shows a monomial
+// telling them apart otherwise. Only the core package is needed, Qt
+// is not required. The image command draws the models like the editor
+// does, it is there when the command is built with the headless tag.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xlab/teg-workshop/teg"
+)
+
+type command struct {
+	name, usage string
+	run         func(args []string) error
+}
+
+var commands []*command
+
+// extra are the commands which draw the models, they need the
+// editor package and so are only built with the headless tag.
+var extra []*command
+
+func init() {
+	commands = []*command{
+		{"validate", "check that the model can be loaded", validate},
+		{"transfer", "print the transfer matrix H = CA*B", transfer},
+		{"cycle", "print the cycle time, the critical circuit and the deadlocks", cycle},
+		{"daters", "print the earliest firing dates of the transitions", daters},
+		{"simulate", "play the token game from the initial marking", simulate},
+		{"control", "synthesize the controller which delays the inputs the most", control},
+		{"compare", "compare the transfers of two models", compare},
+		{"export", "write the model in another format", export},
+		{"layout", "arrange the model anew and write it", layout},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: teg <command> [flags] model.json")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nrun teg <command> -h for the flags of a command")
+}
+
+func main() {
+	commands = append(commands, extra...)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "teg:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+// options parses the flags of a command, asJson is nil
+// for the commands that have a single output format.
+type options struct {
+	*flag.FlagSet
+	asJson *bool
+}
+
+func newOptions(name string, withJson bool) *options {
+	o := &options{FlagSet: flag.NewFlagSet(name, flag.ExitOnError)}
+	if withJson {
+		o.asJson = o.Bool("json", false, "print the result as JSON")
+	}
+	o.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: teg %s [flags] model.json\n", name)
+		o.PrintDefaults()
+	}
+	return o
+}
+
+// load parses the arguments and loads the model they name.
+func (o *options) load(args []string) (*teg.Graph, error) {
+	o.Parse(args)
+	if o.NArg() != 1 {
+		o.Usage()
+		os.Exit(2)
+	}
+	return loadFile(o.Arg(0))
+}
+
+func loadFile(name string) (*teg.Graph, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(name), ".pnml") {
+		return teg.ReadPNML(data)
+	}
+	return teg.Load(data)
+}
+
+func (o *options) print(report fmt.Stringer) error {
+	if o.asJson != nil && *o.asJson {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	_, err := fmt.Print(report.String())
+	return err
+}
+
+// validation is the result of validate,
+// Problems are empty if the model is fine.
+type validation struct {
+	File     string
+	Problems []string
+}
+
+func (v *validation) String() string {
+	if len(v.Problems) < 1 {
+		return fmt.Sprintf("%s: ok\n", v.File)
+	}
+	return strings.Join(v.Problems, "\n") + "\n"
+}
+
+func validate(args []string) error {
+	o := newOptions("validate", true)
+	_, err := o.load(args)
+	v := &validation{File: o.Arg(0), Problems: []string{}}
+	if verr, ok := err.(*teg.ValidationError); ok {
+		v.Problems = verr.Problems
+	} else if err != nil {
+		if _, ok := err.(*os.PathError); ok {
+			return err
+		}
+		// not even a teg document
+		v.Problems = []string{err.Error()}
+	}
+	if err := o.print(v); err != nil {
+		return err
+	}
+	if len(v.Problems) > 0 {
+		return fmt.Errorf("%s: %d problem(s)", v.File, len(v.Problems))
+	}
+	return nil
+}
+
+func transfer(args []string) error {
+	o := newOptions("transfer", true)
+	m, err := o.load(args)
+	if err != nil {
+		return err
+	}
+	return o.print(m.TransferReport())
+}
+
+func cycle(args []string) error {
+	o := newOptions("cycle", true)
+	m, err := o.load(args)
+	if err != nil {
+		return err
+	}
+	return o.print(m.CycleReport())
+}
+
+func daters(args []string) error {
+	o := newOptions("daters", true)
+	n := o.Int("n", teg.DaterEvents, "number of events")
+	m, err := o.load(args)
+	if err != nil {
+		return err
+	}
+	if *n < 1 {
+		return fmt.Errorf("invalid number of events %d", *n)
+	}
+	return o.print(m.DaterReport(*n))
+}
+
+func simulate(args []string) error {
+	o := newOptions("simulate", true)
+	steps := o.Int("steps", 10, "number of steps")
+	m, err := o.load(args)
+	if err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return fmt.Errorf("invalid number of steps %d", *steps)
+	}
+	return o.print(m.Simulate(*steps))
+}
+
+func control(args []string) error {
+	o := newOptions("control", true)
+	precompensator := o.Bool("p", true, "synthesize the precompensator P")
+	feedback := o.Bool("f", true, "synthesize the output feedback F")
+	out := o.String("o", "", "file to write the model of the controller to")
+	m, err := o.load(args)
+	if err != nil {
+		return err
+	}
+	c, err := m.Synthesize(*precompensator, *feedback)
+	if err != nil {
+		return err
+	}
+	if len(*out) > 0 {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		if err = c.Graph().Export(f, "json"); err != nil {
+			f.Close()
+			return err
+		}
+		if err = f.Close(); err != nil {
+			return err
+		}
+	}
+	return o.print(c.Report())
+}
+
+func compare(args []string) error {
+	o := newOptions("compare", true)
+	o.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: teg compare [flags] model.json other.json")
+		o.PrintDefaults()
+	}
+	o.Parse(args)
+	if o.NArg() != 2 {
+		o.Usage()
+		os.Exit(2)
+	}
+	m1, err := loadFile(o.Arg(0))
+	if err != nil {
+		return err
+	}
+	m2, err := loadFile(o.Arg(1))
+	if err != nil {
+		return err
+	}
+	c, err := m1.Compare(m2)
+	if err != nil {
+		return err
+	}
+	return o.print(c)
+}
+
+func export(args []string) error {
+	o := newOptions("export", false)
+	format := o.String("format", "json", "one of "+strings.Join(teg.Formats, ", "))
+	out := o.String("o", "", "output file, the standard output by default")
+	m, err := o.load(args)
+	if err != nil {
+		return err
+	}
+	if len(*out) < 1 {
+		return m.Export(os.Stdout, *format)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err = m.Export(f, *format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func layout(args []string) error {
+	o := newOptions("layout", false)
+	positions := o.String("dot", "", "the output of dot -Tjson for the dot export of the model")
+	format := o.String("format", "json", "json or pnml")
+	out := o.String("o", "", "output file, the standard output by default")
+	m, err := o.load(args)
+	if err != nil {
+		return err
+	}
+	if *format != "json" && *format != "pnml" {
+		return fmt.Errorf("unknown format %q, expected json or pnml", *format)
+	}
+	if len(*positions) > 0 {
+		data, err := ioutil.ReadFile(*positions)
+		if err != nil {
+			return err
+		}
+		if err = m.ApplyDOT(data); err != nil {
+			return err
+		}
+	} else {
+		m.Layout()
+	}
+	if len(*out) < 1 {
+		return m.Export(os.Stdout, *format)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err = m.Export(f, *format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -1,3 +1,6 @@
+//go:build !purego
+// +build !purego
+
 #include "lib/gd.h"
 #include "lib/poly.h"
 #include "lib/serie.h"
@@ -25,6 +28,10 @@ serie_ *newSerie(poly_ *p, poly_ *q, gd_ *r) {
 	return s;
 }
 
+smatrix_ *newSmatrix(int row, int col) {
+	return new smatrix(row, col);
+}
+
 void freeGd(gd_ *m) {
 	delete (gd*)m;
 }
@@ -37,6 +44,10 @@ void freeSerie(void* s) {
 	delete (serie*)s;
 }
 
+void freeSmatrix(smatrix_ *m) {
+	delete (smatrix*)m;
+}
+
 void appendPoly(poly_ *p, int g, int d) {
 	gd m(g, d);
 	((poly*)p)->add(m);
@@ -74,6 +85,22 @@ gd_ *getR(serie_ *s) {
 	return &((serie*)s)->getr();
 }
 
+int rowsSmatrix(smatrix_ *m) {
+	return ((smatrix*)m)->getrow();
+}
+
+int colsSmatrix(smatrix_ *m) {
+	return ((smatrix*)m)->getcol();
+}
+
+serie_ *getSmatrix(smatrix_ *m, int i, int j) {
+	return &(*(smatrix*)m)(i, j);
+}
+
+void setSmatrix(smatrix_ *m, int i, int j, serie_ *s) {
+	(*(smatrix*)m)(i, j) = *(serie*)s;
+}
+
 poly_ *oplusPoly(poly_ *p1, poly_ *p2) {
 	return new poly(oplus(*(poly*)p1, *(poly*)p2));
 }
@@ -98,3 +125,80 @@ serie_ *starPoly(poly_ *p) {
 serie_ *starSerie(serie_ *s) {
 	return new serie(star(*(serie*)s));
 }
+
+poly_ *infPoly(poly_ *p1, poly_ *p2) {
+	return new poly(inf(*(poly*)p1, *(poly*)p2));
+}
+
+poly_ *fracPoly(poly_ *p1, poly_ *p2) {
+	poly a(*(poly*)p1), b(*(poly*)p2);
+	a.simpli();
+	b.simpli();
+	return new poly(frac(a, b));
+}
+
+poly_ *prcausPoly(poly_ *p) {
+	poly a(*(poly*)p);
+	a.simpli();
+	return new poly(prcaus(a));
+}
+
+serie_ *infSerie(serie_ *s1, serie_ *s2) {
+	return new serie(inf(*(serie*)s1, *(serie*)s2));
+}
+
+serie_ *fracSerie(serie_ *s1, serie_ *s2) {
+	return new serie(frac(*(serie*)s1, *(serie*)s2));
+}
+
+serie_ *dualfracSerie(serie_ *s, gd_ *m) {
+	return new serie(Dualfrac(*(serie*)s, *(gd*)m));
+}
+
+serie_ *odotSerie(serie_ *s1, serie_ *s2) {
+	return new serie(odot(*(serie*)s1, *(serie*)s2));
+}
+
+serie_ *prcausSerie(serie_ *s) {
+	return new serie(prcaus(*(serie*)s));
+}
+
+int equalSerie(serie_ *s1, serie_ *s2) {
+	return *(serie*)s1 == *(serie*)s2;
+}
+
+smatrix_ *oplusSmatrix(smatrix_ *m1, smatrix_ *m2) {
+	return new smatrix(oplus(*(smatrix*)m1, *(smatrix*)m2));
+}
+
+smatrix_ *otimesSmatrix(smatrix_ *m1, smatrix_ *m2) {
+	return new smatrix(otimes(*(smatrix*)m1, *(smatrix*)m2));
+}
+
+smatrix_ *infSmatrix(smatrix_ *m1, smatrix_ *m2) {
+	return new smatrix(inf(*(smatrix*)m1, *(smatrix*)m2));
+}
+
+smatrix_ *starSmatrix(smatrix_ *m) {
+	return new smatrix(star(*(smatrix*)m));
+}
+
+smatrix_ *lfracSmatrix(smatrix_ *m1, smatrix_ *m2) {
+	return new smatrix(lfrac(*(smatrix*)m1, *(smatrix*)m2));
+}
+
+smatrix_ *rfracSmatrix(smatrix_ *m1, smatrix_ *m2) {
+	return new smatrix(rfrac(*(smatrix*)m1, *(smatrix*)m2));
+}
+
+smatrix_ *odotSmatrix(smatrix_ *m1, smatrix_ *m2) {
+	return new smatrix(odot(*(smatrix*)m1, *(smatrix*)m2));
+}
+
+smatrix_ *prcausSmatrix(smatrix_ *m) {
+	return new smatrix(prcaus(*(smatrix*)m));
+}
+
+smatrix_ *duallfracSmatrix(smatrix_ *m1, smatrix_ *m2) {
+	return new smatrix(Duallfrac(*(smatrix*)m1, *(smatrix*)m2));
+}
@@ -1,3 +1,6 @@
+//go:build !purego
+// +build !purego
+
 package dioid
 
 // #cgo LDFLAGS: -lstdc++
@@ -38,6 +41,10 @@ func ptr2poly(cpoly unsafe.Pointer) Poly {
 }
 
 func serie2ptr(s Serie) unsafe.Pointer {
+	if !s.R.Causal() {
+		// the library would throw and abort the whole program
+		panic("dioid: the period of a serie should be causal, got " + s.R.String())
+	}
 	cp := poly2ptr(s.P)
 	cq := poly2ptr(s.Q)
 	cr := gd2ptr(s.R)
@@ -55,6 +62,30 @@ func ptr2serie(cserie unsafe.Pointer) Serie {
 	return Serie{p, q, r}
 }
 
+func matrix2ptr(m Matrix) unsafe.Pointer {
+	cm := C.newSmatrix(C.int(m.Rows()), C.int(m.Cols()))
+	for i, row := range m {
+		for j, s := range row {
+			cs := serie2ptr(s)
+			C.setSmatrix(cm, C.int(i), C.int(j), cs)
+			C.freeSerie(cs)
+		}
+	}
+	return cm
+}
+
+func ptr2matrix(cm unsafe.Pointer) Matrix {
+	rows, cols := int(C.rowsSmatrix(cm)), int(C.colsSmatrix(cm))
+	m := make(Matrix, rows)
+	for i := 0; i < rows; i++ {
+		m[i] = make([]Serie, cols)
+		for j := 0; j < cols; j++ {
+			m[i][j] = ptr2serie(C.getSmatrix(cm, C.int(i), C.int(j)))
+		}
+	}
+	return m
+}
+
 func PolySimply(p Poly) (result Poly) {
 	cpoly := poly2ptr(p)
 	C.simplyPoly(cpoly)
@@ -123,3 +154,208 @@ func SerieCanonize(s Serie) (result Serie) {
 	C.freeSerie(cserie)
 	return
 }
+
+// SerieEqual tells whether the series are the same,
+// their canonical forms are compared.
+func SerieEqual(s1 Serie, s2 Serie) bool {
+	cs1 := serie2ptr(s1)
+	cs2 := serie2ptr(s2)
+	equal := C.equalSerie(cs1, cs2) != 0
+	C.freeSerie(cs1)
+	C.freeSerie(cs2)
+	return equal
+}
+
+func PolyInf(p1 Poly, p2 Poly) (result Poly) {
+	cp1 := poly2ptr(p1)
+	cp2 := poly2ptr(p2)
+	cout := C.infPoly(cp1, cp2)
+	result = ptr2poly(cout)
+	C.freePoly(cp1)
+	C.freePoly(cp2)
+	C.freePoly(cout)
+	return
+}
+
+// PolyRightDiv computes p1/p2, the greatest x such that x p2 <= p1.
+func PolyRightDiv(p1 Poly, p2 Poly) (result Poly) {
+	cp1 := poly2ptr(p1)
+	cp2 := poly2ptr(p2)
+	cout := C.fracPoly(cp1, cp2)
+	result = ptr2poly(cout)
+	C.freePoly(cp1)
+	C.freePoly(cp2)
+	C.freePoly(cout)
+	return
+}
+
+func PolyCausalProjection(p Poly) (result Poly) {
+	cpoly := poly2ptr(p)
+	cout := C.prcausPoly(cpoly)
+	result = ptr2poly(cout)
+	C.freePoly(cpoly)
+	C.freePoly(cout)
+	return
+}
+
+func SerieInf(s1 Serie, s2 Serie) (result Serie) {
+	cs1 := serie2ptr(s1)
+	cs2 := serie2ptr(s2)
+	cout := C.infSerie(cs1, cs2)
+	result = ptr2serie(cout)
+	C.freeSerie(cs1)
+	C.freeSerie(cs2)
+	C.freeSerie(cout)
+	return
+}
+
+// SerieRightDiv computes s1/s2, the greatest x such that x s2 <= s1.
+func SerieRightDiv(s1 Serie, s2 Serie) (result Serie) {
+	cs1 := serie2ptr(s1)
+	cs2 := serie2ptr(s2)
+	cout := C.fracSerie(cs1, cs2)
+	result = ptr2serie(cout)
+	C.freeSerie(cs1)
+	C.freeSerie(cs2)
+	C.freeSerie(cout)
+	return
+}
+
+// SerieDualDiv computes the dual residual of s by the monomial m,
+// the least x such that x m >= s.
+func SerieDualDiv(s Serie, m Gd) (result Serie) {
+	cserie := serie2ptr(s)
+	cgd := gd2ptr(m)
+	cout := C.dualfracSerie(cserie, cgd)
+	result = ptr2serie(cout)
+	C.freeSerie(cserie)
+	C.freeGd(cgd)
+	C.freeSerie(cout)
+	return
+}
+
+// SerieOdot computes the dual product, it gives Top if any of operands is Top.
+func SerieOdot(s1 Serie, s2 Serie) (result Serie) {
+	cs1 := serie2ptr(s1)
+	cs2 := serie2ptr(s2)
+	cout := C.odotSerie(cs1, cs2)
+	result = ptr2serie(cout)
+	C.freeSerie(cs1)
+	C.freeSerie(cs2)
+	C.freeSerie(cout)
+	return
+}
+
+// SerieCausalProjection gives the greatest causal serie less than s.
+func SerieCausalProjection(s Serie) (result Serie) {
+	cserie := serie2ptr(s)
+	cout := C.prcausSerie(cserie)
+	result = ptr2serie(cout)
+	C.freeSerie(cserie)
+	C.freeSerie(cout)
+	return
+}
+
+func matrixOp(m1 Matrix, m2 Matrix,
+	op func(unsafe.Pointer, unsafe.Pointer) unsafe.Pointer) (result Matrix) {
+	cm1 := matrix2ptr(m1)
+	cm2 := matrix2ptr(m2)
+	cout := op(cm1, cm2)
+	result = ptr2matrix(cout)
+	C.freeSmatrix(cm1)
+	C.freeSmatrix(cm2)
+	C.freeSmatrix(cout)
+	return
+}
+
+func MatrixOplus(m1 Matrix, m2 Matrix) (result Matrix) {
+	if isEmpty(m1) || m1.Rows() != m2.Rows() || m1.Cols() != m2.Cols() {
+		return matrixMismatch()
+	}
+	return matrixOp(m1, m2, func(a, b unsafe.Pointer) unsafe.Pointer {
+		return C.oplusSmatrix(a, b)
+	})
+}
+
+func MatrixOtimes(m1 Matrix, m2 Matrix) (result Matrix) {
+	if isEmpty(m1) || isEmpty(m2) || m1.Cols() != m2.Rows() {
+		return matrixMismatch()
+	}
+	return matrixOp(m1, m2, func(a, b unsafe.Pointer) unsafe.Pointer {
+		return C.otimesSmatrix(a, b)
+	})
+}
+
+func MatrixInf(m1 Matrix, m2 Matrix) (result Matrix) {
+	if isEmpty(m1) || m1.Rows() != m2.Rows() || m1.Cols() != m2.Cols() {
+		return matrixMismatch()
+	}
+	return matrixOp(m1, m2, func(a, b unsafe.Pointer) unsafe.Pointer {
+		return C.infSmatrix(a, b)
+	})
+}
+
+func MatrixOdot(m1 Matrix, m2 Matrix) (result Matrix) {
+	if isEmpty(m1) || isEmpty(m2) || m1.Cols() != m2.Rows() {
+		return matrixMismatch()
+	}
+	return matrixOp(m1, m2, func(a, b unsafe.Pointer) unsafe.Pointer {
+		return C.odotSmatrix(a, b)
+	})
+}
+
+// MatrixLeftDiv computes m1\m2, the greatest x such that m1 x <= m2.
+func MatrixLeftDiv(m1 Matrix, m2 Matrix) (result Matrix) {
+	if isEmpty(m1) || isEmpty(m2) || m1.Rows() != m2.Rows() {
+		return matrixMismatch()
+	}
+	return matrixOp(m2, m1, func(a, b unsafe.Pointer) unsafe.Pointer {
+		return C.lfracSmatrix(a, b)
+	})
+}
+
+// MatrixRightDiv computes m1/m2, the greatest x such that x m2 <= m1.
+func MatrixRightDiv(m1 Matrix, m2 Matrix) (result Matrix) {
+	if isEmpty(m1) || isEmpty(m2) || m1.Cols() != m2.Cols() {
+		return matrixMismatch()
+	}
+	return matrixOp(m1, m2, func(a, b unsafe.Pointer) unsafe.Pointer {
+		return C.rfracSmatrix(a, b)
+	})
+}
+
+// MatrixDualLeftDiv computes the dual residual of m2 by m1 on the left,
+// the least x such that m1 x >= m2, only the first monomial
+// of each entry of m1 is taken into account.
+func MatrixDualLeftDiv(m1 Matrix, m2 Matrix) (result Matrix) {
+	if isEmpty(m1) || isEmpty(m2) || m1.Rows() != m2.Rows() {
+		return matrixMismatch()
+	}
+	return matrixOp(m2, m1, func(a, b unsafe.Pointer) unsafe.Pointer {
+		return C.duallfracSmatrix(a, b)
+	})
+}
+
+func MatrixStar(m Matrix) (result Matrix) {
+	if isEmpty(m) || m.Rows() != m.Cols() {
+		return matrixMismatch()
+	}
+	cm := matrix2ptr(m)
+	cout := C.starSmatrix(cm)
+	result = ptr2matrix(cout)
+	C.freeSmatrix(cm)
+	C.freeSmatrix(cout)
+	return
+}
+
+func MatrixCausalProjection(m Matrix) (result Matrix) {
+	if isEmpty(m) {
+		return m
+	}
+	cm := matrix2ptr(m)
+	cout := C.prcausSmatrix(cm)
+	result = ptr2matrix(cout)
+	C.freeSmatrix(cm)
+	C.freeSmatrix(cout)
+	return
+}
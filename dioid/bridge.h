@@ -8,14 +8,17 @@ extern "C" {
 typedef void gd_;
 typedef void poly_;
 typedef void serie_;
+typedef void smatrix_;
 
 gd_ *newGd(int g, int d);
 poly_ *newPoly();
 serie_ *newSerie(poly_ *p, poly_ *q, gd_ *r);
+smatrix_ *newSmatrix(int row, int col);
 
 void freeGd(gd_ *p);
 void freePoly(poly_ *p);
 void freeSerie(serie_ *s);
+void freeSmatrix(smatrix_ *m);
 
 void appendPoly(poly_ *p, int g, int d);
 void simplyPoly(poly_ *p);
@@ -27,6 +30,10 @@ int getD(gd_ *m);
 poly_ *getP(poly_ *s);
 poly_ *getQ(poly_ *s);
 gd_ *getR(gd_ *s);
+int rowsSmatrix(smatrix_ *m);
+int colsSmatrix(smatrix_ *m);
+serie_ *getSmatrix(smatrix_ *m, int i, int j);
+void setSmatrix(smatrix_ *m, int i, int j, serie_ *s);
 
 poly_ *oplusPoly(poly_ *p1, poly_ *p2);
 poly_ *otimesPoly(poly_ *p1, poly_ *p2);
@@ -34,6 +41,24 @@ serie_ *oplusSerie(serie_ *s1, serie_ *s2);
 serie_ *otimesSerie(serie_ *s1, serie_ *s2);
 serie_ *starPoly(poly_ *p);
 serie_ *starSerie(serie_ *s);
+poly_ *infPoly(poly_ *p1, poly_ *p2);
+poly_ *fracPoly(poly_ *p1, poly_ *p2);
+poly_ *prcausPoly(poly_ *p);
+serie_ *infSerie(serie_ *s1, serie_ *s2);
+serie_ *fracSerie(serie_ *s1, serie_ *s2);
+serie_ *dualfracSerie(serie_ *s, gd_ *m);
+serie_ *odotSerie(serie_ *s1, serie_ *s2);
+serie_ *prcausSerie(serie_ *s);
+int equalSerie(serie_ *s1, serie_ *s2);
+smatrix_ *oplusSmatrix(smatrix_ *m1, smatrix_ *m2);
+smatrix_ *otimesSmatrix(smatrix_ *m1, smatrix_ *m2);
+smatrix_ *infSmatrix(smatrix_ *m1, smatrix_ *m2);
+smatrix_ *starSmatrix(smatrix_ *m);
+smatrix_ *lfracSmatrix(smatrix_ *m1, smatrix_ *m2);
+smatrix_ *rfracSmatrix(smatrix_ *m1, smatrix_ *m2);
+smatrix_ *odotSmatrix(smatrix_ *m1, smatrix_ *m2);
+smatrix_ *prcausSmatrix(smatrix_ *m);
+smatrix_ *duallfracSmatrix(smatrix_ *m1, smatrix_ *m2);
 
 #ifdef __cplusplus
 }
@@ -36,6 +36,124 @@ func (t *testSuite) TestPolyStar() {
 	t.Equal(a.String(), b.String())
 }
 
+func (t *testSuite) TestPolyStarSlopes() {
+	// g^2d^2 has a greater slope and comes after gd^2
+	data := Poly{{1, 2}, {2, 2}, {3, 6}}
+	t.Equal("(gd^2)*", PolyStar(data).String())
+}
+
+func (t *testSuite) TestPolyInf() {
+	a := Poly{{1, 1}, {2, 2}, {3, 5}}
+	b := PolyInf(Poly{{0, 2}, {3, 5}}, Poly{{1, 1}, {2, 6}})
+	t.Equal(a.String(), b.String())
+}
+
+func (t *testSuite) TestPolyDiv() {
+	a := Poly{{1, 2}, {3, 5}}
+	b := PolyLeftDiv(Poly{{1, 1}}, Poly{{2, 3}, {4, 6}})
+	c := PolyRightDiv(Poly{{2, 3}, {4, 6}}, Poly{{1, 1}})
+	t.Equal(a.String(), b.String())
+	t.Equal(a.String(), c.String())
+}
+
+func (t *testSuite) TestPolyCausalProjection() {
+	a := Poly{{0, 2}}
+	b := PolyCausalProjection(Poly{{-1, -3}, {0, 2}, {2, -1}})
+	t.Equal(a.String(), b.String())
+	a = Poly{{1, 2}, {3, 4}}
+	b = PolyCausalProjection(a)
+	t.Equal(a.String(), b.String())
+}
+
+func (t *testSuite) TestSerieInf() {
+	s1 := Serie{
+		P: Poly{{-1, -3}, {0, 0}},
+		Q: Poly{{2, 2}, {3, 3}},
+		R: Gd{2, 3},
+	}
+	s2 := Serie{
+		P: Poly{{0, 3}},
+		Q: Poly{{2, 6}},
+		R: Gd{1, 2},
+	}
+	a := Serie{
+		P: Poly{{0, 0}},
+		Q: Poly{{2, 2}, {3, 3}},
+		R: Gd{2, 3},
+	}
+	t.Equal(a.String(), SerieInf(s1, s2).String())
+}
+
+func (t *testSuite) TestSerieDiv() {
+	s1 := Serie{
+		P: Poly{{-1, -3}, {0, 0}},
+		Q: Poly{{2, 2}, {3, 3}},
+		R: Gd{2, 3},
+	}
+	s2 := Serie{
+		P: Poly{{0, 3}},
+		Q: Poly{{2, 6}},
+		R: Gd{1, 2},
+	}
+	a := Serie{
+		P: Poly{{1, 3}},
+		Q: Poly{{2, 6}},
+		R: Gd{1, 2},
+	}
+	b := SerieLeftDiv(s1, s2)
+	t.Equal(a.String(), b.String())
+	t.Equal(a.String(), SerieRightDiv(s2, s1).String())
+	// s1 x (s1\s2) <= s2
+	t.Equal(s2.String(), SerieOplus(SerieOtimes(s1, b), s2).String())
+}
+
+func (t *testSuite) TestSerieDualDiv() {
+	s := Serie{
+		P: Poly{{0, 3}},
+		Q: Poly{{2, 6}},
+		R: Gd{1, 2},
+	}
+	a := Serie{
+		P: Poly{{-1, 1}},
+		Q: Poly{{1, 4}},
+		R: Gd{1, 2},
+	}
+	t.Equal(a.String(), SerieDualDiv(s, Gd{1, 2}).String())
+}
+
+func (t *testSuite) TestSerieOdot() {
+	s := Serie{
+		P: Poly{{0, 3}},
+		Q: Poly{{2, 6}},
+		R: Gd{1, 2},
+	}
+	top := Serie{P: Poly{Eps}, Q: Poly{Top}, R: E}
+	t.Equal(Top.String(), SerieOdot(top, s).String())
+	m := Serie{P: Poly{Eps}, Q: Poly{{1, 1}}, R: E}
+	t.Equal(SerieOtimes(m, s).String(), SerieOdot(m, s).String())
+}
+
+func (t *testSuite) TestSerieCausalProjection() {
+	s := Serie{
+		P: Poly{{-1, -3}, {0, 0}},
+		Q: Poly{{2, 2}, {3, 3}},
+		R: Gd{2, 3},
+	}
+	a := Serie{
+		P: Poly{{0, 0}},
+		Q: Poly{{2, 2}, {3, 3}},
+		R: Gd{2, 3},
+	}
+	t.Equal(a.String(), SerieCausalProjection(s).String())
+}
+
+func (t *testSuite) TestSerieStarSlopes() {
+	// the star of the serie takes the one of gd^2 + g^4d^3 + g^5d^6 + g^5d,
+	// which read out of the polynomial before nj was kept in step
+	s := Serie{P: Poly{Eps}, Q: Poly{{4, 3}, {5, 6}, {5, 1}}, R: Gd{1, 2}}
+	t.Equal("e + g^4d^3 + g^5d^6x(gd^2)*", SerieStar(s).String())
+}
+
 func BenchmarkPolySimply(b *testing.B) {
 	data := Poly{
 		{1, 2}, {1, 2}, {3, 3}, {3, 4},
@@ -57,3 +175,36 @@ func BenchmarkPolyStar(b *testing.B) {
 		_ = PolyStar(data)
 	}
 }
+
+func (t *testSuite) TestSerieEqual() {
+	s1 := Serie{
+		P: Poly{{0, 0}},
+		Q: Poly{{1, 2}},
+		R: Gd{1, 2},
+	}
+	s2 := Serie{
+		P: Poly{Eps},
+		Q: Poly{{0, 0}},
+		R: Gd{1, 2},
+	}
+	// e + gd^2x(gd^2)* is (gd^2)*
+	t.True(SerieEqual(s1, s2))
+	s2.R = Gd{1, 3}
+	t.False(SerieEqual(s1, s2))
+}
+
+func (t *testSuite) TestSerieNonCausalPeriod() {
+	s := Serie{P: Poly{Eps}, Q: Poly{{1, 1}}, R: Gd{-1, 2}}
+	panicked := func(s Serie) (msg interface{}) {
+		defer func() {
+			msg = recover()
+		}()
+		SerieOplus(s, s)
+		return
+	}
+	t.Equal("dioid: the period of a serie should be causal, got g^-1d^2", panicked(s))
+	s.R = Gd{2, -1}
+	t.Equal("dioid: the period of a serie should be causal, got g^2d^-1", panicked(s))
+	s.R = Gd{0, 2}
+	t.Nil(panicked(s))
+}
@@ -1,186 +1,35 @@
 package dioid
 
-import (
-	"errors"
-	"fmt"
-	"regexp"
-	"strings"
-
-	"github.com/xlab/teg-workshop/util"
-)
-
-var (
-	regexSpace = regexp.MustCompile(`\s+`)
-	regexGd    = regexp.MustCompile(`(g\^-?\d+d\^-?\d+|(?:g|d)\^-?\d+|gd|e)`)
-	operators  = "+x*"
-)
-
+// Eval evaluates an expression over series. Besides + (oplus), x (otimes)
+// and * (star) it understands ∧ or & (inf), \ and / (left and right residuation,
+// a\b is the greatest x such that a x <= b and a/b the greatest x such that x b <= a)
+// and integer powers like (g^2d^3)^4.
+//
+// The errors are *SyntaxError, *NameError or *PeriodError, all of them
+// locate the faulty part of the expression.
 func Eval(expr string) (result Serie, err error) {
-	defer func() {
-		if e := recover(); e != nil {
-			err = fmt.Errorf("Invalid expression %v (%v)", expr, e)
-		}
-	}()
-
-	tokens := tokenise(expr)
-	postfix := convert2postfix(tokens)
-	if serie, err := evaluatePostfix(postfix); err != nil {
-		return Serie{}, errors.New("Invalid expression")
-	} else {
-		return serie, nil
-	}
-}
-
-func prec(op string) (result int) {
-	if op == "+" {
-		result = 1
-	} else if op == "x" {
-		result = 2
-	} else if op == "*" {
-		result = 3
-	}
-	return
-}
-
-func opGTE(op1, op2 string) bool {
-	return prec(op1) >= prec(op2)
-}
-
-func isOperator(token string) bool {
-	return strings.Contains(operators, token)
-}
-
-func isOperand(token string) bool {
-	return regexGd.MatchString(token)
+	return EvalEnv(expr, nil)
 }
 
-func convert2postfix(tokens []string) []string {
-	var stack util.Stack
-	var result []string
-	for _, token := range tokens {
-		if isOperator(token) {
-		OPERATOR:
-			for {
-				top, err := stack.Top()
-				if err != nil || top == "(" {
-					break OPERATOR
-				}
-				if opGTE(top.(string), token) {
-					pop, _ := stack.Pop()
-					result = append(result, pop.(string))
-				} else {
-					break OPERATOR
-				}
-				break OPERATOR
-			}
-			stack.Push(token)
-
-		} else if token == "(" {
-			stack.Push(token)
-		} else if token == ")" {
-		CLOSE_PAREN:
-			for {
-				top, err := stack.Top()
-				if err != nil || top == "(" {
-					stack.Pop() // pop off "("
-					break CLOSE_PAREN
-				} else {
-					pop, _ := stack.Pop()
-					result = append(result, pop.(string))
-				}
-			}
-		} else if isOperand(token) {
-			result = append(result, token)
-		}
-	}
-
-	for !stack.IsEmpty() {
-		pop, _ := stack.Pop()
-		result = append(result, pop.(string))
-	}
-
-	return result
-}
-
-func evaluatePostfix(postfix []string) (Serie, error) {
-	// log.Println("postifx", postfix)
-	var stack util.Stack
-	for _, token := range postfix {
-		if isOperand(token) {
-			gd, err := scanGd(token)
-			if err != nil {
-				return Serie{}, err
-			}
-			stack.Push(Serie{Q: Poly{gd}})
-		} else if isOperator(token) {
-			pop2 := func() (s1, s2 Serie, err error) {
-				op2, err := stack.Pop()
-				if err != nil {
-					return Serie{}, Serie{}, err
-				}
-				op1, err := stack.Pop()
-				if err != nil {
-					return Serie{}, Serie{}, err
-				}
-				return op1.(Serie), op2.(Serie), nil
-			}
-			pop1 := func() (s Serie, err error) {
-				op, err := stack.Pop()
-				if err != nil {
-					return Serie{}, err
-				}
-				return op.(Serie), nil
-			}
-
-			switch token {
-			case "*":
-				s, err := pop1()
-				if err != nil {
-					return Serie{}, err
-				}
-				// log.Printf("Starring %#v", s)
-				stack.Push(SerieStar(s))
-			case "x":
-				s1, s2, err := pop2()
-				if err != nil {
-					return Serie{}, err
-				}
-				// log.Printf("OTIMES series %#v AND %#v", s2, s1)
-				// not commutative! reverse order (postfix -> infix)
-				stack.Push(SerieOtimes(s2, s1))
-			case "+":
-				s1, s2, err := pop2()
-				if err != nil {
-					return Serie{}, err
-				}
-				// log.Printf("OPLUS series %#v AND %#v", s1, s2)
-				stack.Push(SerieOplus(s1, s2))
-			default:
-				return Serie{}, fmt.Errorf("unknown operator %v", token)
-			}
-		} else {
-			return Serie{}, fmt.Errorf("unknown token %v", token)
-		}
-	}
-
-	// log.Println("Dumping stack\n", stack.Dump())
-	tmp, err := stack.Pop()
+// EvalEnv evaluates a sequence of statements separated by ';',
+// a statement either binds a name like in "H1 = gd^3" or is an expression.
+// Names start with an upper case letter and are looked up in the bindings
+// made so far and then in env, which is left intact. The result is the value
+// of the last statement.
+func EvalEnv(expr string, env map[string]Serie) (result Serie, err error) {
+	prog, err := Parse(expr)
 	if err != nil {
 		return Serie{}, err
 	}
-	if result, ok := tmp.(Serie); !ok {
-		return Serie{}, fmt.Errorf("result is not a valid serie")
-	} else {
-		return result, nil
-	}
+	return prog.Eval(env)
 }
 
-func tokenise(expr string) []string {
-	spaced := regexGd.ReplaceAllString(expr, " ${1} ")
-	symbols := []string{"(", ")"}
-	for _, symbol := range symbols {
-		spaced = strings.Replace(spaced, symbol, fmt.Sprintf(" %s ", symbol), -1)
+// IsName tells whether the string can be used as a name in expressions.
+func IsName(token string) bool {
+	for i, r := range token {
+		if i == 0 && !isNameStart(r) || !isNamePart(r) {
+			return false
+		}
 	}
-	stripped := regexSpace.ReplaceAllString(strings.TrimSpace(spaced), "|")
-	return strings.Split(stripped, "|")
+	return len(token) > 0
 }
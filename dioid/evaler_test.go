@@ -39,3 +39,82 @@ func (t *testSuite) TestEvalMix() {
 	}
 	t.Equal(a.String(), b.String())
 }
+
+func (t *testSuite) TestEvalPrecedence() {
+	a, err := Eval("g^2 + d^2 x gd + g^3d^4")
+	if err != nil {
+		t.Error(err)
+	}
+	b, err := Eval("g^2 + (d^2 x gd) + g^3d^4")
+	if err != nil {
+		t.Error(err)
+	}
+	t.Equal(b.String(), a.String())
+}
+
+func (t *testSuite) TestEvalInf() {
+	a := SerieInf(
+		Serie{Q: Poly{{0, 2}, {3, 5}}},
+		Serie{Q: Poly{{1, 1}, {2, 6}}},
+	)
+	b, err := Eval("(d^2 + g^3d^5) ∧ (gd + g^2d^6)")
+	if err != nil {
+		t.Error(err)
+	}
+	c, err := Eval("(d^2 + g^3d^5) & (gd + g^2d^6)")
+	if err != nil {
+		t.Error(err)
+	}
+	t.Equal(a.String(), b.String())
+	t.Equal(a.String(), c.String())
+}
+
+func (t *testSuite) TestEvalDiv() {
+	a := SerieCanonize(Serie{Q: Poly{{1, 2}, {3, 5}}})
+	b, err := Eval(`gd \ (g^2d^3 + g^4d^6)`)
+	if err != nil {
+		t.Error(err)
+	}
+	c, err := Eval("(g^2d^3 + g^4d^6) / gd")
+	if err != nil {
+		t.Error(err)
+	}
+	t.Equal(a.String(), b.String())
+	t.Equal(a.String(), c.String())
+}
+
+func (t *testSuite) TestEvalPower() {
+	a := Serie{Q: Poly{{8, 12}}}
+	b, err := Eval("(g^2d^3)^4")
+	if err != nil {
+		t.Error(err)
+	}
+	t.Equal(a.String(), b.String())
+	c, err := Eval("(g^2d^3 + gd^2)^2")
+	if err != nil {
+		t.Error(err)
+	}
+	d, err := Eval("(g^2d^3 + gd^2) x (g^2d^3 + gd^2)")
+	if err != nil {
+		t.Error(err)
+	}
+	t.Equal(d.String(), c.String())
+}
+
+func (t *testSuite) TestEvalBindings() {
+	env := map[string]Serie{
+		"U": Serie{Q: Poly{{0, 1}}},
+	}
+	a, err := Eval("d^2 x gd^5 x d^1 x (gd)*")
+	if err != nil {
+		t.Error(err)
+	}
+	b, err := EvalEnv("H1 = d^2 x gd^5; H1 x U x (gd)*", env)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Equal(a.String(), b.String())
+	_, err = EvalEnv("H1 x U", env)
+	t.True(err != nil)
+	t.Equal(1, len(env))
+}
@@ -0,0 +1,142 @@
+package dioid
+
+// This file and poly.go, serie.go and smatrix.go are a port of the dioid library
+// in lib/ to Go, they follow the C++ code step by step so both backends give
+// the same results. See native.go for the entry points.
+
+// gdLess orders the monomials by γ and then by decreasing δ.
+func gdLess(m1, m2 Gd) bool {
+	return m1.G < m2.G || (m1.G == m2.G && m1.D > m2.D)
+}
+
+// gdGeq tells whether m1 >= m2 in the dioid.
+func gdGeq(m1, m2 Gd) bool {
+	return m1.G <= m2.G && m1.D >= m2.D
+}
+
+// gdLeq tells whether m1 <= m2 in the dioid.
+func gdLeq(m1, m2 Gd) bool {
+	return m1.G >= m2.G && m1.D <= m2.D
+}
+
+func gdInf(m1, m2 Gd) Gd {
+	result := m1
+	if m2.G > m1.G {
+		result.G = m2.G
+	}
+	if m2.D < m1.D {
+		result.D = m2.D
+	}
+	return result
+}
+
+func gdOtimes(m1, m2 Gd) (result Gd) {
+	switch {
+	case m1.G == Inf || m2.G == Inf:
+		result.G = Inf
+	case m1.G == _Inf || m2.G == _Inf:
+		result.G = _Inf
+	default:
+		result.G = m1.G + m2.G
+	}
+	switch {
+	case m1.D == _Inf || m2.D == _Inf:
+		result.D = _Inf
+	case m1.D == Inf || m2.D == Inf:
+		result.D = Inf
+	default:
+		result.D = m1.D + m2.D
+	}
+	return
+}
+
+// gdFrac computes m1/m2.
+func gdFrac(m1, m2 Gd) (result Gd) {
+	switch {
+	case m1.G == _Inf:
+		result.G = _Inf
+	case m1.G == Inf && m2.G == Inf:
+		result.G = _Inf
+	case m1.G == Inf:
+		result.G = Inf
+	case m2.G == Inf:
+		result.G = _Inf
+	case m2.G == _Inf:
+		result.G = Inf
+	default:
+		result.G = m1.G - m2.G
+	}
+	switch {
+	case m1.D == Inf:
+		result.D = Inf
+	case m1.D == _Inf && m2.D == _Inf:
+		result.D = Inf
+	case m1.D == _Inf:
+		result.D = _Inf
+	case m2.D == _Inf:
+		result.D = Inf
+	case m2.D == Inf:
+		result.D = _Inf
+	default:
+		result.D = m1.D - m2.D
+	}
+	return
+}
+
+// gdDualFrac computes the dual residual of m1 by m2.
+func gdDualFrac(m1, m2 Gd) (result Gd) {
+	switch {
+	case m1.G == Inf:
+		result.G = Inf
+	case m1.G == _Inf && m2.G == _Inf:
+		result.G = Inf
+	case m1.G == _Inf:
+		result.G = _Inf
+	case m2.G == _Inf:
+		result.G = Inf
+	case m2.G == Inf:
+		result.G = _Inf
+	default:
+		result.G = m1.G - m2.G
+	}
+	switch {
+	case m1.D == _Inf:
+		result.D = _Inf
+	case m1.D == Inf && m2.D == Inf:
+		result.D = _Inf
+	case m1.D == Inf:
+		result.D = Inf
+	case m2.D == Inf:
+		result.D = _Inf
+	case m2.D == _Inf:
+		result.D = Inf
+	default:
+		result.D = m1.D - m2.D
+	}
+	return
+}
+
+func gcd(a, b int) int {
+	for b > 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func lcm(a, b int) int {
+	return a * b / gcd(a, b)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,25 @@
+package dioid
+
+func (t *testSuite) TestGdLatex() {
+	t.Equal("e", E.Latex())
+	t.Equal("\\varepsilon", Eps.Latex())
+	t.Equal("\\gamma\\delta^{3}", Gd{1, 3}.Latex())
+	t.Equal("\\gamma^{-2}", Gd{-2, 0}.Latex())
+	t.Equal("\\gamma^{-\\infty}\\delta^{+\\infty}", Top.Latex())
+}
+
+func (t *testSuite) TestSerieLatex() {
+	s := Serie{
+		P: Poly{{0, 0}, {1, 2}},
+		Q: Poly{{2, 3}, {3, 5}},
+		R: Gd{2, 4},
+	}
+	t.Equal("e \\oplus \\gamma\\delta^{2} \\oplus "+
+		"(\\gamma^{2}\\delta^{3} \\oplus \\gamma^{3}\\delta^{5})(\\gamma^{2}\\delta^{4})^{\\ast}", s.Latex())
+	s = Serie{P: Poly{Eps}, Q: Poly{E}, R: Gd{1, 1}}
+	t.Equal("(\\gamma\\delta)^{\\ast}", s.Latex())
+	s = Serie{P: Poly{Eps}, Q: Poly{{0, 3}}, R: E}
+	t.Equal("\\delta^{3}", s.Latex())
+	s = Serie{P: Poly{Eps}, Q: Poly{Eps}, R: E}
+	t.Equal("\\varepsilon", s.Latex())
+}
@@ -0,0 +1,178 @@
+package dioid
+
+import "math/rand"
+
+// The tests below check the laws of the dioid on random series,
+// the series are compared through their canonical forms.
+
+type generator struct {
+	*rand.Rand
+}
+
+func newGenerator() generator {
+	return generator{rand.New(rand.NewSource(1))}
+}
+
+func (g generator) gd() Gd {
+	return Gd{g.Intn(6), g.Intn(9)}
+}
+
+func (g generator) poly() Poly {
+	p := make(Poly, 1+g.Intn(4))
+	for i := range p {
+		p[i] = g.gd()
+	}
+	return p
+}
+
+func (g generator) serie() Serie {
+	switch g.Intn(10) {
+	case 0:
+		return Serie{P: Poly{Eps}, Q: Poly{Eps}, R: E}
+	case 1:
+		return Serie{P: Poly{Eps}, Q: Poly{E}, R: E}
+	case 2:
+		return Serie{P: Poly{Eps}, Q: Poly{Top}, R: E}
+	case 3:
+		return Serie{P: g.poly(), Q: g.poly(), R: E}
+	case 4:
+		return Serie{P: g.poly(), Q: g.poly(), R: Gd{0, Inf}}
+	}
+	s := Serie{P: Poly{Eps}, Q: g.poly(), R: Gd{1 + g.Intn(3), 1 + g.Intn(4)}}
+	if g.Intn(2) > 0 {
+		s.P = g.poly()
+	}
+	return s
+}
+
+func (g generator) matrix(rows, cols int) Matrix {
+	m := NewMatrix(rows, cols)
+	for i := range m {
+		for j := range m[i] {
+			if g.Intn(3) > 0 {
+				m[i][j] = g.serie()
+			}
+		}
+	}
+	return m
+}
+
+const lawRuns = 200
+
+func (g generator) canonical() Serie {
+	return SerieCanonize(g.serie())
+}
+
+func (t *testSuite) TestLawOplusIdempotent() {
+	g := newGenerator()
+	for i := 0; i < lawRuns; i++ {
+		a := g.canonical()
+		t.Equal(a.String(), SerieOplus(a, a).String(), a.String())
+	}
+}
+
+func (t *testSuite) TestLawOplusCommutative() {
+	g := newGenerator()
+	for i := 0; i < lawRuns; i++ {
+		a, b := g.serie(), g.serie()
+		in := a.String() + "; " + b.String()
+		t.Equal(SerieOplus(a, b).String(), SerieOplus(b, a).String(), in)
+	}
+}
+
+func (t *testSuite) TestLawOtimesAssociative() {
+	g := newGenerator()
+	for i := 0; i < lawRuns; i++ {
+		a, b, c := g.serie(), g.serie(), g.serie()
+		in := a.String() + "; " + b.String() + "; " + c.String()
+		ab := SerieOtimes(a, b)
+		bc := SerieOtimes(b, c)
+		t.Equal(SerieOtimes(ab, c).String(), SerieOtimes(a, bc).String(), in)
+	}
+}
+
+func (t *testSuite) TestLawOtimesDistributive() {
+	g := newGenerator()
+	for i := 0; i < lawRuns; i++ {
+		a, b, c := g.serie(), g.serie(), g.serie()
+		in := a.String() + "; " + b.String() + "; " + c.String()
+		left := SerieOtimes(a, SerieOplus(b, c))
+		right := SerieOplus(SerieOtimes(a, b), SerieOtimes(a, c))
+		t.Equal(left.String(), right.String(), in)
+	}
+}
+
+func (t *testSuite) TestLawStar() {
+	g := newGenerator()
+	e := Serie{P: Poly{Eps}, Q: Poly{E}, R: E}
+	for i := 0; i < lawRuns; i++ {
+		a := g.serie()
+		star := SerieStar(a)
+		t.Equal(star.String(), SerieOplus(e, SerieOtimes(a, star)).String(), a.String())
+	}
+}
+
+// TestCanonizeUnique writes the same serie in other ways:
+// by unrolling the periodic part once and by doubling the period.
+func (t *testSuite) TestCanonizeUnique() {
+	g := newGenerator()
+	for i := 0; i < lawRuns; i++ {
+		a := g.canonical()
+		t.Equal(a.String(), SerieCanonize(a).String(), a.String())
+
+		if a.R.D == Inf || a.R.IsE() {
+			continue
+		}
+		unrolled := Serie{
+			P: PolyOplus(a.P, a.Q),
+			Q: PolyOtimes(a.Q, Poly{a.R}),
+			R: a.R,
+		}
+		doubled := Serie{
+			P: a.P,
+			Q: PolyOplus(a.Q, PolyOtimes(a.Q, Poly{a.R})),
+			R: Gd{2 * a.R.G, 2 * a.R.D},
+		}
+		t.Equal(a.String(), SerieCanonize(unrolled).String(), a.String())
+		t.Equal(a.String(), SerieCanonize(doubled).String(), a.String())
+	}
+}
+
+func (t *testSuite) TestEvalRoundTrip() {
+	g := newGenerator()
+	for i := 0; i < lawRuns; i++ {
+		a := g.canonical()
+		b, err := Eval(a.String())
+		if err != nil {
+			t.Error(a.String(), err)
+			continue
+		}
+		t.Equal(a.String(), b.String())
+	}
+}
+
+func (t *testSuite) TestGdRoundTrip() {
+	g := newGenerator()
+	values := []int{Inf, _Inf, 0, 1, -1}
+	for i := 0; i < lawRuns; i++ {
+		m := g.gd()
+		if g.Intn(2) > 0 {
+			m.G = values[g.Intn(len(values))]
+		}
+		if g.Intn(2) > 0 {
+			m.D = values[g.Intn(len(values))]
+		}
+		b, err := scanGd(m.String())
+		if err != nil {
+			t.Error(m.String(), err)
+			continue
+		}
+		t.Equal(m, b, m.String())
+	}
+}
+
+func (t *testSuite) TestSerieStringE() {
+	t.Equal("e", Serie{P: Poly{Eps}, Q: Poly{E}, R: E}.String())
+	t.Equal("g^-1 + e", Serie{P: Poly{{-1, 0}}, Q: Poly{E}, R: E}.String())
+	t.Equal("(gd)*", Serie{P: Poly{Eps}, Q: Poly{E}, R: Gd{1, 1}}.String())
+}
@@ -0,0 +1,202 @@
+package dioid
+
+import (
+	"fmt"
+	"unicode"
+)
+
+const (
+	tokenEOF = iota
+	tokenGd
+	tokenName
+	tokenPower
+	tokenOperator
+	tokenOpenParen
+	tokenCloseParen
+	tokenAssign
+	tokenSemicolon
+)
+
+// Position locates a part of an expression,
+// the column is counted in characters starting from zero.
+type Position struct {
+	Column, Length int
+}
+
+func (p Position) Pos() Position {
+	return p
+}
+
+// to spans the positions from p up to the end of p2.
+func (p Position) to(p2 Position) Position {
+	return Position{p.Column, p2.Column + p2.Length - p.Column}
+}
+
+// PositionError is an error that knows which part of the expression it refers to.
+type PositionError interface {
+	error
+	Pos() Position
+}
+
+// SyntaxError is reported for expressions that cannot be parsed.
+type SyntaxError struct {
+	Position
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Msg, e.Column+1)
+}
+
+type token struct {
+	Position
+	kind int
+	text string
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+type lexer struct {
+	input  []rune
+	pos    int
+	tokens []token
+}
+
+func (l *lexer) peek(n int) rune {
+	if l.pos+n < len(l.input) {
+		return l.input[l.pos+n]
+	}
+	return 0
+}
+
+func (l *lexer) emit(kind, start int) {
+	l.tokens = append(l.tokens, token{
+		Position: Position{start, l.pos - start},
+		kind:     kind,
+		text:     string(l.input[start:l.pos]),
+	})
+}
+
+func (l *lexer) errorf(start int, format string, args ...interface{}) error {
+	return &SyntaxError{
+		Position: Position{start, l.pos - start + 1},
+		Msg:      fmt.Sprintf(format, args...),
+	}
+}
+
+// exponent reads the ^n part of a monomial, n may be an integer or inf.
+func (l *lexer) exponent() error {
+	if l.peek(0) != '^' {
+		return nil
+	}
+	start := l.pos
+	l.pos++
+	if l.peek(0) == '-' {
+		l.pos++
+	}
+	switch {
+	case isDigit(l.peek(0)):
+		for isDigit(l.peek(0)) {
+			l.pos++
+		}
+	case l.peek(0) == 'i' && l.peek(1) == 'n' && l.peek(2) == 'f':
+		l.pos += 3
+	default:
+		return l.errorf(start, "invalid exponent")
+	}
+	return nil
+}
+
+func (l *lexer) monomial() error {
+	start := l.pos
+	switch l.peek(0) {
+	case 'e':
+		l.pos++
+		if l.peek(0) == 'p' && l.peek(1) == 's' {
+			l.pos += 2
+		}
+	default:
+		if l.peek(0) == 'g' {
+			l.pos++
+			if err := l.exponent(); err != nil {
+				return err
+			}
+		}
+		if l.peek(0) == 'd' {
+			l.pos++
+			if err := l.exponent(); err != nil {
+				return err
+			}
+		}
+	}
+	l.emit(tokenGd, start)
+	return nil
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// Names are made of ASCII letters, digits and '_', they start with
+// an upper case letter or '_'. IsName follows the same rules.
+func isNameStart(r rune) bool {
+	return r == '_' || r >= 'A' && r <= 'Z'
+}
+
+func isNamePart(r rune) bool {
+	return isNameStart(r) || r >= 'a' && r <= 'z' || isDigit(r)
+}
+
+func lex(expr string) ([]token, error) {
+	l := &lexer{input: []rune(expr)}
+	for l.pos < len(l.input) {
+		r := l.peek(0)
+		start := l.pos
+		switch {
+		case unicode.IsSpace(r):
+			l.pos++
+		case r == 'g', r == 'd', r == 'e':
+			if err := l.monomial(); err != nil {
+				return nil, err
+			}
+		case isNameStart(r):
+			for isNamePart(l.peek(0)) {
+				l.pos++
+			}
+			l.emit(tokenName, start)
+		case r == '^':
+			l.pos++
+			if !isDigit(l.peek(0)) {
+				return nil, l.errorf(start, "power should be a non-negative integer")
+			}
+			for isDigit(l.peek(0)) {
+				l.pos++
+			}
+			l.emit(tokenPower, start)
+		case r == '+', r == '∧', r == '&', r == 'x', r == '\\', r == '/', r == '*':
+			l.pos++
+			l.emit(tokenOperator, start)
+		case r == '(':
+			l.pos++
+			l.emit(tokenOpenParen, start)
+		case r == ')':
+			l.pos++
+			l.emit(tokenCloseParen, start)
+		case r == '=':
+			l.pos++
+			l.emit(tokenAssign, start)
+		case r == ';':
+			l.pos++
+			l.emit(tokenSemicolon, start)
+		default:
+			return nil, l.errorf(start, "unexpected character '%c'", r)
+		}
+	}
+	l.emit(tokenEOF, l.pos)
+	return l.tokens, nil
+}
@@ -1,3 +1,6 @@
+//go:build !purego
+// +build !purego
+
 #include "lib/gd.cpp"
 #include "lib/poly.cpp"
 #include "lib/serie.cpp"
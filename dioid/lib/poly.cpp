@@ -874,7 +874,7 @@ poly prcaus(poly& p)
     poly local;
     int i = (p.getn() - 1);
 
-    while (p.getpol(i).getd() >= 0 && p.getpol(i).getg() >= 0 && i >= 0) {
+    while (i >= 0 && p.getpol(i).getd() >= 0 && p.getpol(i).getg() >= 0) {
         local.add(p.getpol(i));
         --i;
     }
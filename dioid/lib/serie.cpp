@@ -1130,7 +1130,9 @@ serie star(poly poly1)
 
             poly1.popj(i); // on ote l'��ent du polyn�e il est trait� if ((int)i<nj) nj--; //l'��ent nj est d�lac�si n�essaire
             --i;
-            --nj; // bug fixed june 2012 Rio de Janeiro nj will be used in the next we don't have to loose it
+            if ((int)i + 1 < nj) { // nj only moves when a monomial before it is removed
+                --nj;
+            }
         } // fin du if sur la pente
     } // fin du for sur i
 
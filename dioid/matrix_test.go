@@ -0,0 +1,55 @@
+package dioid
+
+import "testing"
+
+func testMatrix() Matrix {
+	m := NewMatrix(2, 2)
+	m[0][1] = Serie{P: Poly{Eps}, Q: Poly{{1, 3}}, R: E}
+	m[1][0] = Serie{P: Poly{Eps}, Q: Poly{{0, 2}}, R: E}
+	return m
+}
+
+func (t *testSuite) TestMatrixOplus() {
+	a := testMatrix()
+	b := NewMatrix(2, 2)
+	b[0][1] = Serie{P: Poly{Eps}, Q: Poly{{0, 5}}, R: E}
+	c := MatrixOplus(a, b)
+	t.Equal("[eps, d^5; d^2, eps]", c.String())
+	t.Equal("[eps]", MatrixOplus(a, NewMatrix(2, 1)).String())
+}
+
+func (t *testSuite) TestMatrixStar() {
+	a := MatrixStar(testMatrix())
+	t.Equal("[(gd^5)*, gd^3x(gd^5)*; d^2x(gd^5)*, (gd^5)*]", a.String())
+}
+
+func (t *testSuite) TestMatrixLeftDiv() {
+	a := MatrixStar(testMatrix())
+	u := NewMatrix(2, 1)
+	u[0][0] = Serie{P: Poly{Eps}, Q: Poly{{0, 1}}, R: E}
+	y := MatrixOtimes(a, u)
+	t.Equal("[dx(gd^5)*; d^3x(gd^5)*]", y.String())
+	x := MatrixLeftDiv(a, y)
+	t.Equal(y.String(), MatrixOtimes(a, x).String())
+}
+
+func (t *testSuite) TestMatrixInf() {
+	a := testMatrix()
+	t.Equal(a.String(), MatrixInf(a, MatrixStar(a)).String())
+}
+
+func (t *testSuite) TestMatrixCausalProjection() {
+	a := testMatrix()
+	a[0][0] = Serie{P: Poly{Eps}, Q: Poly{{-2, 1}}, R: E}
+	t.Equal("[d, gd^3; d^2, eps]", MatrixCausalProjection(a).String())
+}
+
+func BenchmarkMatrixStar(b *testing.B) {
+	m := NewMatrix(8, 8)
+	for i := 0; i < 8; i++ {
+		m[(i+1)%8][i] = Serie{P: Poly{Eps}, Q: Poly{{i % 2, i + 1}}, R: E}
+	}
+	for i := 0; i < b.N; i++ {
+		_ = MatrixStar(m)
+	}
+}
@@ -0,0 +1,183 @@
+package dioid
+
+// The native functions below have the signatures of the functions
+// in bridge.go, they are used instead of the bridge when building with
+// the purego tag. The conversions mimic the bridge so that the
+// results are the same monomial by monomial.
+
+func polyFrom(p Poly) poly {
+	result := newPoly(Eps)
+	for _, m := range p {
+		result.add(m)
+	}
+	return result
+}
+
+func (p poly) Poly() Poly {
+	return append(Poly(nil), p.data...)
+}
+
+func serieFrom(s Serie) serie {
+	if !s.R.Causal() {
+		panic("dioid: the period of a serie should be causal, got " + s.R.String())
+	}
+	result := serie{p: polyFrom(s.P), q: polyFrom(s.Q), r: s.R}
+	result.canon()
+	return result
+}
+
+func (s serie) Serie() Serie {
+	return Serie{s.p.Poly(), s.q.Poly(), s.r}
+}
+
+func matrixFrom(m Matrix) smatrix {
+	result := newSmatrix(m.Rows(), m.Cols())
+	for i, row := range m {
+		for j, s := range row {
+			result.data[i][j] = serieFrom(s)
+		}
+	}
+	return result
+}
+
+func (m smatrix) Matrix() Matrix {
+	result := make(Matrix, m.row)
+	for i := range result {
+		result[i] = make([]Serie, m.col)
+		for j := range result[i] {
+			result[i][j] = m.data[i][j].Serie()
+		}
+	}
+	return result
+}
+
+func nativePolySimply(p Poly) Poly {
+	return polyFrom(p).simplified().Poly()
+}
+
+func nativePolyStar(p Poly) Serie {
+	return starSerie(operand(polyFrom(p).simplified())).Serie()
+}
+
+func nativeSerieStar(s Serie) Serie {
+	return starSerie(serieFrom(s)).Serie()
+}
+
+func nativePolyOplus(p1 Poly, p2 Poly) Poly {
+	return oplusPoly(polyFrom(p1), polyFrom(p2)).Poly()
+}
+
+func nativePolyOtimes(p1 Poly, p2 Poly) Poly {
+	return otimesPoly(polyFrom(p1), polyFrom(p2)).Poly()
+}
+
+func nativeSerieOplus(s1 Serie, s2 Serie) Serie {
+	return oplusSerie(serieFrom(s1), serieFrom(s2)).Serie()
+}
+
+func nativeSerieOtimes(s1 Serie, s2 Serie) Serie {
+	return otimesSerie(serieFrom(s1), serieFrom(s2)).Serie()
+}
+
+func nativeSerieCanonize(s Serie) Serie {
+	return serieFrom(s).Serie()
+}
+
+func nativeSerieEqual(s1 Serie, s2 Serie) bool {
+	return serieFrom(s1).equals(serieFrom(s2))
+}
+
+func nativePolyInf(p1 Poly, p2 Poly) Poly {
+	return infPoly(polyFrom(p1), polyFrom(p2)).Poly()
+}
+
+func nativePolyRightDiv(p1 Poly, p2 Poly) Poly {
+	return fracPoly(polyFrom(p1).simplified(), polyFrom(p2).simplified()).Poly()
+}
+
+func nativePolyCausalProjection(p Poly) Poly {
+	return prcausPoly(polyFrom(p).simplified()).Poly()
+}
+
+func nativeSerieInf(s1 Serie, s2 Serie) Serie {
+	return infSerie(serieFrom(s1), serieFrom(s2)).Serie()
+}
+
+func nativeSerieRightDiv(s1 Serie, s2 Serie) Serie {
+	return fracSerie(serieFrom(s1), serieFrom(s2)).Serie()
+}
+
+func nativeSerieDualDiv(s Serie, m Gd) Serie {
+	return dualfracSerieGd(serieFrom(s), m).Serie()
+}
+
+func nativeSerieOdot(s1 Serie, s2 Serie) Serie {
+	return odotSerie(serieFrom(s1), serieFrom(s2)).Serie()
+}
+
+func nativeSerieCausalProjection(s Serie) Serie {
+	return prcausSerie(serieFrom(s)).Serie()
+}
+
+func nativeMatrixOplus(m1 Matrix, m2 Matrix) Matrix {
+	if isEmpty(m1) || m1.Rows() != m2.Rows() || m1.Cols() != m2.Cols() {
+		return matrixMismatch()
+	}
+	return oplusSmatrix(matrixFrom(m1), matrixFrom(m2)).Matrix()
+}
+
+func nativeMatrixOtimes(m1 Matrix, m2 Matrix) Matrix {
+	if isEmpty(m1) || isEmpty(m2) || m1.Cols() != m2.Rows() {
+		return matrixMismatch()
+	}
+	return otimesSmatrix(matrixFrom(m1), matrixFrom(m2)).Matrix()
+}
+
+func nativeMatrixInf(m1 Matrix, m2 Matrix) Matrix {
+	if isEmpty(m1) || m1.Rows() != m2.Rows() || m1.Cols() != m2.Cols() {
+		return matrixMismatch()
+	}
+	return infSmatrix(matrixFrom(m1), matrixFrom(m2)).Matrix()
+}
+
+func nativeMatrixOdot(m1 Matrix, m2 Matrix) Matrix {
+	if isEmpty(m1) || isEmpty(m2) || m1.Cols() != m2.Rows() {
+		return matrixMismatch()
+	}
+	return odotSmatrix(matrixFrom(m1), matrixFrom(m2)).Matrix()
+}
+
+func nativeMatrixLeftDiv(m1 Matrix, m2 Matrix) Matrix {
+	if isEmpty(m1) || isEmpty(m2) || m1.Rows() != m2.Rows() {
+		return matrixMismatch()
+	}
+	return lfracSmatrix(matrixFrom(m2), matrixFrom(m1)).Matrix()
+}
+
+func nativeMatrixRightDiv(m1 Matrix, m2 Matrix) Matrix {
+	if isEmpty(m1) || isEmpty(m2) || m1.Cols() != m2.Cols() {
+		return matrixMismatch()
+	}
+	return rfracSmatrix(matrixFrom(m1), matrixFrom(m2)).Matrix()
+}
+
+func nativeMatrixDualLeftDiv(m1 Matrix, m2 Matrix) Matrix {
+	if isEmpty(m1) || isEmpty(m2) || m1.Rows() != m2.Rows() {
+		return matrixMismatch()
+	}
+	return duallfracSmatrix(matrixFrom(m2), matrixFrom(m1)).Matrix()
+}
+
+func nativeMatrixStar(m Matrix) Matrix {
+	if isEmpty(m) || m.Rows() != m.Cols() {
+		return matrixMismatch()
+	}
+	return starSmatrix(matrixFrom(m)).Matrix()
+}
+
+func nativeMatrixCausalProjection(m Matrix) Matrix {
+	if isEmpty(m) {
+		return m
+	}
+	return prcausSmatrix(matrixFrom(m)).Matrix()
+}
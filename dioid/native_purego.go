@@ -0,0 +1,124 @@
+//go:build purego
+// +build purego
+
+package dioid
+
+// With the purego tag the operations are computed by the port
+// of the dioid library in native.go, cgo is not required.
+
+func PolySimply(p Poly) Poly {
+	return nativePolySimply(p)
+}
+
+func PolyStar(p Poly) Serie {
+	return nativePolyStar(p)
+}
+
+func SerieStar(s Serie) Serie {
+	return nativeSerieStar(s)
+}
+
+func PolyOplus(p1 Poly, p2 Poly) Poly {
+	return nativePolyOplus(p1, p2)
+}
+
+func PolyOtimes(p1 Poly, p2 Poly) Poly {
+	return nativePolyOtimes(p1, p2)
+}
+
+func SerieOplus(s1 Serie, s2 Serie) Serie {
+	return nativeSerieOplus(s1, s2)
+}
+
+func SerieOtimes(s1 Serie, s2 Serie) Serie {
+	return nativeSerieOtimes(s1, s2)
+}
+
+func SerieCanonize(s Serie) Serie {
+	return nativeSerieCanonize(s)
+}
+
+// SerieEqual tells whether the series are the same,
+// their canonical forms are compared.
+func SerieEqual(s1 Serie, s2 Serie) bool {
+	return nativeSerieEqual(s1, s2)
+}
+
+func PolyInf(p1 Poly, p2 Poly) Poly {
+	return nativePolyInf(p1, p2)
+}
+
+// PolyRightDiv computes p1/p2, the greatest x such that x p2 <= p1.
+func PolyRightDiv(p1 Poly, p2 Poly) Poly {
+	return nativePolyRightDiv(p1, p2)
+}
+
+func PolyCausalProjection(p Poly) Poly {
+	return nativePolyCausalProjection(p)
+}
+
+func SerieInf(s1 Serie, s2 Serie) Serie {
+	return nativeSerieInf(s1, s2)
+}
+
+// SerieRightDiv computes s1/s2, the greatest x such that x s2 <= s1.
+func SerieRightDiv(s1 Serie, s2 Serie) Serie {
+	return nativeSerieRightDiv(s1, s2)
+}
+
+// SerieDualDiv computes the dual residual of s by the monomial m,
+// the least x such that x m >= s.
+func SerieDualDiv(s Serie, m Gd) Serie {
+	return nativeSerieDualDiv(s, m)
+}
+
+// SerieOdot computes the dual product, it gives Top if any of operands is Top.
+func SerieOdot(s1 Serie, s2 Serie) Serie {
+	return nativeSerieOdot(s1, s2)
+}
+
+// SerieCausalProjection gives the greatest causal serie less than s.
+func SerieCausalProjection(s Serie) Serie {
+	return nativeSerieCausalProjection(s)
+}
+
+func MatrixOplus(m1 Matrix, m2 Matrix) Matrix {
+	return nativeMatrixOplus(m1, m2)
+}
+
+func MatrixOtimes(m1 Matrix, m2 Matrix) Matrix {
+	return nativeMatrixOtimes(m1, m2)
+}
+
+func MatrixInf(m1 Matrix, m2 Matrix) Matrix {
+	return nativeMatrixInf(m1, m2)
+}
+
+func MatrixOdot(m1 Matrix, m2 Matrix) Matrix {
+	return nativeMatrixOdot(m1, m2)
+}
+
+// MatrixLeftDiv computes m1\m2, the greatest x such that m1 x <= m2.
+func MatrixLeftDiv(m1 Matrix, m2 Matrix) Matrix {
+	return nativeMatrixLeftDiv(m1, m2)
+}
+
+// MatrixRightDiv computes m1/m2, the greatest x such that x m2 <= m1.
+func MatrixRightDiv(m1 Matrix, m2 Matrix) Matrix {
+	return nativeMatrixRightDiv(m1, m2)
+}
+
+// MatrixDualLeftDiv computes the dual residual of m2 by m1 on the left,
+// the least x such that m1 x >= m2, only the first monomial
+// of each entry of m1 is taken into account.
+func MatrixDualLeftDiv(m1 Matrix, m2 Matrix) Matrix {
+	return nativeMatrixDualLeftDiv(m1, m2)
+}
+
+func MatrixStar(m Matrix) Matrix {
+	return nativeMatrixStar(m)
+}
+
+func MatrixCausalProjection(m Matrix) Matrix {
+	return nativeMatrixCausalProjection(m)
+}
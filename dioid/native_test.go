@@ -0,0 +1,73 @@
+//go:build !purego
+// +build !purego
+
+package dioid
+
+// The tests below compare the port in native.go with the dioid
+// library on random causal inputs, the results must be identical.
+
+func (t *testSuite) TestMatrixRoundTrip() {
+	a := testMatrix()
+	a[0][0] = Serie{
+		P: Poly{{-1, -3}, {0, 0}},
+		Q: Poly{{2, 2}, {3, 3}},
+		R: Gd{2, 3},
+	}
+	cm := matrix2ptr(a)
+	b := ptr2matrix(cm)
+	t.Equal(a.String(), b.String())
+	t.Equal(2, b.Rows())
+	t.Equal(2, b.Cols())
+}
+
+const differentialRuns = 200
+
+func (t *testSuite) TestNativePoly() {
+	g := newGenerator()
+	for i := 0; i < differentialRuns; i++ {
+		p1, p2 := g.poly(), g.poly()
+		in := p1.String() + "; " + p2.String()
+		t.Equal(PolySimply(p1).String(), nativePolySimply(p1).String(), in)
+		t.Equal(PolyStar(p1).String(), nativePolyStar(p1).String(), in)
+		t.Equal(PolyOplus(p1, p2).String(), nativePolyOplus(p1, p2).String(), in)
+		t.Equal(PolyOtimes(p1, p2).String(), nativePolyOtimes(p1, p2).String(), in)
+		t.Equal(PolyInf(p1, p2).String(), nativePolyInf(p1, p2).String(), in)
+		t.Equal(PolyRightDiv(p1, p2).String(), nativePolyRightDiv(p1, p2).String(), in)
+		t.Equal(PolyCausalProjection(p1).String(), nativePolyCausalProjection(p1).String(), in)
+	}
+}
+
+func (t *testSuite) TestNativeSerie() {
+	g := newGenerator()
+	for i := 0; i < differentialRuns; i++ {
+		s1, s2, m := g.serie(), g.serie(), g.gd()
+		in := s1.String() + "; " + s2.String()
+		t.Equal(SerieCanonize(s1).String(), nativeSerieCanonize(s1).String(), in)
+		t.Equal(SerieStar(s1).String(), nativeSerieStar(s1).String(), in)
+		t.Equal(SerieOplus(s1, s2).String(), nativeSerieOplus(s1, s2).String(), in)
+		t.Equal(SerieOtimes(s1, s2).String(), nativeSerieOtimes(s1, s2).String(), in)
+		t.Equal(SerieInf(s1, s2).String(), nativeSerieInf(s1, s2).String(), in)
+		t.Equal(SerieRightDiv(s1, s2).String(), nativeSerieRightDiv(s1, s2).String(), in)
+		t.Equal(SerieDualDiv(s1, m).String(), nativeSerieDualDiv(s1, m).String(), in)
+		t.Equal(SerieOdot(s1, s2).String(), nativeSerieOdot(s1, s2).String(), in)
+		t.Equal(SerieCausalProjection(s1).String(), nativeSerieCausalProjection(s1).String(), in)
+		t.Equal(SerieEqual(s1, s2), nativeSerieEqual(s1, s2), in)
+		t.Equal(SerieEqual(s1, SerieOplus(s1, s1)), nativeSerieEqual(s1, SerieOplus(s1, s1)), in)
+	}
+}
+
+func (t *testSuite) TestNativeMatrix() {
+	g := newGenerator()
+	for i := 0; i < differentialRuns/10; i++ {
+		n := 1 + g.Intn(3)
+		m1, m2 := g.matrix(n, n), g.matrix(n, n)
+		in := m1.String() + "; " + m2.String()
+		t.Equal(MatrixOplus(m1, m2).String(), nativeMatrixOplus(m1, m2).String(), in)
+		t.Equal(MatrixOtimes(m1, m2).String(), nativeMatrixOtimes(m1, m2).String(), in)
+		t.Equal(MatrixInf(m1, m2).String(), nativeMatrixInf(m1, m2).String(), in)
+		t.Equal(MatrixLeftDiv(m1, m2).String(), nativeMatrixLeftDiv(m1, m2).String(), in)
+		t.Equal(MatrixRightDiv(m1, m2).String(), nativeMatrixRightDiv(m1, m2).String(), in)
+		t.Equal(MatrixStar(m1).String(), nativeMatrixStar(m1).String(), in)
+		t.Equal(MatrixCausalProjection(m1).String(), nativeMatrixCausalProjection(m1).String(), in)
+	}
+}
@@ -0,0 +1,323 @@
+package dioid
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Node is a node of the syntax tree of an expression.
+type Node interface {
+	Pos() Position
+	Eval(env map[string]Serie) (Serie, error)
+}
+
+// GdNode is a monomial.
+type GdNode struct {
+	Position
+	Gd Gd
+}
+
+// NameNode refers to a serie bound to a name.
+type NameNode struct {
+	Position
+	Name string
+}
+
+// BinaryNode applies one of + ∧ x \ / to its operands.
+type BinaryNode struct {
+	Position
+	Op          string
+	Left, Right Node
+}
+
+// StarNode is the Kleene star of its operand.
+type StarNode struct {
+	Position
+	X Node
+}
+
+// PowerNode is the N-th power of its operand.
+type PowerNode struct {
+	Position
+	X Node
+	N int
+}
+
+// Statement binds the value of an expression to a name,
+// Name is empty for plain expressions.
+type Statement struct {
+	Name string
+	Expr Node
+}
+
+// Program is a sequence of statements separated by ';'.
+type Program []*Statement
+
+// NameError is reported when a name is not bound to anything.
+type NameError struct {
+	Position
+	Name string
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("unknown name %s at column %d", e.Name, e.Column+1)
+}
+
+// PeriodError is reported when a part of the expression evaluates
+// to a serie with a non-causal period, the library computes only
+// with causal ones.
+type PeriodError struct {
+	Position
+	R Gd
+}
+
+func (e *PeriodError) Error() string {
+	return fmt.Sprintf("non-causal period %s at column %d", e.R, e.Column+1)
+}
+
+func causal(pos Position, s Serie) (Serie, error) {
+	if !s.R.Causal() {
+		return Serie{}, &PeriodError{pos, s.R}
+	}
+	return s, nil
+}
+
+func (n *GdNode) Eval(env map[string]Serie) (Serie, error) {
+	return Serie{P: Poly{Eps}, Q: Poly{n.Gd}, R: E}, nil
+}
+
+func (n *NameNode) Eval(env map[string]Serie) (Serie, error) {
+	if s, ok := env[n.Name]; ok {
+		return causal(n.Position, s)
+	}
+	return Serie{}, &NameError{n.Position, n.Name}
+}
+
+func (n *BinaryNode) Eval(env map[string]Serie) (Serie, error) {
+	s1, err := n.Left.Eval(env)
+	if err != nil {
+		return Serie{}, err
+	}
+	s2, err := n.Right.Eval(env)
+	if err != nil {
+		return Serie{}, err
+	}
+	switch n.Op {
+	case "+":
+		return causal(n.Position, SerieOplus(s1, s2))
+	case "∧", "&":
+		return causal(n.Position, SerieInf(s1, s2))
+	case "x":
+		return causal(n.Position, SerieOtimes(s1, s2))
+	case "\\":
+		return causal(n.Position, SerieLeftDiv(s1, s2))
+	case "/":
+		return causal(n.Position, SerieRightDiv(s1, s2))
+	}
+	return Serie{}, &SyntaxError{n.Position, "unknown operator " + n.Op}
+}
+
+func (n *StarNode) Eval(env map[string]Serie) (Serie, error) {
+	s, err := n.X.Eval(env)
+	if err != nil {
+		return Serie{}, err
+	}
+	return causal(n.Position, SerieStar(s))
+}
+
+func (n *PowerNode) Eval(env map[string]Serie) (Serie, error) {
+	s, err := n.X.Eval(env)
+	if err != nil {
+		return Serie{}, err
+	}
+	result := Serie{P: Poly{Eps}, Q: Poly{E}, R: E}
+	for k := n.N; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			result = SerieOtimes(result, s)
+		}
+		if k > 1 {
+			s = SerieOtimes(s, s)
+		}
+	}
+	return causal(n.Position, result)
+}
+
+// Eval evaluates the statements in order, the bindings are made
+// in a copy of env. The result is the value of the last statement.
+func (prog Program) Eval(env map[string]Serie) (result Serie, err error) {
+	vars := make(map[string]Serie, len(env)+len(prog))
+	for name, s := range env {
+		vars[name] = s
+	}
+	for _, stmt := range prog {
+		if result, err = stmt.Expr.Eval(vars); err != nil {
+			return Serie{}, err
+		}
+		if len(stmt.Name) > 0 {
+			vars[stmt.Name] = result
+		}
+	}
+	return
+}
+
+// The grammar is
+//
+//	program   = statement { ";" statement }
+//	statement = [ name "=" ] expr
+//	expr      = term { ( "+" | "∧" | "&" ) term }
+//	term      = factor { ( "x" | "\" | "/" ) factor }
+//	factor    = primary { "*" | "^" integer }
+//	primary   = monomial | name | "(" expr ")"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func unexpected(t token) error {
+	length := t.Length
+	if length < 1 {
+		length = 1
+	}
+	return &SyntaxError{Position{t.Column, length}, "unexpected " + t.String()}
+}
+
+// Parse builds the syntax tree of an expression.
+func Parse(expr string) (prog Program, err error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	for {
+		switch p.peek().kind {
+		case tokenSemicolon:
+			p.next()
+			continue
+		case tokenEOF:
+			if len(prog) < 1 {
+				return nil, &SyntaxError{Position{0, len([]rune(expr))}, "empty expression"}
+			}
+			return prog, nil
+		}
+		stmt, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		prog = append(prog, stmt)
+		switch t := p.peek(); t.kind {
+		case tokenSemicolon, tokenEOF:
+		default:
+			return nil, &SyntaxError{Position{t.Column, t.Length}, "missing operator before " + t.String()}
+		}
+	}
+}
+
+func (p *parser) statement() (*Statement, error) {
+	stmt := &Statement{}
+	if p.peek().kind == tokenName && p.tokens[p.pos+1].kind == tokenAssign {
+		stmt.Name = p.next().text
+		p.next()
+	}
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Expr = expr
+	return stmt, nil
+}
+
+func (p *parser) binary(operand func() (Node, error), ops ...string) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokenOperator || !contains(ops, t.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryNode{left.Pos().to(right.Pos()), t.text, left, right}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, it := range list {
+		if it == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expr() (Node, error) {
+	return p.binary(p.term, "+", "∧", "&")
+}
+
+func (p *parser) term() (Node, error) {
+	return p.binary(p.factor, "x", "\\", "/")
+}
+
+func (p *parser) factor() (Node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenOperator && t.text == "*":
+			p.next()
+			x = &StarNode{x.Pos().to(t.Position), x}
+		case t.kind == tokenPower:
+			p.next()
+			n, err := strconv.Atoi(t.text[1:])
+			if err != nil {
+				return nil, &SyntaxError{t.Position, "power is too large"}
+			}
+			x = &PowerNode{x.Pos().to(t.Position), x, n}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenGd:
+		gd, err := scanGd(t.text)
+		if err != nil {
+			return nil, &SyntaxError{t.Position, err.Error()}
+		}
+		return &GdNode{t.Position, gd}, nil
+	case tokenName:
+		return &NameNode{t.Position, t.text}, nil
+	case tokenOpenParen:
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenCloseParen {
+			return nil, &SyntaxError{t.Position, "unclosed parenthesis"}
+		}
+		return x, nil
+	}
+	return nil, unexpected(t)
+}
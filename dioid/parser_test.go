@@ -0,0 +1,109 @@
+package dioid
+
+func (t *testSuite) TestParseTree() {
+	prog, err := Parse("H = (g^2d^3 + d)* x gd^4; H")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Equal(2, len(prog))
+	t.Equal("H", prog[0].Name)
+	mul, ok := prog[0].Expr.(*BinaryNode)
+	t.True(ok)
+	if ok {
+		t.Equal("x", mul.Op)
+		t.Equal(Position{5, 19}, mul.Pos())
+		_, ok = mul.Left.(*StarNode)
+		t.True(ok)
+		t.Equal(&GdNode{Position{20, 4}, Gd{1, 4}}, mul.Right)
+	}
+	t.Equal(&NameNode{Position{26, 1}, "H"}, prog[1].Expr)
+}
+
+func (t *testSuite) TestParseMonomials() {
+	cases := map[string]Gd{
+		"e":           E,
+		"eps":         Eps,
+		"g":           {1, 0},
+		"d":           {0, 1},
+		"gd^3":        {1, 3},
+		"g^-2d":       {-2, 1},
+		"g^-infd^inf": Top,
+	}
+	for expr, gd := range cases {
+		prog, err := Parse(expr)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		t.Equal(gd, prog[0].Expr.(*GdNode).Gd)
+	}
+}
+
+func (t *testSuite) TestParseErrors() {
+	cases := []struct {
+		expr string
+		pos  Position
+	}{
+		{"g^2 + ? d", Position{6, 1}},
+		{"(gd + d^2", Position{0, 1}},
+		{"gd d^2", Position{3, 3}},
+		{"g^x", Position{1, 2}},
+		{"gd + ", Position{5, 1}},
+		{"e ∧ x", Position{4, 1}},
+		{"g^2 ^-1", Position{4, 2}},
+		{"(gd)^99999999999999999999 + e", Position{4, 21}},
+		{"gd^٣", Position{2, 2}},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.expr)
+		e, ok := err.(*SyntaxError)
+		t.True(ok, c.expr)
+		if ok {
+			t.Equal(c.pos, e.Pos(), c.expr)
+		}
+	}
+}
+
+func (t *testSuite) TestEvalNameError() {
+	_, err := Eval("gd x (U + e)")
+	e, ok := err.(*NameError)
+	t.True(ok)
+	if ok {
+		t.Equal("U", e.Name)
+		t.Equal(Position{6, 1}, e.Pos())
+	}
+}
+
+func (t *testSuite) TestEvalPeriodError() {
+	_, err := Eval("e + (g^-1d^2)* + gd")
+	e, ok := err.(*PeriodError)
+	t.True(ok)
+	if ok {
+		t.Equal(Gd{-1, 2}, e.R)
+		t.Equal(Position{5, 9}, e.Pos())
+	}
+	_, err = EvalEnv("e + U", map[string]Serie{"U": {P: Poly{Eps}, Q: Poly{E}, R: Gd{1, -1}}})
+	_, ok = err.(*PeriodError)
+	t.True(ok)
+	// only the period has to be causal
+	s, err := Eval("g^-1d^2 x (gd)*")
+	t.Nil(err)
+	t.Equal("g^-1d^2x(gd)*", s.String())
+}
+
+func (t *testSuite) TestIsName() {
+	for _, name := range []string{"U", "_x", "H1", "Y_out2"} {
+		t.True(IsName(name), name)
+		// the lexer reads the same names
+		tokens, err := lex(name)
+		t.Nil(err)
+		t.Equal(tokenName, tokens[0].kind, name)
+		t.Equal(name, tokens[0].text, name)
+	}
+	for _, name := range []string{"", "u", "1U", "U-1", "Ü", "UÉ"} {
+		t.False(IsName(name), name)
+	}
+	_, err := lex("UÉ")
+	t.True(err != nil)
+}
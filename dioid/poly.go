@@ -0,0 +1,275 @@
+package dioid
+
+import "sort"
+
+// poly is a polynomial of the port, the monomials are known to be sorted
+// and reduced when simple is 1. Like in the library, 2 marks the
+// polynomials which are trusted to be sorted but were not reduced.
+//
+// The methods never write into data, it may be shared by several polynomials.
+type poly struct {
+	data   []Gd
+	simple int
+}
+
+// newPoly gives a polynomial made of one monomial.
+func newPoly(m Gd) poly {
+	return poly{data: []Gd{m}, simple: 1}
+}
+
+// monomial gives a polynomial made of one monomial that still has
+// to be simplified, like the library does when a monomial is assigned.
+func monomial(m Gd) poly {
+	return poly{data: []Gd{m}}
+}
+
+func (p poly) n() int {
+	return len(p.data)
+}
+
+func (p poly) at(i int) Gd {
+	return p.data[i]
+}
+
+func (p poly) last() Gd {
+	return p.data[len(p.data)-1]
+}
+
+func (p poly) equals(p2 poly) bool {
+	if len(p.data) != len(p2.data) {
+		return false
+	}
+	for i, m := range p.data {
+		if m != p2.data[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// init replaces the monomials, they are sorted and reduced
+// when can is 0 and only reduced when can is 2.
+func (p *poly) init(data []Gd, can int) {
+	if len(data) < 1 {
+		return
+	}
+	p.data = data
+	p.simple = can
+	switch can {
+	case 0:
+		p.simpli()
+	case 2:
+		p.onlysimpli()
+	}
+}
+
+// pop removes the last monomial, the last one left turns into eps.
+func (p *poly) pop() {
+	if len(p.data) > 1 {
+		p.data = p.data[:len(p.data)-1]
+		return
+	}
+	p.data = []Gd{Eps}
+}
+
+func (p *poly) popj(j int) {
+	n := len(p.data)
+	if j >= n {
+		return
+	}
+	if n == 1 {
+		p.data = []Gd{Eps}
+		return
+	}
+	data := make([]Gd, 0, n-1)
+	data = append(data, p.data[:j]...)
+	p.data = append(data, p.data[j+1:]...)
+}
+
+// add appends a monomial, it takes the place of a lone eps.
+func (p *poly) add(m Gd) {
+	n := len(p.data)
+	if n == 1 && p.data[0].IsEps() {
+		p.data = []Gd{m}
+	} else {
+		p.data = append(p.data[:n:n], m)
+	}
+	p.simple = 0
+}
+
+func (p *poly) simpli() {
+	if p.simple == 1 || len(p.data) < 1 {
+		return
+	}
+	sorted := true
+	for j := 1; j < len(p.data); j++ {
+		if !gdLess(p.data[j-1], p.data[j]) {
+			sorted = false
+			break
+		}
+	}
+	if !sorted {
+		data := make([]Gd, len(p.data))
+		copy(data, p.data)
+		sort.Slice(data, func(i, j int) bool {
+			return gdLess(data[i], data[j])
+		})
+		p.data = data
+	}
+	p.onlysimpli()
+}
+
+// onlysimpli drops the monomials dominated by the previous ones,
+// the polynomial must be sorted.
+func (p *poly) onlysimpli() {
+	if len(p.data) < 1 {
+		return
+	}
+	data := make([]Gd, 1, len(p.data))
+	data[0] = p.data[0]
+	for _, m := range p.data[1:] {
+		if m.D > data[len(data)-1].D {
+			data = append(data, m)
+		}
+	}
+	p.data = data
+
+	if p.data[0].G == _Inf {
+		if p.data[0].D != _Inf {
+			p.data = []Gd{Top}
+			p.simple = 1
+			return
+		}
+		for p.data[0].G == _Inf {
+			p.popj(0)
+		}
+	}
+	if p.last().IsEps() && p.n() > 1 {
+		p.popj(p.n() - 1)
+	}
+	if last := p.last(); last.G == Inf && last.D != _Inf {
+		p.popj(p.n() - 1)
+	}
+	if first := p.at(0); first.D == _Inf && first.G != Inf {
+		p.popj(0)
+	}
+	p.simple = 1
+}
+
+// merge works like std::merge with gdLess.
+func merge(a, b []Gd) []Gd {
+	result := make([]Gd, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if gdLess(b[j], a[i]) {
+			result = append(result, b[j])
+			j++
+		} else {
+			result = append(result, a[i])
+			i++
+		}
+	}
+	result = append(result, a[i:]...)
+	return append(result, b[j:]...)
+}
+
+func oplusPoly(p1, p2 poly, more ...poly) (result poly) {
+	data := merge(p1.data, p2.data)
+	for _, p := range more {
+		data = merge(data, p.data)
+	}
+	result = newPoly(Eps)
+	result.init(data, 2)
+	return
+}
+
+// simplified returns p sorted and reduced, p is left intact.
+func (p poly) simplified() poly {
+	p.simpli()
+	return p
+}
+
+func otimesPoly(p1, p2 poly) (result poly) {
+	if p1.simple == 0 {
+		p1 = p1.simplified()
+	}
+	if p2.simple == 0 {
+		p2 = p2.simplified()
+	}
+	data := make([]Gd, 0, p1.n()*p2.n())
+	for _, m1 := range p1.data {
+		for _, m2 := range p2.data {
+			data = append(data, gdOtimes(m1, m2))
+		}
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return gdLess(data[i], data[j])
+	})
+	result = newPoly(Eps)
+	result.init(data, 2)
+	return
+}
+
+func otimesPolyGd(p poly, m Gd) poly {
+	return otimesPoly(p, monomial(m))
+}
+
+func infPoly(p1, p2 poly) (result poly) {
+	if p1.simple == 0 {
+		p1 = p1.simplified()
+	}
+	if p2.simple == 0 {
+		p2 = p2.simplified()
+	}
+	data := make([]Gd, 0, p1.n()*p2.n())
+	for _, m1 := range p1.data {
+		for _, m2 := range p2.data {
+			data = append(data, gdInf(m1, m2))
+		}
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return gdLess(data[i], data[j])
+	})
+	result = newPoly(Eps)
+	result.init(data, 2)
+	return
+}
+
+// fracPolyGd computes p/m, the result is not reduced.
+func fracPolyGd(p poly, m Gd) (result poly) {
+	result = newPoly(Eps)
+	if p.simple == 0 {
+		return
+	}
+	data := make([]Gd, p.n())
+	for k, m1 := range p.data {
+		data[k] = gdFrac(m1, m)
+	}
+	result.data = data
+	result.simple = 2
+	return
+}
+
+// fracPoly computes p1/p2.
+func fracPoly(p1, p2 poly) (result poly) {
+	result = fracPolyGd(p1, p2.at(0))
+	for _, m := range p2.data[1:] {
+		result = infPoly(result, fracPolyGd(p1, m))
+	}
+	return
+}
+
+// prcausPoly gives the causal part of p.
+func prcausPoly(p poly) (local poly) {
+	local = newPoly(Eps)
+	i := p.n() - 1
+	for i >= 0 && p.at(i).D >= 0 && p.at(i).G >= 0 {
+		local.add(p.at(i))
+		i--
+	}
+	if i >= 0 && p.at(i).D >= 0 {
+		local.add(Gd{0, p.at(i).D})
+	}
+	local.simpli()
+	return
+}
@@ -0,0 +1,868 @@
+package dioid
+
+import "math"
+
+// serie is a periodic serie p + q r* of the port,
+// canonised tells whether it is in the canonical form.
+type serie struct {
+	p, q      poly
+	r         Gd
+	canonised bool
+}
+
+// newSerie gives eps + eps (e)*, it is not canonised.
+func newSerie() serie {
+	return serie{p: newPoly(Eps), q: newPoly(Eps), r: E}
+}
+
+func epsSerie() serie {
+	return serie{p: monomial(Eps), q: monomial(Eps), r: E, canonised: true}
+}
+
+func topSerie() serie {
+	return serie{p: monomial(Eps), q: monomial(Top), r: E, canonised: true}
+}
+
+// gdSerie turns a monomial into a serie.
+func gdSerie(m Gd) serie {
+	return serie{p: monomial(Eps), q: monomial(m), r: E, canonised: true}
+}
+
+// polySerie turns a polynomial into a serie, the last monomial goes to q.
+func polySerie(p poly) (s serie) {
+	s.p = p.simplified()
+	s.q = monomial(s.p.last())
+	s.p.pop()
+	s.r = E
+	if s.q.last().D == Inf {
+		s.r = Gd{0, Inf}
+	}
+	s.canonised = true
+	return
+}
+
+// operand turns a polynomial into a serie the way the library
+// does it for the operands of the operations, p is not simplified.
+func operand(p poly) (s serie) {
+	s.p = p
+	s.q = monomial(p.last())
+	s.p.pop()
+	s.r = E
+	s.canonised = true
+	return
+}
+
+func (s serie) equals(s2 serie) bool {
+	return s.p.equals(s2.p) && s.q.equals(s2.q) && s.r == s2.r
+}
+
+func (s *serie) canon() {
+	if s.canonised {
+		return
+	}
+	p, q, r := s.p.simplified(), s.q.simplified(), s.r
+	defer func() {
+		s.p, s.q, s.r = p, q, r
+		s.canonised = true
+	}()
+
+	if p.at(0) == Top || q.at(0) == Top {
+		p, q, r = monomial(Eps), monomial(Top), E
+		return
+	}
+	if q.at(0).IsEps() {
+		q = monomial(p.last())
+		p.pop()
+		r = E
+		return
+	}
+	if q.last().D == Inf || p.last().D == Inf {
+		p = oplusPoly(p, q)
+		q = monomial(p.last())
+		p.pop()
+		r = Gd{0, Inf}
+		return
+	}
+
+	// degenerated cases
+	if r.G == Inf || r.D == 0 {
+		p = oplusPoly(p, q)
+		q = monomial(p.last())
+		p.pop()
+		r = E
+		return
+	}
+	if r.G > 0 && r.D == Inf {
+		p = oplusPoly(p, q)
+		q = otimesPolyGd(monomial(q.at(0)), r)
+		if q.at(0).G <= p.last().G {
+			p = oplusPoly(p, q)
+			p.pop()
+		}
+		r = Gd{0, Inf}
+		return
+	}
+	if r.D > 0 && r.G == 0 {
+		r = Gd{0, Inf}
+		q = monomial(gdOtimes(q.at(0), r))
+		p = oplusPoly(p, q)
+		p.pop()
+		return
+	}
+
+	// drop the monomials of q dominated by the others once developed
+	for j := q.n() - 1; j > 0; j-- {
+		for i := 0; i < j; {
+			k := (q.at(j).G - q.at(i).G) / r.G
+			if k >= 1 && q.at(i).D+k*r.D >= q.at(j).D {
+				q.popj(j)
+				j--
+				i = 0
+			} else {
+				i++
+			}
+		}
+	}
+
+	// make the pattern of q fit into r
+	j := q.n() - 1
+	if q.at(j).G-q.at(0).G >= r.G || q.at(j).D-q.at(0).D >= r.D {
+		periodic := make([]Gd, q.n())
+		periodic[0] = q.at(j)
+		var transient []Gd
+		for i := 0; i < j; i++ {
+			m := q.at(i)
+			transient = append(transient, m)
+			for n := (q.at(j).G - m.G - 1) / r.G; n > 0; n-- {
+				m = gdOtimes(m, r)
+				transient = append(transient, m)
+			}
+			periodic[i+1] = gdOtimes(m, r)
+		}
+		for _, m := range transient {
+			p.add(m)
+		}
+		p.simpli()
+		q.init(periodic, 0)
+	}
+
+	// reduce r and then q
+	index := min(r.G, r.D)
+	for index >= 2 && q.n() > 1 {
+		if r.G%index != 0 || r.D%index != 0 {
+			index--
+			continue
+		}
+		nu, tau := r.G/index, r.D/index
+		pattern := monomial(q.at(0))
+		for i := 1; i < q.n()-1 && q.at(i).G-q.at(0).G < nu && q.at(i).D-q.at(0).D < tau; i++ {
+			pattern.add(q.at(i))
+		}
+		extended := pattern
+		for i := 1; i < index; i++ {
+			extended = oplusPoly(extended, otimesPolyGd(pattern, Gd{nu * i, tau * i}))
+		}
+		equal := false
+		if extended.n() == q.n() {
+			equal = true
+			for i := pattern.n(); equal && i < q.n(); i++ {
+				if extended.at(i) != q.at(i) {
+					equal = false
+				}
+			}
+		}
+		if equal {
+			q = pattern
+			r = Gd{nu, tau}
+			index = min(r.G, r.D)
+		} else {
+			index--
+		}
+	}
+
+	// drop the last monomials of p dominated by q
+	for dominated := true; dominated; {
+		dominated = false
+		i := p.n() - 1
+		for j := 0; j < q.n() && !dominated; j++ {
+			if gdLeq(p.at(i), q.at(j)) && !p.at(i).IsEps() {
+				p.pop()
+				dominated = true
+			}
+		}
+	}
+
+	// develop q as long as the last monomial of p dominates it
+	if i := p.n() - 1; !p.at(i).IsEps() {
+		temp := monomial(Eps)
+		for p.at(i).G >= q.at(0).G || p.at(i).D >= q.at(0).D {
+			for _, m := range q.data {
+				temp.add(m)
+			}
+			q = otimesPolyGd(q, r)
+		}
+		p = oplusPoly(temp, p)
+	}
+
+	// move the transient into the periodic part as much as possible
+	for q.last() == gdOtimes(p.last(), r) {
+		data := make([]Gd, 0, q.n())
+		data = append(data, p.last())
+		q.data = append(data, q.data[:q.n()-1]...)
+		p.pop()
+	}
+}
+
+func oplusSerie(s1, s2 serie) (result serie) {
+	s1.canon()
+	s2.canon()
+	if s1.q.at(0).G == _Inf || s2.q.at(0).G == _Inf {
+		return topSerie()
+	}
+	if s1.q.at(0).G == Inf {
+		return s2
+	}
+	if s2.q.at(0).G == Inf {
+		return s1
+	}
+
+	// degenerated cases
+	if s1.r.D == 0 && s2.r.D == 0 {
+		return polySerie(oplusPoly(s1.p, s1.q, s2.p, s2.q))
+	}
+	a1, a2 := &s1, &s2
+	if s1.r.D == 0 && s2.r.D != 0 {
+		a1, a2 = &s2, &s1
+	}
+	if a1.r.D != 0 && a2.r.D == 0 {
+		if a1.r.G == 0 {
+			return polySerie(oplusPoly(a1.p, a2.p, a2.q, monomial(Gd{a1.q.at(0).G, Inf})))
+		}
+		result = newSerie()
+		result.p = oplusPoly(a1.p, a2.p, a2.q)
+		result.q = a1.q
+		result.r = a1.r
+		result.canon()
+		return
+	}
+	if s1.r.G == 0 && s2.r.G == 0 {
+		p := oplusPoly(monomial(Gd{s1.q.at(0).G, Inf}), s1.p, s2.p)
+		return polySerie(oplusPoly(newPoly(Gd{s2.q.at(0).G, Inf}), p))
+	}
+	if s1.r.G == 0 || s2.r.G == 0 {
+		// extend the serie beyond the first monomial of the trajectory
+		if s1.r.G == 0 {
+			a1, a2 = &s2, &s1
+		} else {
+			a1, a2 = &s1, &s2
+		}
+		p := a1.q
+		for i := 1; p.last().G <= a2.q.at(0).G; i++ {
+			p = oplusPoly(p, otimesPolyGd(a1.q, Gd{a1.r.G * i, a1.r.D * i}))
+		}
+		result = polySerie(oplusPoly(newPoly(Gd{a2.q.at(0).G, Inf}), oplusPoly(a2.p, a1.p, p)))
+		result.r = Gd{0, Inf}
+		return
+	}
+
+	slope1 := float64(s1.r.G) / float64(s1.r.D)
+	slope2 := float64(s2.r.G) / float64(s2.r.D)
+	result = newSerie()
+	if slope1 == slope2 {
+		result.p = oplusPoly(s1.p, s2.p)
+		result.r = Gd{lcm(s1.r.G, s2.r.G), lcm(s1.r.D, s2.r.D)}
+		k1 := result.r.G / s1.r.G
+		k2 := result.r.G / s2.r.G
+		q := s1.q
+		for i := 1; i <= k1-1; i++ {
+			for _, m := range s1.q.data {
+				q.add(gdOtimes(Gd{i * s1.r.G, i * s1.r.D}, m))
+			}
+		}
+		for i := 0; i <= k2-1; i++ {
+			for _, m := range s2.q.data {
+				q.add(Gd{m.G + i*s2.r.G, m.D + i*s2.r.D})
+			}
+		}
+		result.q = q
+		result.canon()
+		return
+	}
+
+	// the slope of a1 is less than the one of a2
+	a1, a2 = &s1, &s2
+	if slope1 > slope2 {
+		a1, a2 = &s2, &s1
+	}
+	t2 := a2.q.last().D
+	k1 := a1.r.G*(t2-a1.q.at(0).D+a1.r.D) + a1.r.D*(a1.q.at(0).G-a2.q.at(0).G)
+	k2 := a1.r.D*a2.r.G - a1.r.G*a2.r.D
+	k := max(max(ceil(k1, k2), 0), ceil(a1.q.at(0).G-a2.q.at(0).G, a2.r.G))
+	p := oplusPoly(a1.p, a2.p)
+	for i := 0; i < k; i++ {
+		for _, m := range a2.q.data {
+			p.add(Gd{m.G + i*a2.r.G, m.D + i*a2.r.D})
+		}
+	}
+	result.p = p
+	result.q = a1.q
+	result.r = a1.r
+	result.canon()
+	return
+}
+
+// ceil computes ⌈a/b⌉ the way the library does.
+func ceil(a, b int) int {
+	return int(math.Ceil(float64(a) / float64(b)))
+}
+
+func otimesSerie(s1, s2 serie) (result serie) {
+	s1.canon()
+	s2.canon()
+	if s1.q.at(0).G == Inf || s2.q.at(0).G == Inf {
+		return epsSerie()
+	}
+	if s1.q.at(0).G == _Inf || s2.q.at(0).G == _Inf {
+		return topSerie()
+	}
+
+	// (p1 + q1 r1*)(p2 + q2 r2*) = p1 p2 + p1 q2 r2* + p2 q1 r1* + q1 q2 r1* r2*
+	result = newSerie()
+	result.p = otimesPoly(s1.p, s2.p)
+	result.q = otimesPoly(s1.p, s2.q)
+	result.r = s2.r
+	result.canon()
+
+	temp := newSerie()
+	temp.q = otimesPoly(s2.p, s1.q)
+	temp.p = monomial(Eps)
+	temp.r = s1.r
+	temp.canon()
+	result = oplusSerie(result, temp)
+
+	temp.canonised = false
+	temp.q = otimesPoly(s1.q, s2.q)
+
+	// degenerated cases of r1* r2*
+	if s1.r.D == 0 && s2.r.D == 0 {
+		return oplusSerie(result, temp)
+	}
+	if (s1.r.G == 0 && s1.r.D == Inf) || (s2.r.G == 0 && s2.r.D == Inf) {
+		temp.p = monomial(Eps)
+		temp.r = Gd{0, Inf}
+		return oplusSerie(result, temp)
+	}
+	a1, a2 := &s1, &s2
+	if s1.r.D == 0 && s1.r.G == 0 && s2.r.G != 0 && s2.r.D != 0 && s2.r.D != Inf {
+		a1, a2 = &s2, &s1
+	}
+	if a2.r.D == 0 && a2.r.G == 0 && a1.r.G != 0 && a1.r.D != 0 && a1.r.D != Inf {
+		temp.r = a1.r
+		temp.p = monomial(Eps)
+		temp.canon()
+		return oplusSerie(result, temp)
+	}
+
+	slope1 := float64(s1.r.G) / float64(s1.r.D)
+	slope2 := float64(s2.r.G) / float64(s2.r.D)
+	if slope1 == slope2 {
+		k1 := gcd(s1.r.G, s2.r.G)
+		k2 := gcd(s1.r.D, s2.r.D)
+		temp.r = Gd{k1, k2}
+		tau := float64(k1) / float64(k2)
+		k1 = int(float64(s1.r.G-k1)*float64(s2.r.G-k1)) / k1
+		k2 = int(float64(s1.r.D-k2)*float64(s2.r.D-k2)) / k2
+
+		// the transient of r1* r2*
+		p1 := newPoly(Eps)
+		for i := 0; i*s1.r.D < k2; i++ {
+			for teta := i * s1.r.D; teta < k2; teta += s2.r.D {
+				p1.add(Gd{int(tau * float64(teta)), teta})
+			}
+		}
+		p1.simpli()
+
+		temp.p = otimesPoly(p1, temp.q)
+		temp.q = otimesPolyGd(temp.q, Gd{k1, k2})
+		temp.canon()
+	} else {
+		a1, a2 = &s1, &s2
+		if slope1 > slope2 {
+			a1, a2 = &s2, &s1
+		}
+		k1 := a1.r.G * a1.r.D
+		k2 := a1.r.D*a2.r.G - a1.r.G*a2.r.D
+		k1 = max(ceil(k1, k2), 0)
+		dominated := func() bool {
+			a := int(math.Floor(float64(k1) * float64(a2.r.G) / float64(a1.r.G)))
+			return float64(a1.r.D*a) >= float64(a2.r.D*k1)
+		}
+		for dominated() && k1 > 0 {
+			k1--
+		}
+		k1++
+		q1 := monomial(E)
+		for j := 1; j < k1; j++ {
+			q1.add(Gd{a2.r.G * j, a2.r.D * j})
+		}
+		temp.q = otimesPoly(temp.q, q1)
+		temp.p = monomial(Eps)
+		temp.r = a1.r
+		temp.canon()
+	}
+	return oplusSerie(result, temp)
+}
+
+func starPoly(p poly) (result serie) {
+	p = p.simplified()
+	if p.at(0).G == _Inf {
+		return topSerie()
+	}
+	for i := 0; i < p.n(); i++ {
+		if m := p.at(i); m.G == Inf || m.D == 0 {
+			if p.n() > 1 {
+				p.popj(i)
+			} else {
+				return serie{p: monomial(Eps), q: monomial(E), r: E, canonised: true}
+			}
+		}
+	}
+
+	numax := Inf
+	for _, m := range p.data {
+		if m.G == 0 && m.D > 0 {
+			return serie{p: monomial(Eps), q: monomial(Gd{0, Inf}), r: Gd{0, Inf}, canonised: true}
+		}
+		if m.D == Inf && m.G < numax {
+			numax = m.G
+		}
+	}
+
+	// some monomials are trajectories
+	if numax != Inf {
+		result.p = monomial(E)
+		for _, m := range p.data {
+			for j := 1; j*m.G < numax; j++ {
+				result.p.add(Gd{j * m.G, j * m.D})
+			}
+		}
+		result.p.simpli()
+		result.q = monomial(Gd{numax, Inf})
+		result.r = Gd{0, Inf}
+		result.canonised = true
+		return
+	}
+
+	// the monomial with the least slope gives r
+	slope := float64(Inf)
+	nj := 0
+	for i, m := range p.data {
+		if s := float64(m.G) / float64(m.D); s < slope {
+			slope = s
+			nj = i
+		}
+	}
+	rtemp := p.at(nj)
+	k1 := rtemp.G * rtemp.D
+
+	// bound the extension of the other monomials
+	gammakmax := 0
+	var tabki []int
+	for _, m := range p.data {
+		if float64(m.G)/float64(m.D) <= slope {
+			continue
+		}
+		ki := rtemp.D*m.G - rtemp.G*m.D
+		kmin := ceil(max(k1, 0), ki)
+		dominated := func() bool {
+			a := int(math.Floor(float64(kmin) * float64(m.G) / float64(rtemp.G)))
+			return float64(rtemp.D*a) >= float64(m.D*kmin)
+		}
+		for dominated() && kmin > 0 {
+			kmin--
+		}
+		kmin++
+		if gammakmin := kmin * m.G; gammakmin > gammakmax {
+			gammakmax = gammakmin
+		}
+		tabki = append(tabki, kmin)
+	}
+
+	result = newSerie()
+	result.p = monomial(Eps)
+	result.r = rtemp
+	result.q = monomial(E)
+
+	tabgd := make([]Gd, gammakmax+1)
+	for i := range tabgd {
+		tabgd[i] = Eps
+	}
+	tabgd[0] = E
+	n := 0
+	for i := 0; i < p.n(); i++ {
+		m := p.at(i)
+		if float64(m.G)/float64(m.D) <= slope {
+			continue
+		}
+		qtemp := monomial(E)
+		for j := 1; j < tabki[n]; j++ {
+			qtemp.add(Gd{m.G * j, m.D * j})
+		}
+		n++
+		for _, m1 := range qtemp.data {
+			for _, m2 := range result.q.data {
+				mono := gdOtimes(m1, m2)
+				if mono.G >= gammakmax {
+					break
+				}
+				if mono.G >= 0 && gdGeq(mono, tabgd[mono.G]) {
+					tabgd[mono.G] = mono
+				}
+			}
+		}
+		result.q = monomial(tabgd[0])
+		for k := 1; k < gammakmax; k++ {
+			if !tabgd[k].IsEps() {
+				result.q.add(tabgd[k])
+			}
+		}
+		p.popj(i)
+		if i < nj {
+			nj--
+		}
+		i--
+	}
+	result.q.simpli()
+
+	// same slopes
+	for i := nj + 1; i < p.n(); i++ {
+		for k := nj; k < i; k++ {
+			if p.at(i).G%p.at(k).G == 0 {
+				p.popj(i)
+				i--
+				break
+			}
+		}
+	}
+	for i := nj + 1; i < p.n(); i++ {
+		result.r = Gd{lcm(result.r.G, p.at(i).G), lcm(result.r.D, p.at(i).D)}
+	}
+	for i := nj; i < p.n(); i++ {
+		m := p.at(i)
+		qtemp := monomial(E)
+		for j := 1; j < result.r.G/m.G; j++ {
+			qtemp.add(Gd{m.G * j, m.D * j})
+		}
+		result.q = otimesPoly(result.q, qtemp)
+	}
+	result.canon()
+	return
+}
+
+func starSerie(s serie) (result serie) {
+	s.canon()
+	result = starPoly(oplusPoly(newPoly(s.r), s.q))
+	temp := otimesSerie(operand(s.q), result)
+	result = oplusSerie(gdSerie(E), temp)
+	return otimesSerie(starPoly(s.p), result)
+}
+
+// infSeriePoly computes s ∧ p.
+func infSeriePoly(s serie, p poly) (result serie) {
+	p = p.simplified()
+	s.canon()
+	if p.at(0).IsEps() || s.q.at(0).G == Inf {
+		return epsSerie()
+	}
+	if p.at(0).G == _Inf {
+		return s
+	}
+	if s.q.at(0).G == _Inf {
+		return polySerie(p)
+	}
+
+	// s is a polynomial or a trajectory
+	if s.r == E || s.r.D == Inf {
+		result.p = infPoly(oplusPoly(s.p, s.q), p)
+		result.q = monomial(result.p.last())
+		result.p.pop()
+		result.r = E
+		if s.r.D == Inf && result.p.last().D == Inf {
+			result.r = Gd{0, Inf}
+		}
+		result.canonised = true
+		return
+	}
+
+	result = newSerie()
+	result.p = infPoly(s.p, p)
+
+	// p is a trajectory
+	if p.last().D == Inf {
+		temp := monomial(Eps)
+		i := 0
+		for ; s.q.at(0).G+i*s.r.G < p.last().G; i++ {
+			temp = oplusPoly(temp, otimesPolyGd(s.q, Gd{i * s.r.G, i * s.r.D}))
+		}
+		result.p = oplusPoly(result.p, infPoly(p, temp))
+		result.q = otimesPolyGd(s.q, Gd{i * s.r.G, i * s.r.D})
+		result.r = s.r
+		result.canon()
+		return
+	}
+
+	b := s.q.at(0).D
+	temp := monomial(E)
+	for j := 1; b+temp.last().D <= p.last().D; j++ {
+		temp.add(Gd{s.r.G * j, s.r.D * j})
+	}
+	temp = infPoly(otimesPoly(temp, s.q), p)
+	return oplusSerie(operand(temp), result)
+}
+
+func infSerie(s1, s2 serie) (result serie) {
+	s1.canon()
+	s2.canon()
+	if s1.q.at(0).G == Inf || s2.q.at(0).G == Inf {
+		return epsSerie()
+	}
+	if s1.q.at(0).G == _Inf {
+		return s2
+	}
+	if s2.q.at(0).G == _Inf {
+		return s1
+	}
+
+	// degenerated cases
+	if s1.r.G == 0 && s2.r.G == 0 {
+		result.p = infPoly(oplusPoly(s1.p, s1.q), oplusPoly(s2.p, s2.q))
+		result.q = monomial(result.p.last())
+		result.p.pop()
+		result.r = E
+		if s1.r.D == Inf && s2.r.D == Inf {
+			result.r = Gd{0, Inf}
+		}
+		result.canonised = true
+		return
+	}
+	if s1.r.G == 0 {
+		return infSeriePoly(s2, oplusPoly(s1.p, s1.q))
+	}
+	if s2.r.G == 0 {
+		return infSeriePoly(s1, oplusPoly(s2.p, s2.q))
+	}
+
+	// p1 ∧ p2 + p1 ∧ q2 r2* + p2 ∧ q1 r1*
+	p3 := infPoly(s1.p, s2.p)
+	p4 := infSeriePoly(serie{p: monomial(Eps), q: s2.q, r: s2.r, canonised: true}, s1.p)
+	p5 := infSeriePoly(serie{p: monomial(Eps), q: s1.q, r: s1.r, canonised: true}, s2.p)
+	p4 = oplusSerie(operand(p3), oplusSerie(p4, p5))
+
+	// q1 r1* ∧ q2 r2*
+	slope1 := float64(s1.r.G) / float64(s1.r.D)
+	slope2 := float64(s2.r.G) / float64(s2.r.D)
+	result = newSerie()
+	if slope1 == slope2 {
+		r := Gd{lcm(s1.r.G, s2.r.G), lcm(s1.r.D, s2.r.D)}
+		k1 := r.G / s1.r.G
+		k2 := r.G / s2.r.G
+		q := monomial(Eps)
+		for _, m1 := range s1.q.data {
+			for _, m2 := range s2.q.data {
+				for l := 0; l < k2; l++ {
+					for g := 0; g < k1; g++ {
+						a, c := m1.G+g*s1.r.G, m1.D+g*s1.r.D
+						b, d := m2.G+l*s2.r.G, m2.D+l*s2.r.D
+						if a < b {
+							a, b = b, a
+							c, d = d, c
+						}
+						for h := 0; h <= max(ceil(c-d, r.D), 0); h++ {
+							q.add(Gd{max(a, b+h*r.G), min(c, d+h*r.D)})
+						}
+					}
+				}
+			}
+		}
+		q.simpli()
+		result.p = monomial(Eps)
+		result.q = q
+		result.r = r
+		result = oplusSerie(result, p4)
+		result.canon()
+		return
+	}
+
+	a1, a2 := &s1, &s2
+	if slope1 > slope2 {
+		a1, a2 = &s2, &s1
+	}
+	t2 := a2.q.last().D
+	k1 := a1.r.G*(t2-a1.q.at(0).D+a1.r.D) + a1.r.D*(a1.q.at(0).G-a2.q.at(0).G)
+	k2 := a1.r.D*a2.r.G - a1.r.G*a2.r.D
+	k := max(max(ceil(k1, k2), 0), ceil(a1.q.at(0).G-a2.q.at(0).G, a2.r.G))
+	p3 = monomial(E)
+	for i := 0; i < k; i++ {
+		p3.add(Gd{i * a2.r.G, i * a2.r.D})
+	}
+	result.q = otimesPolyGd(a2.q, Gd{k * a2.r.G, k * a2.r.D})
+	result.p = monomial(Eps)
+	result.r = a2.r
+	temp := serie{p: monomial(Eps), q: a1.q, r: a1.r, canonised: true}
+	temp = infSeriePoly(temp, otimesPoly(a2.q, p3))
+	result = oplusSerie(oplusSerie(result, temp), p4)
+	result.canon()
+	return
+}
+
+// fracSerieGd computes s/m, s is expected to be canonised.
+func fracSerieGd(s serie, m Gd) (result serie) {
+	return mapSerie(s, func(m1 Gd) Gd {
+		return gdFrac(m1, m)
+	})
+}
+
+// dualfracSerieGd computes the dual residual of s by m.
+func dualfracSerieGd(s serie, m Gd) (result serie) {
+	return mapSerie(s, func(m1 Gd) Gd {
+		return gdDualFrac(m1, m)
+	})
+}
+
+func mapSerie(s serie, f func(Gd) Gd) (result serie) {
+	result = newSerie()
+	result.p = monomial(f(s.p.at(0)))
+	for _, m := range s.p.data[1:] {
+		result.p.add(f(m))
+	}
+	result.q = monomial(f(s.q.at(0)))
+	for _, m := range s.q.data[1:] {
+		result.q.add(f(m))
+	}
+	result.r = s.r
+	result.canon()
+	return
+}
+
+// fracSerie computes s1/s2.
+func fracSerie(s1, s2 serie) (result serie) {
+	s1.canon()
+	s2.canon()
+	if s1.q.at(0).G == _Inf || s2.q.at(0).G == Inf {
+		return topSerie()
+	}
+	if s1.q.at(0).G == Inf || s2.q.at(0).G == _Inf {
+		return epsSerie()
+	}
+
+	// s1 is a polynomial and s2 is not
+	if s1.r.G == 0 && s1.r.D == 0 && s2.r.D != 0 {
+		return epsSerie()
+	}
+	// s2 is a trajectory and s1 is a serie
+	if s1.r.G > 0 && s2.r.D == Inf {
+		return epsSerie()
+	}
+	// polynomials and trajectories
+	if s1.r.G == 0 && s2.r.G == 0 {
+		return polySerie(fracPoly(oplusPoly(s1.p, s1.q), oplusPoly(s2.p, s2.q)))
+	}
+	// s2 is a polynomial
+	if s2.r.G == 0 && s2.r.D == 0 {
+		p2 := oplusPoly(s2.p, s2.q)
+		result = fracSerieGd(s1, p2.at(0))
+		for _, m := range p2.data[1:] {
+			result = infSerie(result, fracSerieGd(s1, m))
+		}
+		result.canon()
+		return
+	}
+	// s1 is a trajectory and s2 is a serie
+	if s1.r.G == 0 && s1.r.D == Inf {
+		p1 := oplusPoly(s1.p, s1.q)
+		tampon := fracPoly(p1, s2.p)
+		n := ceil(p1.last().G-p1.at(0).G, s2.r.G)
+		tampon1 := p1
+		for k := 1; k <= n; k++ {
+			tampon1 = infPoly(fracPolyGd(p1, Gd{k * s2.r.G, k * s2.r.D}), tampon1)
+		}
+		temp := polySerie(fracPolyGd(tampon1, s2.q.at(0)))
+		for _, m := range s2.q.data[1:] {
+			temp = infSeriePoly(temp, fracPolyGd(tampon1, m))
+		}
+		result = infSeriePoly(temp, tampon)
+		result.r = Gd{0, Inf}
+		return
+	}
+
+	slope1 := float64(s1.r.G) / float64(s1.r.D)
+	slope2 := float64(s2.r.G) / float64(s2.r.D)
+	if slope2 < slope1 {
+		return epsSerie()
+	}
+
+	// s1/p2
+	result = fracSerieGd(s1, s2.p.at(0))
+	for _, m := range s2.p.data[1:] {
+		result = infSerie(result, fracSerieGd(s1, m))
+	}
+
+	// s1/q2 r2*
+	k2 := lcm(s1.r.G, s2.r.G) / s2.r.G
+	k11 := ceil(s1.p.last().D-s1.p.at(0).D, s2.r.D)
+	k12 := 0
+	if g := s1.p.at(0).G; g != Inf && g != _Inf {
+		k12 = ceil(s1.q.at(0).G-g, s2.r.G)
+	}
+	k1 := max(k11, k12)
+	temp := s1
+	for j := 0; j < k1; j++ {
+		temp = infSerie(temp, otimesSerie(gdSerie(Gd{-j * s2.r.G, -j * s2.r.D}), s1))
+	}
+	temp3 := serie{p: monomial(Eps), q: s1.q, r: s1.r, canonised: true}
+	for j := k1; j < k1+k2; j++ {
+		temp = infSerie(temp, otimesSerie(gdSerie(Gd{-j * s2.r.G, -j * s2.r.D}), temp3))
+	}
+	temp3 = otimesSerie(gdSerie(Gd{-s2.q.at(0).G, -s2.q.at(0).D}), temp)
+	for _, m := range s2.q.data[1:] {
+		temp3 = infSerie(temp3, otimesSerie(gdSerie(Gd{-m.G, -m.D}), temp))
+	}
+	result = infSerie(result, temp3)
+	result.canon()
+	return
+}
+
+// odotSerie computes the dual product, it gives Top if any of operands is Top.
+func odotSerie(s1, s2 serie) serie {
+	s1.canon()
+	top := newSerie()
+	top.q = monomial(Top)
+	if s1.equals(top) || s2.equals(top) {
+		return top
+	}
+	return otimesSerie(s1, s2)
+}
+
+// prcausSerie gives the greatest causal serie less than s.
+func prcausSerie(s serie) (result serie) {
+	s.canon()
+	result = s
+	if result.equals(epsSerie()) {
+		return
+	}
+	// s is a polynomial or a trajectory
+	if s.r == E || s.r == (Gd{0, Inf}) {
+		return polySerie(prcausPoly(oplusPoly(s.p, s.q)))
+	}
+	n0, t0 := float64(s.q.at(0).G), float64(s.q.at(0).D)
+	if n0 >= 0 && t0 >= 0 {
+		result.p = prcausPoly(result.p)
+		return
+	}
+	i := max(max(int(math.Ceil(-(n0/float64(s.r.G)))), int(math.Ceil(-(t0/float64(s.r.D))))), 0)
+	result.q = otimesPolyGd(s.q, Gd{i * s.r.G, i * s.r.D})
+	result.p = prcausPoly(otimesPolyGd(s.q, Gd{(i - 1) * s.r.G, (i - 1) * s.r.D}))
+	result.canonised = false
+	result.canon()
+	return
+}
@@ -0,0 +1,171 @@
+package dioid
+
+// smatrix is a matrix of the port, the entries are stored by rows.
+type smatrix struct {
+	row, col int
+	data     [][]serie
+}
+
+// newSmatrix gives a row x col matrix of series that are not canonised yet.
+func newSmatrix(row, col int) (m smatrix) {
+	if row < 1 || col < 1 {
+		return
+	}
+	m.row, m.col = row, col
+	m.data = make([][]serie, row)
+	for i := range m.data {
+		m.data[i] = make([]serie, col)
+		for j := range m.data[i] {
+			m.data[i][j] = newSerie()
+		}
+	}
+	return
+}
+
+// epsSmatrix is the 1x1 matrix given on size mismatches.
+func epsSmatrix() smatrix {
+	m := newSmatrix(1, 1)
+	m.data[0][0].canon()
+	return m
+}
+
+func oplusSmatrix(a, b smatrix) smatrix {
+	if a.row != b.row || a.col != b.col {
+		return epsSmatrix()
+	}
+	result := newSmatrix(a.row, b.col)
+	for i := 0; i < a.row; i++ {
+		for j := 0; j < a.col; j++ {
+			result.data[i][j] = oplusSerie(a.data[i][j], b.data[i][j])
+		}
+	}
+	return result
+}
+
+func infSmatrix(a, b smatrix) smatrix {
+	result := newSmatrix(a.row, b.col)
+	for i := 0; i < a.row; i++ {
+		for j := 0; j < a.col; j++ {
+			result.data[i][j] = infSerie(a.data[i][j], b.data[i][j])
+		}
+	}
+	return result
+}
+
+func otimesSmatrix(a, b smatrix) smatrix {
+	if a.col != b.row {
+		return epsSmatrix()
+	}
+	result := newSmatrix(a.row, b.col)
+	for i := 0; i < a.row; i++ {
+		for j := 0; j < b.col; j++ {
+			result.data[i][j] = gdSerie(Eps)
+			for k := 0; k < a.col; k++ {
+				temp := otimesSerie(a.data[i][k], b.data[k][j])
+				result.data[i][j] = oplusSerie(result.data[i][j], temp)
+			}
+		}
+	}
+	return result
+}
+
+func odotSmatrix(a, b smatrix) smatrix {
+	result := newSmatrix(a.row, b.col)
+	for i := 0; i < a.row; i++ {
+		for j := 0; j < b.col; j++ {
+			result.data[i][j] = gdSerie(Top)
+			for k := 0; k < a.col; k++ {
+				temp := odotSerie(a.data[i][k], b.data[k][j])
+				result.data[i][j] = infSerie(result.data[i][j], temp)
+			}
+		}
+	}
+	return result
+}
+
+// lfracSmatrix computes b\a.
+func lfracSmatrix(a, b smatrix) smatrix {
+	result := newSmatrix(b.col, a.col)
+	for i := 0; i < b.col; i++ {
+		for j := 0; j < a.col; j++ {
+			result.data[i][j] = fracSerie(a.data[0][j], b.data[0][i])
+			for k := 1; k < b.row; k++ {
+				temp := fracSerie(a.data[k][j], b.data[k][i])
+				result.data[i][j] = infSerie(result.data[i][j], temp)
+			}
+		}
+	}
+	return result
+}
+
+// rfracSmatrix computes a/b.
+func rfracSmatrix(a, b smatrix) smatrix {
+	result := newSmatrix(a.row, b.row)
+	for i := 0; i < a.row; i++ {
+		for j := 0; j < b.row; j++ {
+			result.data[i][j] = fracSerie(a.data[i][0], b.data[j][0])
+			for k := 1; k < b.col; k++ {
+				temp := fracSerie(a.data[i][k], b.data[j][k])
+				result.data[i][j] = infSerie(result.data[i][j], temp)
+			}
+		}
+	}
+	return result
+}
+
+// duallfracSmatrix computes the dual residual of a by b on the left,
+// only the first monomial of q is taken from the entries of b.
+func duallfracSmatrix(a, b smatrix) smatrix {
+	result := newSmatrix(b.col, a.col)
+	for i := 0; i < b.col; i++ {
+		for j := 0; j < a.col; j++ {
+			result.data[i][j] = dualfracSerieGd(a.data[0][j], b.data[0][i].q.at(0))
+			for k := 1; k < b.row; k++ {
+				temp := dualfracSerieGd(a.data[k][j], b.data[k][i].q.at(0))
+				result.data[i][j] = oplusSerie(result.data[i][j], temp)
+			}
+		}
+	}
+	return result
+}
+
+func starSmatrix(ak1 smatrix) smatrix {
+	if ak1.row != ak1.col {
+		return epsSmatrix()
+	}
+	a := newSmatrix(ak1.row, ak1.col)
+	for k := 0; k < a.row; k++ {
+		akkstar := starSerie(ak1.data[k][k])
+		for i := 0; i < a.row; i++ {
+			for j := 0; j < a.col; j++ {
+				temp := otimesSerie(akkstar, ak1.data[k][j])
+				temp = otimesSerie(ak1.data[i][k], temp)
+				a.data[i][j] = oplusSerie(ak1.data[i][j], temp)
+			}
+		}
+		ak1 = a.clone()
+	}
+	for k := 0; k < a.row; k++ {
+		a.data[k][k] = oplusSerie(gdSerie(E), a.data[k][k])
+	}
+	return a
+}
+
+func prcausSmatrix(s smatrix) smatrix {
+	local := newSmatrix(s.row, s.col)
+	for i := 0; i < s.row; i++ {
+		for j := 0; j < s.col; j++ {
+			local.data[i][j] = prcausSerie(s.data[i][j])
+		}
+	}
+	return local
+}
+
+func (m smatrix) clone() smatrix {
+	result := m
+	result.data = make([][]serie, m.row)
+	for i, row := range m.data {
+		result.data[i] = append([]serie(nil), row...)
+	}
+	return result
+}
@@ -1,4 +1,7 @@
 // Package dioid implements symbolic computations over dioid.
+//
+// The computations are done by the C++ library in lib/ through cgo,
+// or by its Go port when building with the purego tag.
 package dioid
 
 import (
@@ -26,43 +29,101 @@ type Gd struct {
 
 type Poly []Gd
 
+// Serie is p + q x r*, the period r has to be causal,
+// the operations panic otherwise.
 type Serie struct {
 	P, Q Poly
 	R    Gd
 }
 
+// Matrix is a matrix of series stored by rows.
+type Matrix [][]Serie
+
+// NewMatrix creates a rows x cols matrix filled with eps.
+func NewMatrix(rows, cols int) Matrix {
+	m := make(Matrix, rows)
+	for i := range m {
+		m[i] = make([]Serie, cols)
+		for j := range m[i] {
+			m[i][j] = Serie{P: Poly{Eps}, Q: Poly{Eps}, R: E}
+		}
+	}
+	return m
+}
+
+func (m Matrix) Rows() int {
+	return len(m)
+}
+
+func (m Matrix) Cols() int {
+	if len(m) < 1 {
+		return 0
+	}
+	return len(m[0])
+}
+
+// PolyLeftDiv computes p1\p2, the greatest x such that p1 x <= p2.
+func PolyLeftDiv(p1 Poly, p2 Poly) (result Poly) {
+	return PolyRightDiv(p2, p1)
+}
+
+// SerieLeftDiv computes s1\s2, the greatest x such that s1 x <= s2.
+func SerieLeftDiv(s1 Serie, s2 Serie) (result Serie) {
+	return SerieRightDiv(s2, s1)
+}
+
+// The matrix operations expect conforming sizes, otherwise
+// they give a 1x1 eps matrix just like the dioid library does.
+
+func matrixMismatch() Matrix {
+	return NewMatrix(1, 1)
+}
+
+func isEmpty(m Matrix) bool {
+	return m.Rows() < 1 || m.Cols() < 1
+}
+
+var regexScanGd = regexp.MustCompile(`^(?:g(?:\^(-?(?:\d+|inf)))?)?(?:d(?:\^(-?(?:\d+|inf)))?)?$`)
+
+func scanExponent(input string) (int, error) {
+	switch input {
+	case "":
+		return 1, nil
+	case "inf":
+		return Inf, nil
+	case "-inf":
+		return _Inf, nil
+	}
+	n, err := strconv.ParseInt(input, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid exponent %s", input)
+	}
+	return int(n), nil
+}
+
+// scanGd reads a monomial as printed by Gd.String.
 func scanGd(input string) (gd Gd, err error) {
 	switch input {
 	case "e":
-		gd = Gd{0, 0}
-		return
-	case "gd":
-		gd = Gd{1, 1}
-		return
-	default:
-		rxG := regexp.MustCompile(`g\^(-?\d+)`)
-		rxD := regexp.MustCompile(`d\^(-?\d+)`)
-		strG := rxG.FindStringSubmatch(input)
-		strD := rxD.FindStringSubmatch(input)
-		if len(strG) < 2 && len(strD) < 2 {
-			panic("should be at least one submatch")
-		}
-		if len(strG) > 0 {
-			tmp, err := strconv.ParseInt(strG[1], 10, 32)
-			if err != nil {
-				return gd, err
-			}
-			gd.G = int(tmp)
+		return E, nil
+	case "eps":
+		return Eps, nil
+	}
+	m := regexScanGd.FindStringSubmatch(input)
+	if len(input) < 1 || m == nil {
+		return gd, fmt.Errorf("invalid monomial %s", input)
+	}
+	if strings.HasPrefix(input, "g") {
+		if gd.G, err = scanExponent(m[1]); err != nil {
+			return
 		}
-		if len(strD) > 0 {
-			tmp, err := strconv.ParseInt(strD[1], 10, 32)
-			if err != nil {
-				return gd, err
-			}
-			gd.D = int(tmp)
+	}
+	if strings.Contains(input, "d") {
+		if gd.D, err = scanExponent(m[2]); err != nil {
+			return
 		}
-		return
 	}
+	return
 }
 
 func (m Gd) String() string {
@@ -96,6 +157,11 @@ func (m Gd) IsEps() bool {
 	return m.G == Eps.G && m.D == Eps.D
 }
 
+// Causal tells whether neither the shift nor the delay is negative.
+func (m Gd) Causal() bool {
+	return m.G >= 0 && m.D >= 0
+}
+
 func (p Poly) IsE() bool {
 	if len(p) != 1 {
 		return false
@@ -129,7 +195,7 @@ func (s Serie) String() (str string) {
 	}
 	if len(s.Q) > 1 {
 		str += "(" + s.Q.String() + ")"
-	} else if !s.Q.IsE() {
+	} else if !s.Q.IsE() || s.R.IsE() {
 		str += s.Q.String()
 	}
 	if !s.R.IsE() && !s.Q.IsE() {
@@ -140,6 +206,76 @@ func (s Serie) String() (str string) {
 	return
 }
 
+func (m Matrix) String() (str string) {
+	str = "["
+	for i, row := range m {
+		for j, s := range row {
+			str += s.String()
+			if j < len(row)-1 {
+				str += ", "
+			}
+		}
+		if i < len(m)-1 {
+			str += "; "
+		}
+	}
+	return str + "]"
+}
+
+// Latex writes the monomial as LaTeX math, like γ^{2}δ^{3}.
+func (m Gd) Latex() string {
+	decor := func(prefix string, value int) string {
+		if value == Inf {
+			return prefix + "^{+\\infty}"
+		} else if value == _Inf {
+			return prefix + "^{-\\infty}"
+		} else if value == 1 {
+			return prefix
+		} else if value != 0 {
+			return fmt.Sprintf("%s^{%d}", prefix, value)
+		}
+		return ""
+	}
+	switch {
+	case m.IsE():
+		return "e"
+	case m.IsEps():
+		return "\\varepsilon"
+	default:
+		return decor("\\gamma", m.G) + decor("\\delta", m.D)
+	}
+}
+
+// Latex writes the polynomial as LaTeX math, the monomials are summed by ⊕.
+func (p Poly) Latex() string {
+	if len(p) < 1 {
+		return Eps.Latex()
+	}
+	list := make([]string, len(p))
+	for i, gd := range p {
+		list[i] = gd.Latex()
+	}
+	return strings.Join(list, " \\oplus ")
+}
+
+// Latex writes the serie as LaTeX math, its parts are laid out like String does.
+func (s Serie) Latex() (str string) {
+	if !s.P.IsEps() {
+		str += s.P.Latex() + " \\oplus "
+	}
+	if len(s.Q) > 1 {
+		str += "(" + s.Q.Latex() + ")"
+	} else if !s.Q.IsE() || s.R.IsE() {
+		str += s.Q.Latex()
+	}
+	if !s.R.IsE() {
+		str += "(" + s.R.Latex() + ")^{\\ast}"
+	}
+	return
+}
+
+// Latex rewrites the text of an expression as LaTeX, it knows nothing of
+// its structure. Serie.Latex should be preferred for the series themselves.
 func Latex(expr string) string {
 	expr = strings.Replace(expr, "x", "", -1)
 	expr = strings.Replace(expr, "+", "\\oplus", -1)
@@ -1,7 +1,11 @@
+//go:build !headless
+// +build !headless
+
 package planeview
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"math"
 
@@ -51,6 +55,11 @@ type keyEvent struct {
 
 type stopEvent struct{}
 
+var (
+	errReadOnly     = errors.New("The serie of this plane is computed, only inputs and references can be edited")
+	errNotReference = errors.New("Only the planes of outputs may hold a reference")
+)
+
 type Ctrl struct {
 	CanvasWidth        float64
 	CanvasHeight       float64
@@ -63,6 +72,8 @@ type Ctrl struct {
 
 	Title       string
 	ErrorText   string
+	ErrorColumn int // where the last rejected expression is wrong, -1 if unknown
+	ErrorLength int
 	VertexText  string
 	Layers      *Layers
 	ActiveLayer int
@@ -71,8 +82,7 @@ type Ctrl struct {
 	ModifierKeyControl bool
 	ModifierKeyShift   bool
 
-	models  []*Plane
-	enabled map[string]bool
+	models []*Plane
 
 	events  chan interface{}
 	actions chan interface{}
@@ -137,16 +147,57 @@ func (c *Ctrl) LabelAt(i int) (label string) {
 	return
 }
 
+// SlopeAt gives the period of the serie of a layer and its asymptotic slope,
+// the time between two events in the long run.
+func (c *Ctrl) SlopeAt(i int) (text string) {
+	if i >= len(c.models) {
+		return
+	}
+	s := c.models[i].Dioid()
+	if s.Q.IsEps() || s.R.IsE() || s.R.G < 1 {
+		return
+	}
+	if s.R.D%s.R.G == 0 {
+		return fmt.Sprintf("r = %v, λ = %d", s.R, s.R.D/s.R.G)
+	}
+	return fmt.Sprintf("r = %v, λ = %d/%d", s.R, s.R.D, s.R.G)
+}
+
 func (c *Ctrl) IsInputAt(i int) (is bool) {
-	if i < len(c.models) {
+	if i >= 0 && i < len(c.models) {
 		is = c.models[i].input
 	}
 	return
 }
 
+func (c *Ctrl) IsReferenceAt(i int) (is bool) {
+	if i >= 0 && i < len(c.models) {
+		is = c.models[i].reference
+	}
+	return
+}
+
+// Reference makes the active output plane hold a reference for the inputs,
+// or gives it back its computed serie.
+func (c *Ctrl) Reference() {
+	active := c.Active()
+	if active == nil {
+		return
+	}
+	if active.input {
+		c.Error(errNotReference)
+		return
+	}
+	active.deselectAll()
+	active.mergeTemporary()
+	active.SetReference(!active.reference)
+	active.update()
+	active.edited()
+}
+
 func (c *Ctrl) SetEnabledAt(i int, enabled bool) {
-	if c.enabled[c.models[i].ioId] != enabled {
-		c.enabled[c.models[i].ioId] = enabled
+	if c.models[i].enabled != enabled {
+		c.models[i].enabled = enabled
 		if enabled {
 			c.SetActive(i)
 		}
@@ -156,7 +207,7 @@ func (c *Ctrl) SetEnabledAt(i int, enabled bool) {
 
 func (c *Ctrl) EnabledAt(i int) (enabled bool) {
 	if i < len(c.models) {
-		enabled = c.enabled[c.models[i].ioId]
+		enabled = c.models[i].enabled
 	}
 	return
 }
@@ -179,35 +230,66 @@ func (c *Ctrl) Dioid() (expr string) {
 func (c *Ctrl) DioidLatex() (expr string) {
 	active := c.Active()
 	if active != nil {
-		expr = dioid.Latex(active.Dioid().String())
+		expr = active.Dioid().Latex()
 	}
 	return
 }
 
-func (c *Ctrl) SetDioid(expr string) bool {
+// editable returns the active plane if its serie may be edited.
+func (c *Ctrl) editable() *Plane {
 	active := c.Active()
+	if active != nil && !active.writable() {
+		c.Error(errReadOnly)
+		return nil
+	}
+	return active
+}
+
+func (c *Ctrl) SetDioid(expr string) bool {
+	active := c.editable()
 	if active == nil {
 		return false
 	}
-	serie, err := dioid.Eval(expr)
+	serie, err := dioid.EvalEnv(expr, c.env())
 	if err != nil {
+		c.ErrorColumn, c.ErrorLength = -1, 0
+		if e, ok := err.(dioid.PositionError); ok {
+			c.ErrorColumn, c.ErrorLength = e.Pos().Column, e.Pos().Length
+		}
 		c.Error(err)
 		return false
 	}
 	active.SetDioid(serie)
 	active.update()
+	active.edited()
 	return true
 }
 
+// env makes the series of planes available by their labels,
+// if a label is a valid name for the expressions.
+func (c *Ctrl) env() map[string]dioid.Serie {
+	env := make(map[string]dioid.Serie, len(c.models))
+	for _, m := range c.models {
+		if dioid.IsName(m.Label()) {
+			env[m.Label()] = m.Dioid()
+		}
+	}
+	return env
+}
+
 func (c *Ctrl) SetActive(i int) {
 	active := c.Active()
 	if active != nil {
 		active.deselectAll()
+		edited := len(active.temporary) > 0
 		active.mergeTemporary()
+		if edited {
+			active.edited()
+		}
 		active.update()
 	}
 	c.ActiveLayer = i
-	if i >= 0 && c.enabled[c.models[i].ioId] {
+	if i >= 0 && c.models[i].enabled {
 		c.Layers.active = c.models[i].ioId
 		c.models[i].update()
 	}
@@ -235,12 +317,16 @@ func (c *Ctrl) Fix() {
 		return
 	}
 	active.deselectAll()
+	edited := len(active.temporary) > 0
 	active.mergeTemporary()
+	if edited {
+		active.edited()
+	}
 	active.update()
 }
 
 func (c *Ctrl) Star() {
-	active := c.Active()
+	active := c.editable()
 	if active == nil {
 		return
 	}
@@ -251,6 +337,7 @@ func (c *Ctrl) Star() {
 	active.deselectAll()
 	active.star(set...)
 	active.update()
+	active.edited()
 }
 
 func (c *Ctrl) HasTemporary() bool {
@@ -321,6 +408,10 @@ func (c *Ctrl) handleEvents() {
 					v, found := active.findV(x, y)
 
 					if c.ModifierKeyShift && !found {
+						if !active.writable() {
+							c.Error(errReadOnly)
+							continue
+						}
 						active.deselectAll()
 						v := active.placeV(x, y)
 						active.selectV(v)
@@ -360,6 +451,9 @@ func (c *Ctrl) handleEvents() {
 					x0, y0 = x, y
 
 					if focused != nil {
+						if !active.writable() {
+							continue
+						}
 						active.util.kind = UtilNone
 						active.util.max = nil
 						//active.placeV(x, y)
@@ -456,12 +550,17 @@ func (c *Ctrl) handleKeyEvent(ev *keyEvent) {
 				active.selectV(v)
 			}
 		case 16777219, 16777223, 8:
+			if !active.writable() {
+				c.Error(errReadOnly)
+				return
+			}
 			for v := range active.selected {
 				active.removeV(v)
 				updated = true
 			}
 			if updated {
 				active.SetDioid(active.Dioid())
+				active.edited()
 			}
 		}
 	}
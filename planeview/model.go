@@ -20,6 +20,15 @@ const (
 
 const MaxGenerated int = 50
 
+const (
+	ColorSelected      = "#b10000"
+	ColorDefault       = "#000000"
+	ColorUtility       = "#3498db"
+	ColorUtilityShadow = "#202980b9"
+	ColorVertexPad     = "#90bdc3c7"
+	ColorHollow        = "#ffffff"
+)
+
 type vertex struct {
 	X, Y   float64
 	parent *Plane
@@ -85,25 +94,61 @@ type utility struct {
 	kind     int
 }
 
+// Plane holds the serie of an input or an output. Only the series of inputs
+// and the references of outputs are edited in the viewer, the others are
+// computed by the owner of the plane.
 type Plane struct {
 	id        int
 	input     bool
+	reference bool
 	ioId      string
 	ioLabel   string
 	util      *utility
 	color     string
+	enabled   bool
 	dioid     dioid.Serie
 	defined   []*vertex
 	temporary []*vertex
 	generated []*vertex
 	selected  map[*vertex]bool
 	updated   chan int
+	edits     chan<- string
 }
 
 func (p *Plane) IsInput() bool {
 	return p.input
 }
 
+// IsReference tells whether the output plane holds a reference
+// the inputs are computed from instead of the computed output.
+func (p *Plane) IsReference() bool {
+	return p.reference
+}
+
+func (p *Plane) SetReference(reference bool) {
+	p.reference = reference && !p.input
+}
+
+func (p *Plane) writable() bool {
+	return p.input || p.reference
+}
+
+// SetEdits makes the viewer send the io id of the plane
+// to the channel whenever the serie is edited there.
+func (p *Plane) SetEdits(edits chan<- string) {
+	p.edits = edits
+}
+
+func (p *Plane) edited() {
+	if p.edits == nil {
+		return
+	}
+	select {
+	case p.edits <- p.ioId:
+	default:
+	}
+}
+
 func (p *Plane) update() {
 	p.updated <- p.id
 }
@@ -227,7 +272,7 @@ func (p *Plane) SetDioid(serie dioid.Serie) {
 			}
 			g, d = g+serie.R.G, d+serie.R.D
 		}
-	} else {
+	} else if !serie.Q.IsEps() {
 		for _, m := range serie.Q {
 			p.defined = append(p.defined, p.newV(m.G, m.D))
 		}
@@ -238,6 +283,19 @@ func (p *Plane) SetColor(color string) {
 	p.color = color
 }
 
+func (p *Plane) Color() string {
+	return p.color
+}
+
+// Enabled tells whether the plane is shown in the viewer.
+func (p *Plane) Enabled() bool {
+	return p.enabled
+}
+
+func (p *Plane) SetEnabled(enabled bool) {
+	p.enabled = enabled
+}
+
 func NewPlane(ioId, ioLabel string, input bool) *Plane {
 	return &Plane{
 		ioId:      ioId,
@@ -1,3 +1,6 @@
+//go:build !headless
+// +build !headless
+
 package planeview
 
 import (
@@ -10,14 +13,6 @@ const (
 	TextFontNormal = "Georgia"
 )
 
-const (
-	ColorSelected      = "#b10000"
-	ColorDefault       = "#000000"
-	ColorUtility       = "#3498db"
-	ColorUtilityShadow = "#202980b9"
-	ColorVertexPad     = "#90bdc3c7"
-)
-
 const (
 	Thickness    = 6.0
 	Padding      = 2.0
@@ -113,12 +108,13 @@ func (pr *planeRenderer) process(p *Plane) {
 func (pr *planeRenderer) renderModel(p *Plane) {
 	points := make([]*geometry.Point, 0, len(p.defined)+len(p.generated))
 
+	// the vertices of computed series are hollow
 	for _, v := range p.defined {
-		pr.renderVertex(v, p.color)
+		pr.renderVertex(v, p.color, !p.writable())
 		points = append(points, pt(v.X, v.Y))
 	}
 	for _, v := range p.generated {
-		pr.renderVertex(v, p.color)
+		pr.renderVertex(v, p.color, !p.writable())
 		points = append(points, pt(v.X, v.Y))
 	}
 
@@ -127,7 +123,7 @@ func (pr *planeRenderer) renderModel(p *Plane) {
 	}
 
 	for _, v := range p.temporary {
-		pr.renderVertex(v, ColorDefault)
+		pr.renderVertex(v, ColorDefault, false)
 		pr.renderChain([]*geometry.Point{pt(v.X, v.Y)}, ColorDefault)
 	}
 
@@ -176,7 +172,7 @@ func (pr *planeRenderer) renderChain(points []*geometry.Point, color string) {
 	pr.buf.Chains.Put(chain)
 }
 
-func (pr *planeRenderer) renderVertex(v *vertex, color string) {
+func (pr *planeRenderer) renderVertex(v *vertex, color string, hollow bool) {
 	point := &render.Circle{
 		Style: &render.Style{
 			Fill:      true,
@@ -186,6 +182,12 @@ func (pr *planeRenderer) renderVertex(v *vertex, color string) {
 		Y: pr.absY(pr.scaleY(v.Y - PointRadius)),
 		D: pr.scale(PointRadius * 2),
 	}
+	if hollow {
+		point.Style.FillStyle = ColorHollow
+		point.Style.Stroke = true
+		point.Style.StrokeStyle = color
+		point.Style.LineWidth = pr.scale(1.5)
+	}
 	if v.isSelected() {
 		point.Style.FillStyle = ColorSelected
 		pad := &render.Circle{
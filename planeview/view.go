@@ -1,3 +1,6 @@
+//go:build !headless
+// +build !headless
+
 package planeview
 
 import (
@@ -37,7 +40,7 @@ func NewView(id string) *View {
 			Init: func(ctrl *Ctrl, obj qml.Object) {
 				ctrl.Layers = &Layers{}
 				ctrl.ActiveLayer = -1
-				ctrl.enabled = make(map[string]bool)
+				ctrl.ErrorColumn = -1
 				ctrl.events = make(chan interface{}, 100)
 				ctrl.actions = make(chan interface{}, 100)
 				ctrl.errors = make(chan error, 100)
@@ -81,6 +81,16 @@ ColumnLayout {
                     bgPressedColor: panelBtnBgPressedColor
                     onClicked: ctrl.fix()
                 }
+
+                XButton {
+                    id: referenceAct
+                    x: 40
+                    imageSrc: "icons/lock.png"
+                    original: true
+                    bgColor: panelBtnBgColor
+                    bgPressedColor: panelBtnBgPressedColor
+                    onClicked: ctrl.reference()
+                }
             }
 
             Repeater {
@@ -98,8 +108,10 @@ ColumnLayout {
 
                     var selected = ctrl.definedSelected()
                     var temporary = ctrl.hasTemporary()
-                    starAct.visible = selected && !temporary
+                    var writable = ctrl.isInputAt(ctrl.activeLayer) || ctrl.isReferenceAt(ctrl.activeLayer)
+                    starAct.visible = selected && !temporary && writable
                     applyAct.visible = temporary
+                    referenceAct.visible = ctrl.activeLayer >= 0 && !ctrl.isInputAt(ctrl.activeLayer)
                 }
 
                 model: layers.length
@@ -309,6 +321,27 @@ ColumnLayout {
                                     text = ctrl.labelAt(index)
                                 }
                             }
+                            Text {
+                                visible: !ctrl.isInputAt(index)
+                                color: "#7f8c8d"
+                                font.pixelSize: 11
+                                font.italic: true
+                                text: ctrl.isReferenceAt(index) ? "z" : "y = H u"
+                                property var updated: ctrl.updated
+                                onUpdatedChanged: {
+                                    text = ctrl.isReferenceAt(index) ? "z" : "y = H u"
+                                }
+                            }
+                            Text {
+                                Layout.rightMargin: 10
+                                color: "#7f8c8d"
+                                font.pixelSize: 11
+                                text: ctrl.slopeAt(index)
+                                property var updated: ctrl.updated
+                                onUpdatedChanged: {
+                                    text = ctrl.slopeAt(index)
+                                }
+                            }
                         }
                     }
                 }
@@ -322,7 +355,7 @@ ColumnLayout {
                         anchors.leftMargin: 10
                         anchors.fill: parent
                         verticalAlignment: Text.AlignVCenter
-                        text: layerList.count > 0 ? "Available inputs:" : "No available inputs"
+                        text: layerList.count > 0 ? "Available I/O:" : "No available I/O"
                         font.pixelSize: 16
                     }
                 }
@@ -393,6 +426,10 @@ ColumnLayout {
                     if(ok) {
                         view.text = false
                         dioid.update()
+                    } else if(ctrl.errorColumn >= 0) {
+                        dioid.select(ctrl.errorColumn,
+                            ctrl.errorColumn + Math.max(ctrl.errorLength, 1))
+                        dioid.forceActiveFocus()
                     }
                 }
             }
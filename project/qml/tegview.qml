@@ -14,6 +14,9 @@ ApplicationWindow {
 
     property real zoom: 1.0
     property bool help: false
+    property bool daters: false
+    property bool lint: false
+    property bool compare: false
     property bool sane: true
     property string errorText
     property string label: ctrl.title
@@ -86,6 +89,28 @@ ApplicationWindow {
 
             XSeparator{}
 
+            XButton {
+                enabled: ctrl.canUndo && !tglLock.enabled
+                text: "↶"
+                fontSize: 18
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                fgPressedColor: panelBtnFgPressedColor
+                onClicked: ctrl.undo()
+            }
+
+            XButton {
+                enabled: ctrl.canRedo && !tglLock.enabled
+                text: "↷"
+                fontSize: 18
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                fgPressedColor: panelBtnFgPressedColor
+                onClicked: ctrl.redo()
+            }
+
+            XSeparator{}
+
             XButton {
                 imageSrc: "icons/magnifier-zoom-in.png"
                 original: true
@@ -128,6 +153,41 @@ ApplicationWindow {
                 onClicked: ctrl.planeView()
             }
 
+            XButton {
+                imageSrc: "icons/document.png"
+                original: true
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                onClicked: ctrl.daters()
+            }
+
+            XButton {
+                imageSrc: "icons/tick.png"
+                original: true
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                onClicked: {
+                    ctrl.lint()
+                    view.lint = true
+                }
+            }
+
+            XButton {
+                text: "K"
+                fontSize: 16
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                onClicked: synthesisMenu.popup()
+            }
+
+            XButton {
+                text: "≡"
+                fontSize: 16
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                onClicked: ctrl.compare()
+            }
+
             XButton {
                 imageSrc: "icons/camera.png"
                 original: true
@@ -145,6 +205,68 @@ ApplicationWindow {
                 bgPressedColor: panelBtnBgPressedColor
             }
 
+            XToggle {
+                id: tglCritical
+                text: "λ"
+                fontSize: 16
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                onEnabledChanged: {
+                    ctrl.showCritical = enabled
+                    ctrl.flush()
+                }
+            }
+
+            XSeparator{}
+
+            XToggle {
+                id: tglSim
+                text: "sim"
+                fontSize: 12
+                onlySignal: true
+                enabled: ctrl.simulating
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                onToggled: {
+                    if(ctrl.simulating) {
+                        ctrl.simStop()
+                    } else {
+                        tglLock.enabled = true
+                        ctrl.simStart()
+                    }
+                }
+            }
+
+            XButton {
+                visible: ctrl.simulating
+                text: "»"
+                fontSize: 18
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                fgPressedColor: panelBtnFgPressedColor
+                onClicked: ctrl.simStep()
+            }
+
+            XButton {
+                visible: ctrl.simulating
+                text: ctrl.simRunning ? "❚❚" : "▶"
+                fontSize: 14
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                fgPressedColor: panelBtnFgPressedColor
+                onClicked: ctrl.simRunning ? ctrl.simPause() : ctrl.simRun()
+            }
+
+            XButton {
+                visible: ctrl.simulating
+                text: "↺"
+                fontSize: 18
+                bgColor: panelBtnBgColor
+                bgPressedColor: panelBtnBgPressedColor
+                fgPressedColor: panelBtnFgPressedColor
+                onClicked: ctrl.simReset()
+            }
+
             Item { Layout.fillWidth: true }
 
             XButton {
@@ -185,6 +307,16 @@ ApplicationWindow {
             }
             XSeparator{ color: "#2c3e50" }
             Label { text: view.label }
+            XSeparator{ visible: ctrl.cycleText.length > 0; color: "#2c3e50" }
+            Label {
+                visible: ctrl.cycleText.length > 0
+                text: ctrl.cycleText
+            }
+            XSeparator{ visible: ctrl.simulating; color: "#2c3e50" }
+            Label {
+                visible: ctrl.simulating
+                text: "t = " + ctrl.simClock
+            }
             XSeparator{ visible: view.lock; color: "#2c3e50" }
             Label {
                 visible: view.lock
@@ -193,6 +325,23 @@ ApplicationWindow {
         }
     }
 
+    Menu {
+        id: synthesisMenu
+        title: "Synthesize"
+        MenuItem {
+            text: "Precompensator and feedback"
+            onTriggered: ctrl.synthesize(true, true)
+        }
+        MenuItem {
+            text: "Precompensator P only"
+            onTriggered: ctrl.synthesize(true, false)
+        }
+        MenuItem {
+            text: "Output feedback F only"
+            onTriggered: ctrl.synthesize(false, true)
+        }
+    }
+
     MessageDialog {
         id: about
         icon: StandardIcon.Information
@@ -233,9 +382,15 @@ ApplicationWindow {
         id: savePic
         title: "Choose file to save snapshot"
         selectExisting: false
-        nameFilters: [ "PNG Images (*.png)", "All files (*)" ]
+        nameFilters: [ "PNG Images (*.png)", "SVG Images (*.svg)", "PDF Documents (*.pdf)", "TikZ Pictures (*.tikz)", "All files (*)" ]
         onAccepted: {
-            var ok = takeScreenshot(("" + fileUrl).replace("file://", ""))
+            var path = ("" + fileUrl).replace("file://", "")
+            var ok
+            if(ctrl.isPicture(path)) {
+                ok = ctrl.exportPicture(path)
+            } else {
+                ok = takeScreenshot(path)
+            }
             if(!ok) {
                 ctrl.qmlError("Unable to save snapshot")
             }
@@ -249,7 +404,7 @@ ApplicationWindow {
         id: openFile
         title: "Choose file to load model"
         selectExisting: true
-        nameFilters: [ "TEG files (*.teg *.json)", "All files (*)" ]
+        nameFilters: [ "TEG files (*.teg *.json)", "PNML nets (*.pnml)", "All files (*)" ]
         onAccepted: {
             var path = "" + fileUrl
             ctrl.openFile(path.replace("file://", ""))
@@ -263,7 +418,7 @@ ApplicationWindow {
         id: saveFile
         title: "Choose file to save model"
         selectExisting: false
-        nameFilters: [ "TEG files (*.teg *.json)", "All files (*)" ]
+        nameFilters: [ "TEG files (*.teg *.json)", "PNML nets (*.pnml)", "All files (*)" ]
         onAccepted: {
             var path = "" + fileUrl
             ctrl.saveFile(path.replace("file://", ""))
@@ -310,7 +465,12 @@ ApplicationWindow {
                         ListElement {key: "Ctrl+G"; hint: "Group selected items or flatten group"}
                         ListElement {key: "Ctrl+O"; hint: "Open group's model in new window"}
                         ListElement {key: "Ctrl+Z"; hint: "Fold/unfold a group"}
+                        ListElement {key: "Ctrl+R"; hint: "Lay out selected items or the whole model"}
+                        ListElement {key: "Ctrl+U"; hint: "Undo the last edit"}
+                        ListElement {key: "Ctrl+Y"; hint: "Redo the undone edit"}
                         ListElement {key: "Ctrl+L"; hint: "Toggle view only mode"}
+                        ListElement {key: "Ctrl+S"; hint: "Step the simulation"}
+                        ListElement {key: "λ"; hint: "Highlight the critical circuit and deadlocks"}
                         ListElement {key: "Ctrl+W"; hint: "Close window"}
                     }
                     delegate: keyHint
@@ -340,6 +500,117 @@ ApplicationWindow {
         }
     }
 
+    Rectangle {
+        visible: view.daters
+        anchors.fill: parent
+        color: "#D0000000"
+        z: 10
+        MouseArea {
+            anchors.fill: parent
+            onClicked: view.daters = false
+        }
+        ColumnLayout {
+            anchors.fill: parent
+            anchors.margins: 30
+            spacing: 10
+            Text {
+                color: "white"
+                font.pixelSize: 16
+                font.bold: true
+                text: "Earliest firing dates x(k)"
+            }
+            TextArea {
+                Layout.fillWidth: true
+                Layout.fillHeight: true
+                readOnly: true
+                textFormat: TextEdit.PlainText
+                font.family: "monospace"
+                font.pixelSize: 14
+                text: ctrl.daterText
+            }
+        }
+    }
+
+    Rectangle {
+        visible: view.compare
+        anchors.fill: parent
+        color: "#D0000000"
+        z: 10
+        MouseArea {
+            anchors.fill: parent
+            onClicked: view.compare = false
+        }
+        ColumnLayout {
+            anchors.fill: parent
+            anchors.margins: 30
+            spacing: 10
+            Text {
+                color: "white"
+                font.pixelSize: 16
+                font.bold: true
+                text: "Transfers of the selected groups"
+            }
+            TextArea {
+                Layout.fillWidth: true
+                Layout.fillHeight: true
+                readOnly: true
+                textFormat: TextEdit.PlainText
+                font.family: "monospace"
+                font.pixelSize: 14
+                text: ctrl.compareText
+            }
+        }
+    }
+
+    Rectangle {
+        visible: view.lint
+        anchors.fill: parent
+        color: "#D0000000"
+        z: 10
+        MouseArea {
+            anchors.fill: parent
+            onClicked: view.lint = false
+        }
+        ColumnLayout {
+            anchors.fill: parent
+            anchors.margins: 30
+            spacing: 10
+            Text {
+                color: "white"
+                font.pixelSize: 16
+                font.bold: true
+                text: diagnostics.count > 0 ? "Diagnostics" : "No problems found"
+            }
+            ScrollView {
+                Layout.fillWidth: true
+                Layout.fillHeight: true
+                ListView {
+                    id: diagnostics
+                    model: ctrl.diagnostics ? ctrl.diagnostics.length : 0
+                    spacing: 6
+                    delegate: Text {
+                        property var diagnostic: ctrl.diagnostics.at(index)
+                        color: diagnostic.severity > 0 ? "#e74c3c" : "#f1c40f"
+                        font.pixelSize: 14
+                        text: (diagnostic.severity > 0 ? "error: " : "warning: ") + diagnostic.text
+                        MouseArea {
+                            anchors.fill: parent
+                            cursorShape: Qt.PointingHandCursor
+                            onClicked: {
+                                var focus = ctrl.lintFocus(index)
+                                if(focus) {
+                                    cv.canvasWindow.x = cv.canvasSize.width / 2 + focus.x
+                                    cv.canvasWindow.y = cv.canvasSize.height / 2 + focus.y
+                                }
+                                view.lint = false
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    }
+
     Canvas {
         id: cv
         anchors.fill: parent
@@ -430,6 +701,8 @@ ApplicationWindow {
                 tglLock.enabled = !tglLock.enabled
             } else if(ctrl.modifierKeyControl && event.key === Qt.Key_W) {
                 Qt.quit()
+            } else if(ctrl.modifierKeyControl && event.key === Qt.Key_S && ctrl.simulating) {
+                ctrl.simStep()
             } else {
                 ctrl.keyPressed(event.key, event.text)
             }
@@ -550,6 +823,20 @@ ApplicationWindow {
         canvasWindowWidth: cv.canvasWindow.width
         zoom: 1.0
 
+        onDaterTextChanged: {
+            view.daters = daterText.length > 0
+        }
+
+        onDiagnosticsChanged: {
+            if(ctrl.diagnostics.length > 0) {
+                view.lint = true
+            }
+        }
+
+        onCompareTextChanged: {
+            view.compare = compareText.length > 0
+        }
+
         onErrorTextChanged: {
             if(errorText.length > 0) {
                 view.sane = false
@@ -562,6 +849,14 @@ ApplicationWindow {
         }
     }
 
+    Timer {
+        id: simTimer
+        interval: 500
+        repeat: true
+        running: ctrl.simRunning
+        onTriggered: ctrl.simStep()
+    }
+
     Timer {
         id: errorHide
         interval: 5000
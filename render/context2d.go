@@ -58,8 +58,8 @@ type Poly struct {
 	Length int
 }
 
-func NewPoly(pts ...*Point) *Chain {
-	return &Chain{points: pts, Length: len(pts)}
+func NewPoly(pts ...*Point) *Poly {
+	return &Poly{points: pts, Length: len(pts)}
 }
 
 func (c *Poly) At(i int) *Point {
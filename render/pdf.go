@@ -0,0 +1,353 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf16"
+)
+
+// WritePDF writes the picture as a single page PDF document,
+// one pixel of the canvas is one point of the page. Texts are set
+// with the standard Times fonts, so nothing has to be embedded. The
+// characters beyond ASCII get the upper codes of the font encoding
+// by their glyph names, there is room for 128 of them, the viewers
+// find the glyphs in a serif font of their own.
+func WritePDF(w io.Writer, p *Picture) error {
+	pdf := &pdfWriter{
+		alphas: make(map[[2]uint8]int),
+		codes:  make(map[rune]byte),
+	}
+	c := &pdf.content
+	fmt.Fprintf(c, "1 0 0 -1 0 %s cm\n", num(p.Height))
+	for _, it := range p.Items {
+		switch it := it.(type) {
+		case *Line:
+			pdf.begin(it.Style)
+			fmt.Fprintf(c, "%s %s m %s %s l\n", num(it.Start.X), num(it.Start.Y), num(it.End.X), num(it.End.Y))
+			pdf.paint(it.Style)
+		case *Chain:
+			if it.Length > 2 {
+				pdf.begin(it.Style)
+				pdf.polyline(it.points)
+				pdf.paint(it.Style)
+			}
+		case *Poly:
+			if it.Length > 2 {
+				pdf.begin(it.Style)
+				pdf.polyline(it.points)
+				fmt.Fprintf(c, "h\n")
+				pdf.paint(it.Style)
+			}
+		case *Circle:
+			pdf.begin(it.Style)
+			r := it.D / 2
+			pdf.roundedRect(it.X, it.Y, it.D, it.D, r)
+			pdf.paint(it.Style)
+		case *Rect:
+			pdf.begin(it.Style)
+			fmt.Fprintf(c, "%s %s %s %s re\n", num(it.X), num(it.Y), num(it.W), num(it.H))
+			pdf.paint(it.Style)
+		case *RoundedRect:
+			pdf.begin(it.Style)
+			pdf.roundedRect(it.X, it.Y, it.W, it.H, it.R)
+			pdf.paint(it.Style)
+		case *BezierCurve:
+			pdf.begin(it.Style)
+			fmt.Fprintf(c, "%s %s m %s %s %s %s %s %s c\n",
+				num(it.Start.X), num(it.Start.Y), num(it.C1.X), num(it.C1.Y),
+				num(it.C2.X), num(it.C2.Y), num(it.End.X), num(it.End.Y))
+			pdf.paint(it.Style)
+		case *Text:
+			pdf.begin(it.Style)
+			pdf.text(it)
+			fmt.Fprintf(c, "Q\n")
+		default:
+			return fmt.Errorf("pdf: unknown primitive %T", it)
+		}
+	}
+	return pdf.write(w, p.Width, p.Height)
+}
+
+type pdfWriter struct {
+	content bytes.Buffer
+	// states are the fill and stroke opacities used, alphas tells their indexes
+	states [][2]uint8
+	alphas map[[2]uint8]int
+	// extra are the characters beyond ASCII from the code 128 on, codes tells their codes
+	extra []rune
+	codes map[rune]byte
+}
+
+// begin saves the graphics state and sets the colours of the style.
+func (pdf *pdfWriter) begin(s *Style) {
+	c := &pdf.content
+	fmt.Fprintf(c, "q\n")
+	if s == nil {
+		return
+	}
+	alpha := [2]uint8{255, 255}
+	if s.Fill {
+		r, g, b, a := rgba(s.FillStyle)
+		fmt.Fprintf(c, "%s %s %s rg\n", pdfColor(r), pdfColor(g), pdfColor(b))
+		alpha[0] = a
+	}
+	if s.Stroke {
+		r, g, b, a := rgba(s.StrokeStyle)
+		fmt.Fprintf(c, "%s %s %s RG %s w\n", pdfColor(r), pdfColor(g), pdfColor(b), num(s.LineWidth))
+		alpha[1] = a
+	}
+	if alpha[0] < 255 || alpha[1] < 255 {
+		i, ok := pdf.alphas[alpha]
+		if !ok {
+			i = len(pdf.states)
+			pdf.alphas[alpha] = i
+			pdf.states = append(pdf.states, alpha)
+		}
+		fmt.Fprintf(c, "/GS%d gs\n", i)
+	}
+}
+
+// paint fills and strokes the current path as the style asks and restores the graphics state.
+func (pdf *pdfWriter) paint(s *Style) {
+	op := "n"
+	switch {
+	case s == nil:
+	case s.Fill && s.Stroke:
+		op = "B"
+	case s.Fill:
+		op = "f"
+	case s.Stroke:
+		op = "S"
+	}
+	fmt.Fprintf(&pdf.content, "%s\nQ\n", op)
+}
+
+func (pdf *pdfWriter) polyline(pts []*Point) {
+	for i, pt := range pts {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(&pdf.content, "%s %s %s\n", num(pt.X), num(pt.Y), op)
+	}
+}
+
+// roundedRect draws the corners as quarters of a circle approximated
+// by Bézier curves, a circle is a square with the round corners.
+func (pdf *pdfWriter) roundedRect(x, y, w, h, r float64) {
+	r = math.Max(0, math.Min(r, math.Min(w, h)/2))
+	k := r * (1 - 0.5523) // how far the control points are from the corner
+	c := &pdf.content
+	fmt.Fprintf(c, "%s %s m\n", num(x+r), num(y))
+	fmt.Fprintf(c, "%s %s l\n", num(x+w-r), num(y))
+	fmt.Fprintf(c, "%s %s %s %s %s %s c\n", num(x+w-k), num(y), num(x+w), num(y+k), num(x+w), num(y+r))
+	fmt.Fprintf(c, "%s %s l\n", num(x+w), num(y+h-r))
+	fmt.Fprintf(c, "%s %s %s %s %s %s c\n", num(x+w), num(y+h-k), num(x+w-k), num(y+h), num(x+w-r), num(y+h))
+	fmt.Fprintf(c, "%s %s l\n", num(x+r), num(y+h))
+	fmt.Fprintf(c, "%s %s %s %s %s %s c\n", num(x+k), num(y+h), num(x), num(y+h-k), num(x), num(y+h-r))
+	fmt.Fprintf(c, "%s %s l\n", num(x), num(y+r))
+	fmt.Fprintf(c, "%s %s %s %s %s %s c\nh\n", num(x), num(y+k), num(x+k), num(y), num(x+r), num(y))
+}
+
+// text sets a line of text, the page is flipped upside down
+// so the text matrix flips the glyphs back.
+func (pdf *pdfWriter) text(t *Text) {
+	font, mode := "F1", 0
+	if t.Oblique {
+		font = "F2"
+	}
+	if t.Style != nil {
+		switch {
+		case t.Fill && t.Stroke:
+			mode = 2
+		case t.Stroke:
+			mode = 1
+		case !t.Fill:
+			mode = 3
+		}
+	}
+	label := pdf.encode(t.Label)
+	var offset float64
+	switch t.Align {
+	case TextAlignCenter:
+		offset = textWidth(label, t.FontSize) / 2
+	case TextAlignRight:
+		offset = textWidth(label, t.FontSize)
+	}
+	matrix := "1 0 0 -1"
+	if t.Vertical {
+		matrix = "0 1 1 0"
+	}
+	fmt.Fprintf(&pdf.content, "BT /%s %s Tf %d Tr %s %s %s Tm %s 0 Td (%s) Tj ET\n",
+		font, num(t.FontSize), mode, matrix, num(t.X), num(t.Y), num(-offset), pdfEscape(label))
+}
+
+func (pdf *pdfWriter) write(w io.Writer, width, height float64) error {
+	buf := bufio.NewWriter(w)
+	var offsets []int
+	var written int
+	put := func(format string, args ...interface{}) {
+		n, _ := fmt.Fprintf(buf, format, args...)
+		written += n
+	}
+	object := func(format string, args ...interface{}) {
+		offsets = append(offsets, written)
+		put("%d 0 obj\n", len(offsets))
+		put(format, args...)
+		put("\nendobj\n")
+	}
+
+	var states bytes.Buffer
+	for i, alpha := range pdf.states {
+		fmt.Fprintf(&states, " /GS%d << /ca %s /CA %s >>", i,
+			num(float64(alpha[0])/255), num(float64(alpha[1])/255))
+	}
+	put("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Contents 4 0 R "+
+		"/Resources << /Font << /F1 5 0 R /F2 6 0 R >> /ExtGState <<%s >> >> >>",
+		num(width), num(height), states.String())
+	object("<< /Length %d >>\nstream\n%sendstream", pdf.content.Len(), pdf.content.String())
+	for _, font := range []string{"Times-Roman", "Times-Italic"} {
+		object("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding 7 0 R "+
+			"/FirstChar 32 /LastChar 255 /Widths [%s] /ToUnicode 8 0 R >>", font, pdf.widths())
+	}
+	object("<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [128%s] >>", pdf.differences())
+	cmap := pdf.toUnicode()
+	object("<< /Length %d >>\nstream\n%sendstream", len(cmap), cmap)
+
+	xref := written
+	put("xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		put("%010d 00000 n \n", off)
+	}
+	put("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Flush()
+}
+
+func pdfColor(c uint8) string {
+	return num(float64(c) / 255)
+}
+
+// encode gives the codes of the characters, the new ones beyond ASCII
+// take the next free code, '?' stands for the rest.
+func (pdf *pdfWriter) encode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r >= 32 && r < 127 {
+			b = append(b, byte(r))
+			continue
+		}
+		code, ok := pdf.codes[r]
+		if !ok && len(pdf.extra) < 128 && r > 127 {
+			code, ok = byte(128+len(pdf.extra)), true
+			pdf.codes[r] = code
+			pdf.extra = append(pdf.extra, r)
+		}
+		if !ok {
+			code = '?'
+		}
+		b = append(b, code)
+	}
+	return b
+}
+
+func (pdf *pdfWriter) widths() string {
+	var buf bytes.Buffer
+	for c := 32; c < 256; c++ {
+		if c > 32 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprint(&buf, charWidth(byte(c)))
+	}
+	return buf.String()
+}
+
+// differences names the glyphs of the upper codes after their characters.
+func (pdf *pdfWriter) differences() string {
+	var buf bytes.Buffer
+	for _, r := range pdf.extra {
+		if r > 0xffff {
+			fmt.Fprintf(&buf, " /u%X", r)
+		} else {
+			fmt.Fprintf(&buf, " /uni%04X", r)
+		}
+	}
+	return buf.String()
+}
+
+// toUnicode lets the viewers copy and search the texts.
+func (pdf *pdfWriter) toUnicode() string {
+	var buf bytes.Buffer
+	buf.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<00> <FF>\nendcodespacerange\n" +
+		"1 beginbfrange\n<20> <7E> <0020>\nendbfrange\n")
+	for i := 0; i < len(pdf.extra); i += 100 {
+		chunk := pdf.extra[i:]
+		if len(chunk) > 100 {
+			chunk = chunk[:100]
+		}
+		fmt.Fprintf(&buf, "%d beginbfchar\n", len(chunk))
+		for j, r := range chunk {
+			fmt.Fprintf(&buf, "<%02X> <", 128+i+j)
+			for _, u := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&buf, "%04X", u)
+			}
+			buf.WriteString(">\n")
+		}
+		buf.WriteString("endbfchar\n")
+	}
+	buf.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return buf.String()
+}
+
+func pdfEscape(s []byte) string {
+	var buf bytes.Buffer
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c > 126:
+			fmt.Fprintf(&buf, "\\%03o", c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// timesWidths are the widths of the printable ASCII characters
+// in Times-Roman, in thousandths of the font size.
+var timesWidths = [...]int{
+	250, 333, 408, 500, 500, 833, 778, 180, 333, 333, 500, 564, 250, 333, 250, 278,
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444,
+	921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722,
+	556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500,
+	333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500,
+	500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541,
+}
+
+// charWidth tells the width of the code, the characters
+// beyond ASCII are taken as wide as a digit.
+func charWidth(c byte) int {
+	if c >= 32 && int(c-32) < len(timesWidths) {
+		return timesWidths[c-32]
+	}
+	return 500
+}
+
+// textWidth measures a line for the alignment, italics are close enough to the roman widths.
+func textWidth(s []byte, size float64) float64 {
+	var w int
+	for _, c := range s {
+		w += charWidth(c)
+	}
+	return float64(w) * size / 1000
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func (t *testSuite) TestWritePDF() {
+	var buf bytes.Buffer
+	t.Nil(WritePDF(&buf, sample()))
+	data := buf.Bytes()
+	t.True(bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	t.True(bytes.HasSuffix(data, []byte("%%EOF\n")))
+
+	// startxref points at the table, the table at each object
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	t.Not(m == nil)
+	if m == nil {
+		return
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	table := string(data[xref:])
+	t.True(strings.HasPrefix(table, "xref\n0 9\n0000000000 65535 f \n"))
+	lines := strings.Split(table, "\n")
+	for i := 1; i < 9; i++ {
+		// the entries take 20 bytes with the end of line
+		entry := lines[i+2]
+		t.Equal(20, len(entry)+1)
+		t.True(strings.HasSuffix(entry, " 00000 n "))
+		off, err := strconv.Atoi(entry[:10])
+		t.Nil(err)
+		obj := fmt.Sprintf("%d 0 obj\n", i)
+		t.True(off < xref && bytes.HasPrefix(data[off:], []byte(obj)), obj)
+	}
+	t.True(strings.Contains(table, "trailer\n<< /Size 9 /Root 1 0 R >>\n"))
+
+	// the length of the content stream is right
+	m = regexp.MustCompile(`4 0 obj\n<< /Length (\d+) >>\nstream\n`).FindSubmatch(data)
+	t.Not(m == nil)
+	if m == nil {
+		return
+	}
+	length, _ := strconv.Atoi(string(m[1]))
+	start := bytes.Index(data, m[0]) + len(m[0])
+	t.True(bytes.HasPrefix(data[start+length:], []byte("endstream\n")))
+
+	// τ and ₁ take the first upper codes and are named after their code points
+	content := string(data[start : start+length])
+	t.True(strings.Contains(content, "(\\200\\201 < 2) Tj"))
+	t.True(strings.Contains(string(data), "/Differences [128 /uni03C4 /uni2081]"))
+	t.True(strings.Contains(string(data), "<80> <03C4>\n<81> <2081>\n"))
+	// the translucent stroke gets a graphics state
+	t.True(strings.Contains(string(data), "/ExtGState << /GS0 << /ca 1 /CA 0.5 >> >>"))
+	t.True(strings.Contains(content, "/GS0 gs\n"))
+}
+
+func (t *testSuite) TestPDFEncode() {
+	pdf := &pdfWriter{codes: make(map[rune]byte)}
+	t.Equal("a\x80\x81\x80", string(pdf.encode("aτ₁τ")))
+	t.Equal("?", string(pdf.encode("\t")))
+	for r := rune(0x400); r < 0x480; r++ {
+		pdf.encode(string(r))
+	}
+	// there is no room left beyond the first 128
+	t.Equal(128, len(pdf.extra))
+	t.Equal("?", string(pdf.encode("Ω")))
+	t.Equal(`(a\)\\\200`, "("+pdfEscape([]byte("a)\\\x80")))
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Picture is a drawing made of the primitives above, they are
+// painted in the order they were added, like on the canvas.
+type Picture struct {
+	Width, Height float64
+	Items         []interface{}
+}
+
+func NewPicture(width, height float64) *Picture {
+	return &Picture{Width: width, Height: height}
+}
+
+func (p *Picture) Add(items ...interface{}) {
+	p.Items = append(p.Items, items...)
+}
+
+// PictureFormats are the formats WritePicture knows.
+var PictureFormats = []string{"svg", "pdf", "tikz"}
+
+// WritePicture writes the picture as a SVG image, a PDF document or a TikZ picture.
+func WritePicture(w io.Writer, p *Picture, format string) error {
+	switch format {
+	case "svg":
+		return WriteSVG(w, p)
+	case "pdf":
+		return WritePDF(w, p)
+	case "tikz":
+		return WriteTikZ(w, p)
+	}
+	return fmt.Errorf("unknown picture format %q, want one of %s",
+		format, strings.Join(PictureFormats, ", "))
+}
+
+// rgba reads colours the way the canvas does, either #rrggbb or #aarrggbb.
+// Anything else is drawn black.
+func rgba(color string) (r, g, b, a uint8) {
+	if !strings.HasPrefix(color, "#") {
+		return 0, 0, 0, 255
+	}
+	hex := color[1:]
+	if len(hex) == 6 {
+		hex = "ff" + hex
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 8 || err != nil {
+		return 0, 0, 0, 255
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), uint8(v >> 24)
+}
+
+// num prints a coordinate without noise, 0.01 is finer than anyone can see.
+func num(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
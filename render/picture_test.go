@@ -0,0 +1,66 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/remogatto/prettytest"
+)
+
+type testSuite struct {
+	prettytest.Suite
+}
+
+func TestRunner(t *testing.T) {
+	prettytest.RunWithFormatter(
+		t,
+		new(prettytest.TDDFormatter),
+		new(testSuite),
+	)
+}
+
+// sample draws a place holding a token linked to a transition,
+// with a label beyond ASCII under the transition.
+func sample() *Picture {
+	p := NewPicture(200, 100)
+	stroke := &Style{LineWidth: 2, StrokeStyle: "#000000", Stroke: true}
+	p.Add(
+		&Rect{Style: &Style{FillStyle: "#ffffff", Fill: true}, X: 0, Y: 0, W: 200, H: 100},
+		&Circle{Style: stroke, X: 20, Y: 30, D: 40},
+		&Circle{Style: &Style{FillStyle: "#000000", Fill: true}, X: 37, Y: 47, D: 6},
+		&BezierCurve{Style: stroke, Start: &Point{60, 50}, C1: &Point{80, 40},
+			C2: &Point{100, 40}, End: &Point{118, 50}},
+		NewPoly(&Point{118, 45}, &Point{126, 50}, &Point{118, 55}),
+		&RoundedRect{Style: &Style{LineWidth: 1, StrokeStyle: "#80ff0000", Stroke: true},
+			X: 126, Y: 20, W: 10, H: 60, R: 2},
+		&Text{Style: &Style{FillStyle: "#000000", Fill: true}, X: 131, Y: 95,
+			FontSize: 12, Oblique: true, Align: TextAlignCenter, Font: "Times", Label: "τ₁ < 2"},
+	)
+	return p
+}
+
+func (t *testSuite) TestWritePicture() {
+	var buf bytes.Buffer
+	for _, format := range PictureFormats {
+		buf.Reset()
+		t.Nil(WritePicture(&buf, sample(), format))
+		t.True(buf.Len() > 0)
+	}
+	t.Not(WritePicture(&buf, sample(), "png") == nil)
+}
+
+func (t *testSuite) TestRgba() {
+	r, g, b, a := rgba("#102030")
+	t.True(r == 0x10 && g == 0x20 && b == 0x30 && a == 255)
+	r, g, b, a = rgba("#80ff0000")
+	t.True(r == 255 && g == 0 && b == 0 && a == 0x80)
+	r, g, b, a = rgba("red")
+	t.True(r == 0 && g == 0 && b == 0 && a == 255)
+}
+
+func (t *testSuite) TestNum() {
+	t.Equal("1.5", num(1.5))
+	t.Equal("2", num(2.001))
+	t.Equal("0", num(-0.001))
+	t.Equal("-3.25", num(-3.25))
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteSVG writes the picture as a standalone SVG image,
+// one pixel of the canvas is one user unit.
+func WriteSVG(w io.Writer, p *Picture) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" version=\"1.1\" "+
+		"width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		num(p.Width), num(p.Height), num(p.Width), num(p.Height))
+	for _, it := range p.Items {
+		switch it := it.(type) {
+		case *Line:
+			fmt.Fprintf(buf, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"%s/>\n",
+				num(it.Start.X), num(it.Start.Y), num(it.End.X), num(it.End.Y), svgStyle(it.Style))
+		case *Chain:
+			if it.Length > 2 {
+				fmt.Fprintf(buf, "<polyline points=\"%s\"%s/>\n", svgPoints(it.points), svgStyle(it.Style))
+			}
+		case *Poly:
+			if it.Length > 2 {
+				fmt.Fprintf(buf, "<polygon points=\"%s\"%s/>\n", svgPoints(it.points), svgStyle(it.Style))
+			}
+		case *Circle:
+			fmt.Fprintf(buf, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\"%s/>\n",
+				num(it.X+it.D/2), num(it.Y+it.D/2), num(it.D/2), svgStyle(it.Style))
+		case *Rect:
+			fmt.Fprintf(buf, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\"%s/>\n",
+				num(it.X), num(it.Y), num(it.W), num(it.H), svgStyle(it.Style))
+		case *RoundedRect:
+			fmt.Fprintf(buf, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" rx=\"%s\"%s/>\n",
+				num(it.X), num(it.Y), num(it.W), num(it.H), num(it.R), svgStyle(it.Style))
+		case *BezierCurve:
+			fmt.Fprintf(buf, "<path d=\"M%s %s C%s %s %s %s %s %s\"%s/>\n",
+				num(it.Start.X), num(it.Start.Y), num(it.C1.X), num(it.C1.Y),
+				num(it.C2.X), num(it.C2.Y), num(it.End.X), num(it.End.Y), svgStyle(it.Style))
+		case *Text:
+			writeSVGText(buf, it)
+		default:
+			return fmt.Errorf("svg: unknown primitive %T", it)
+		}
+	}
+	fmt.Fprintf(buf, "</svg>\n")
+	return buf.Flush()
+}
+
+func writeSVGText(w io.Writer, t *Text) {
+	anchor := "start"
+	switch t.Align {
+	case TextAlignCenter:
+		anchor = "middle"
+	case TextAlignRight:
+		anchor = "end"
+	}
+	attrs := fmt.Sprintf(" font-family=\"%s, serif\" font-size=\"%s\" text-anchor=\"%s\"",
+		svgEscape(t.Font), num(t.FontSize), anchor)
+	if t.Oblique {
+		attrs += " font-style=\"oblique\""
+	}
+	if t.Vertical {
+		attrs += fmt.Sprintf(" transform=\"rotate(90 %s %s)\"", num(t.X), num(t.Y))
+	}
+	fmt.Fprintf(w, "<text x=\"%s\" y=\"%s\"%s%s>%s</text>\n",
+		num(t.X), num(t.Y), attrs, svgStyle(t.Style), svgEscape(t.Label))
+}
+
+func svgPoints(pts []*Point) string {
+	list := make([]string, len(pts))
+	for i, pt := range pts {
+		list[i] = num(pt.X) + "," + num(pt.Y)
+	}
+	return strings.Join(list, " ")
+}
+
+func svgStyle(s *Style) string {
+	if s == nil {
+		return ""
+	}
+	paint := func(kind, color string) string {
+		r, g, b, a := rgba(color)
+		attr := fmt.Sprintf(" %s=\"#%02x%02x%02x\"", kind, r, g, b)
+		if a < 255 {
+			attr += fmt.Sprintf(" %s-opacity=\"%s\"", kind, num(float64(a)/255))
+		}
+		return attr
+	}
+	var attrs string
+	if s.Fill {
+		attrs += paint("fill", s.FillStyle)
+	} else {
+		attrs += " fill=\"none\""
+	}
+	if s.Stroke {
+		attrs += paint("stroke", s.StrokeStyle)
+		attrs += fmt.Sprintf(" stroke-width=\"%s\"", num(s.LineWidth))
+	}
+	return attrs
+}
+
+func svgEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
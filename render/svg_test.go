@@ -0,0 +1,14 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+)
+
+func (t *testSuite) TestWriteSVG() {
+	golden, err := ioutil.ReadFile("testdata/picture.svg")
+	t.Nil(err)
+	var buf bytes.Buffer
+	t.Nil(WriteSVG(&buf, sample()))
+	t.Equal(string(golden), buf.String())
+}
@@ -0,0 +1,128 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xlab/teg-workshop/util"
+)
+
+// WriteTikZ writes the picture as a tikzpicture environment to be input
+// into a LaTeX document using the tikz package. The y axis points down
+// like on the canvas, one pixel of the canvas is one point.
+func WriteTikZ(w io.Writer, p *Picture) error {
+	var body bytes.Buffer
+	colors := make(map[string]string)
+	var names []string
+	color := func(c string) (name string, alpha float64) {
+		r, g, b, a := rgba(c)
+		hex := fmt.Sprintf("%02X%02X%02X", r, g, b)
+		name, ok := colors[hex]
+		if !ok {
+			name = fmt.Sprintf("teg%d", len(names))
+			colors[hex] = name
+			names = append(names, fmt.Sprintf("\\definecolor{%s}{HTML}{%s}", name, hex))
+		}
+		return name, float64(a) / 255
+	}
+	style := func(s *Style) string {
+		if s == nil {
+			return ""
+		}
+		var opts []string
+		if s.Stroke {
+			name, alpha := color(s.StrokeStyle)
+			opts = append(opts, "draw="+name, "line width="+num(s.LineWidth)+"pt")
+			if alpha < 1 {
+				opts = append(opts, "draw opacity="+num(alpha))
+			}
+		}
+		if s.Fill {
+			name, alpha := color(s.FillStyle)
+			opts = append(opts, "fill="+name)
+			if alpha < 1 {
+				opts = append(opts, "fill opacity="+num(alpha))
+			}
+		}
+		return strings.Join(opts, ", ")
+	}
+	point := func(pt *Point) string {
+		return "(" + num(pt.X) + "," + num(pt.Y) + ")"
+	}
+	path := func(pts []*Point) string {
+		list := make([]string, len(pts))
+		for i, pt := range pts {
+			list[i] = point(pt)
+		}
+		return strings.Join(list, " -- ")
+	}
+
+	for _, it := range p.Items {
+		switch it := it.(type) {
+		case *Line:
+			fmt.Fprintf(&body, "\\path[%s] %s -- %s;\n", style(it.Style), point(it.Start), point(it.End))
+		case *Chain:
+			if it.Length > 2 {
+				fmt.Fprintf(&body, "\\path[%s] %s;\n", style(it.Style), path(it.points))
+			}
+		case *Poly:
+			if it.Length > 2 {
+				fmt.Fprintf(&body, "\\path[%s] %s -- cycle;\n", style(it.Style), path(it.points))
+			}
+		case *Circle:
+			fmt.Fprintf(&body, "\\path[%s] (%s,%s) circle[radius=%s];\n", style(it.Style),
+				num(it.X+it.D/2), num(it.Y+it.D/2), num(it.D/2))
+		case *Rect:
+			fmt.Fprintf(&body, "\\path[%s] (%s,%s) rectangle (%s,%s);\n", style(it.Style),
+				num(it.X), num(it.Y), num(it.X+it.W), num(it.Y+it.H))
+		case *RoundedRect:
+			fmt.Fprintf(&body, "\\path[%s, rounded corners=%spt] (%s,%s) rectangle (%s,%s);\n",
+				style(it.Style), num(it.R), num(it.X), num(it.Y), num(it.X+it.W), num(it.Y+it.H))
+		case *BezierCurve:
+			fmt.Fprintf(&body, "\\path[%s] %s .. controls %s and %s .. %s;\n", style(it.Style),
+				point(it.Start), point(it.C1), point(it.C2), point(it.End))
+		case *Text:
+			anchor := "base west"
+			switch it.Align {
+			case TextAlignCenter:
+				anchor = "base"
+			case TextAlignRight:
+				anchor = "base east"
+			}
+			opts := []string{"anchor=" + anchor, "inner sep=0"}
+			if it.Style != nil && it.Fill {
+				name, alpha := color(it.FillStyle)
+				opts = append(opts, "text="+name)
+				if alpha < 1 {
+					opts = append(opts, "text opacity="+num(alpha))
+				}
+			}
+			if it.Vertical {
+				opts = append(opts, "rotate=-90")
+			}
+			font := fmt.Sprintf("\\fontsize{%s}{%s}\\selectfont", num(it.FontSize), num(it.FontSize*1.2))
+			if it.Oblique {
+				font += "\\itshape"
+			}
+			fmt.Fprintf(&body, "\\node[%s, font=%s] at (%s,%s) {%s};\n",
+				strings.Join(opts, ", "), font, num(it.X), num(it.Y), util.TexEscape(it.Label))
+		default:
+			return fmt.Errorf("tikz: unknown primitive %T", it)
+		}
+	}
+
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "%% needs \\usepackage{tikz}\n")
+	fmt.Fprintf(buf, "\\begin{tikzpicture}[x=1pt, y=-1pt]\n")
+	for _, def := range names {
+		fmt.Fprintln(buf, def)
+	}
+	// keeps the margins of the picture
+	fmt.Fprintf(buf, "\\path (0,0) rectangle (%s,%s);\n", num(p.Width), num(p.Height))
+	body.WriteTo(buf)
+	fmt.Fprintf(buf, "\\end{tikzpicture}\n")
+	return buf.Flush()
+}
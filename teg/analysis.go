@@ -0,0 +1,177 @@
+package teg
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+var (
+	serieEps = dioid.Serie{P: dioid.Poly{dioid.Eps}, Q: dioid.Poly{dioid.Eps}, R: dioid.E}
+	serieE   = dioid.Serie{P: dioid.Poly{dioid.Eps}, Q: dioid.Poly{dioid.E}, R: dioid.E}
+)
+
+// Ref is an item within a particular instance of a group graph, graphs
+// may be shared by several groups. Path is made of the ids of the groups
+// leading to the instance, it is empty for the items of the graph itself.
+type Ref struct {
+	Path, Id string
+}
+
+// node is a transition within a particular instance of a group graph.
+type node struct {
+	path string
+	t    *Transition
+}
+
+func (n node) ref() Ref {
+	return Ref{n.path, n.t.Id}
+}
+
+// arc is a place between two nodes, path tells
+// which instance of a group graph holds the place.
+type arc struct {
+	from, to node
+	path     string
+	p        *Place
+}
+
+func (a arc) ref() Ref {
+	return Ref{a.path, a.p.Id}
+}
+
+// node returns the real transition behind t, the proxies
+// of a group lead into the graph of the group.
+func (t *Transition) node(path string) node {
+	for t.Proxy != nil {
+		path += "/" + t.Group.Id
+		t = t.Proxy
+	}
+	return node{path, t}
+}
+
+// Ref returns the real transition behind t like the analyses know it,
+// path is the path of the graph holding t.
+func (t *Transition) Ref(path string) Ref {
+	return t.node(path).ref()
+}
+
+// flatten unrolls the groups of the graph, each group gets
+// its own copy of the places and transitions of its graph.
+func (g *Graph) flatten(path string) (nodes []node, arcs []arc) {
+	for _, t := range g.Transitions {
+		nodes = append(nodes, node{path, t})
+	}
+	for _, p := range g.Places {
+		if p.In == nil || p.Out == nil {
+			continue
+		}
+		arcs = append(arcs, arc{p.In.node(path), p.Out.node(path), path, p})
+	}
+	for _, gr := range g.Groups {
+		n, a := gr.Graph.flatten(path + "/" + gr.Id)
+		nodes = append(nodes, n...)
+		arcs = append(arcs, a...)
+	}
+	return
+}
+
+// stateSpace builds the matrices of the system x = Ax ⊕ Bu, y = Cx.
+// Every transition which is not an input of the graph is a state,
+// every place between two transitions contributes γ^counter δ^timer.
+func (g *Graph) stateSpace() (a, b, c dioid.Matrix, inputs, outputs []*Transition) {
+	nodes, arcs := g.flatten("")
+	states := make(map[node]int, len(nodes))
+	ins := make(map[node]int)
+	for _, t := range g.Transitions {
+		if t.Kind == TransitionInput {
+			ins[node{"", t}] = len(inputs)
+			inputs = append(inputs, t)
+		}
+	}
+	for _, n := range nodes {
+		if _, ok := ins[n]; ok {
+			continue
+		}
+		states[n] = len(states)
+	}
+	for _, t := range g.Transitions {
+		if t.Kind == TransitionOutput {
+			outputs = append(outputs, t)
+		}
+	}
+
+	a = dioid.NewMatrix(len(states), len(states))
+	b = dioid.NewMatrix(len(states), len(inputs))
+	c = dioid.NewMatrix(len(outputs), len(states))
+	for _, arc := range arcs {
+		i, ok := states[arc.to]
+		if !ok {
+			continue
+		}
+		if j, ok := states[arc.from]; ok {
+			a[i][j] = dioid.SerieOplus(a[i][j], arc.p.Serie())
+		} else if j, ok := ins[arc.from]; ok {
+			b[i][j] = dioid.SerieOplus(b[i][j], arc.p.Serie())
+		}
+	}
+	for i, t := range outputs {
+		c[i][states[node{"", t}]] = serieE
+	}
+	return
+}
+
+// Transfer computes the transfer matrix H = CA*B of the graph,
+// rows are indexed by outputs and columns by inputs.
+func (g *Graph) Transfer() (h dioid.Matrix, inputs, outputs []*Transition) {
+	a, b, c, inputs, outputs := g.stateSpace()
+	if len(inputs) < 1 || len(outputs) < 1 {
+		// the library does not take empty matrices
+		return dioid.NewMatrix(len(outputs), len(inputs)), inputs, outputs
+	}
+	h = dioid.MatrixOtimes(c, dioid.MatrixOtimes(dioid.MatrixStar(a), b))
+	return
+}
+
+// JustInTime computes the latest inputs for the references of the outputs,
+// u = H\z the greatest causal u such that H u <= z. The references are given
+// by the ids of the outputs, the outputs without one do not bound the inputs.
+// The inputs are keyed by their ids, it gives nil without references.
+func (g *Graph) JustInTime(refs map[string]dioid.Serie) map[string]dioid.Serie {
+	h, inputs, outputs := g.Transfer()
+	var hr, z dioid.Matrix
+	for i, t := range outputs {
+		if ref, ok := refs[t.Id]; ok {
+			hr = append(hr, h[i])
+			z = append(z, []dioid.Serie{ref})
+		}
+	}
+	if len(z) < 1 || len(inputs) < 1 {
+		return nil
+	}
+	x := dioid.MatrixCausalProjection(dioid.MatrixLeftDiv(hr, z))
+	u := make(map[string]dioid.Serie, len(inputs))
+	for j, t := range inputs {
+		u[t.Id] = x[j][0]
+	}
+	return u
+}
+
+// Signature describes everything the analyses depend on, it stays
+// the same while the items are just moved around or renamed.
+func (g *Graph) Signature() string {
+	var buf bytes.Buffer
+	nodes, arcs := g.flatten("")
+	for _, t := range g.Transitions {
+		fmt.Fprintf(&buf, "t%s:%d;", t.Id, t.Kind)
+	}
+	for _, n := range nodes {
+		fmt.Fprintf(&buf, "n%s/%s;", n.path, n.t.Id)
+	}
+	for _, a := range arcs {
+		fmt.Fprintf(&buf, "a%s/%s:%s/%s:%d:%d;", a.from.path, a.from.t.Id,
+			a.to.path, a.to.t.Id, a.p.Counter, a.p.Timer)
+	}
+	return buf.String()
+}
@@ -0,0 +1,158 @@
+package teg
+
+import (
+	"fmt"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+func (t *testSuite) TestTransfer() {
+	g, _, _, _, _ := sample()
+	h, inputs, outputs := g.Transfer()
+	t.Equal(1, len(inputs))
+	t.Equal(1, len(outputs))
+	// a token goes around in 5 units of time
+	t.Equal("gd^3x(gd^5)*", h[0][0].String())
+}
+
+func (t *testSuite) TestJustInTime() {
+	g, u, _, _, y := sample()
+	t.True(g.JustInTime(nil) == nil)
+	z, err := dioid.Eval("gd^8x(gd^5)*")
+	t.Nil(err)
+	x := g.JustInTime(map[string]dioid.Serie{y.Id: z})
+	t.Equal(1, len(x))
+	// the inputs come 5 units of time before the outputs are due
+	t.Equal("d^5x(gd^5)*", x[u.Id].String())
+	h, _, _ := g.Transfer()
+	t.Equal(z.String(), dioid.SerieOtimes(h[0][0], x[u.Id]).String())
+}
+
+func (t *testSuite) TestCycleTime() {
+	g, _, t1, t2, _ := sample()
+	ct := g.CycleTime()
+	t.Equal("λ = 5", ct.String())
+	t.Equal(2, len(ct.Critical))
+	t.True(ct.CriticalTransitions[Ref{"", t1.Id}])
+	t.True(ct.CriticalPlaces[Ref{"", t2.Out[0].Id}])
+
+	// an empty circuit apart leaves the cycle time of the others
+	t4, t5 := g.AddTransition(0, 200), g.AddTransition(100, 200)
+	for _, inbound := range []bool{false, true} {
+		p := g.AddPlace(0, 0)
+		t4.Link(p, !inbound)
+		t5.Link(p, inbound)
+	}
+	ct = g.CycleTime()
+	t.Equal("Deadlock: 1 circuit(s) without tokens, elsewhere λ = 5", ct.String())
+	g.RemoveTransition(t4)
+	g.RemoveTransition(t5)
+
+	t1.Out[0].Counter = 0
+	ct = g.CycleTime()
+	t.Equal(1, len(ct.Deadlocks))
+	t.True(ct.DeadPlaces[Ref{"", t1.Out[0].Id}])
+	t.Equal("Deadlock: 1 circuit(s) without tokens", ct.String())
+
+	// t1 ⇄ t3 makes a second empty circuit in the same component,
+	// a single elementary one is reported but all the places are dead
+	t3 := g.AddTransition(100, 100)
+	for _, inbound := range []bool{false, true} {
+		p := g.AddPlace(0, 0)
+		t1.Link(p, !inbound)
+		t3.Link(p, inbound)
+	}
+	ct = g.CycleTime()
+	t.Equal(1, len(ct.Deadlocks))
+	t.Equal(2, len(ct.Deadlocks[0]))
+	t.Not(ct.Deadlocks[0][0] == ct.Deadlocks[0][1])
+	t.Equal(4, len(ct.DeadPlaces))
+}
+
+func (t *testSuite) TestDaters() {
+	g, u, t1, _, y := sample()
+	refs, x := g.Daters(map[string][]int{u.Id: {0, 0, 0}}, 3)
+	t.Equal(4, len(refs))
+	// the initial token lets t2 fire at once, t1 waits for it
+	t.Equal("[2 7 12]", fmt.Sprint(x[Ref{"", t1.Id}]))
+	t.Equal("[0 5 10]", fmt.Sprint(x[Ref{"", y.Id}]))
+}
+
+func (t *testSuite) TestPlaceLabels() {
+	g, u, t1, t2, _ := sample()
+	u.Out[0].Label, t1.Out[0].Label, t2.Out[0].Label = "p", "p", "p#2"
+	labels := g.PlaceLabels()
+	t.Equal("p", labels[Ref{"", u.Out[0].Id}])
+	t.Equal("p#3", labels[Ref{"", t1.Out[0].Id}])
+	t.Equal("p#2", labels[Ref{"", t2.Out[0].Id}])
+	t.Equal("p4", labels[Ref{"", t2.Out[1].Id}])
+	// the report keeps every place apart
+	r := g.Simulate(2)
+	t.Equal(len(g.Places), len(r.Steps[0].Tokens))
+}
+
+func (t *testSuite) TestSimulation() {
+	g, u, t1, t2, y := sample()
+	s := NewSimulation(g)
+	p := Ref{"", t1.Out[0].Id}
+	n, ok := s.Count(p)
+	t.True(ok)
+	t.Equal(1, n)
+	// u is not driven so t1 never waits for it, t2 fires at once
+	// then y gets the token of t2, the token back to t1 is there at 2
+	t.True(s.Flooded(Ref{"", u.Out[0].Id}))
+	t.True(s.Step())
+	t.Equal(0, s.Clock)
+	t.True(s.Fired(Ref{"", t2.Id}))
+	t.False(s.Fired(Ref{"", u.Id}))
+	t.True(s.Step())
+	t.Equal(0, s.Clock)
+	t.True(s.Fired(Ref{"", y.Id}))
+	t.True(s.Step())
+	t.Equal(2, s.Clock)
+	t.True(s.Fired(Ref{"", t1.Id}))
+	t.True(s.Changed(p))
+	m := s.Marking()
+	t.True(s.Step())
+	t.Equal(5, s.Clock)
+	t.True(s.Fired(Ref{"", t2.Id}))
+	// the marking of a step is left as is by the next one
+	t.Equal(2, m.Clock)
+	t.True(m.Fired(Ref{"", t1.Id}))
+	t.False(m.Fired(Ref{"", t2.Id}))
+}
+
+func (t *testSuite) TestSimulationInputs() {
+	g, u, t1, _, _ := sample()
+	// u fires at 0 and 4, then without limit from 9 on
+	g.Infos = []*Info{{IoId: u.Id, Serie: dioid.Serie{
+		P: dioid.Poly{{G: 0, D: 0}, {G: 1, D: 4}, {G: 2, D: 9}},
+		Q: dioid.Poly{dioid.Eps}, R: dioid.E}}}
+	s := NewSimulation(g)
+	in := Ref{"", u.Out[0].Id}
+	t.False(s.Flooded(in))
+	t.True(s.Step())
+	t.Equal(0, s.Clock)
+	t.True(s.Fired(Ref{"", u.Id}))
+	var inputs, dates []int
+	for s.Step() && s.Clock < 20 {
+		if s.Fired(Ref{"", u.Id}) {
+			inputs = append(inputs, s.Clock)
+		}
+		if s.Fired(Ref{"", t1.Id}) {
+			dates = append(dates, s.Clock)
+		}
+	}
+	t.Equal("[4]", fmt.Sprint(inputs))
+	t.True(s.Flooded(in))
+	// t1 keeps the pace of its circuit, like the daters tell
+	_, x := g.Daters(map[string][]int{u.Id: SerieDaters(g.Infos[0].Serie, 4)}, 4)
+	t.Equal("[2 7 12 17]", fmt.Sprint(x[Ref{"", t1.Id}]))
+	t.Equal(fmt.Sprint(x[Ref{"", t1.Id}]), fmt.Sprint(dates))
+
+	r := g.Simulate(20)
+	t.False(r.Deadlock)
+	t1.Out[0].Counter = 0
+	r = g.Simulate(20)
+	t.True(r.Deadlock)
+}
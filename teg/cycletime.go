@@ -0,0 +1,315 @@
+package teg
+
+import (
+	"fmt"
+	"math"
+)
+
+const howardEps = 1e-9
+
+// CycleTime describes the asymptotic behaviour of a graph. The cycle time is the
+// greatest ratio Σtimer/Σcounter over the circuits of the graph, it is reached
+// on the critical circuit. Circuits without tokens are structural deadlocks,
+// one of them is given for every strongly connected component they make up
+// while DeadPlaces holds all the empty places of those components.
+// The circuits are given by their transitions in the order of the arcs.
+type CycleTime struct {
+	Timer, Counter int
+	Critical       []Ref
+	Deadlocks      [][]Ref
+
+	// the places and transitions to highlight
+	CriticalPlaces      map[Ref]bool
+	CriticalTransitions map[Ref]bool
+	DeadPlaces          map[Ref]bool
+}
+
+// Defined tells whether the graph has a circuit at all.
+func (ct *CycleTime) Defined() bool {
+	return ct.Counter > 0
+}
+
+func (ct *CycleTime) Value() float64 {
+	return float64(ct.Timer) / float64(ct.Counter)
+}
+
+// String tells the deadlocks and the cycle time of the components
+// left live by them, if there are any.
+func (ct *CycleTime) String() string {
+	var lambda string
+	switch {
+	case !ct.Defined():
+		lambda = "No circuits"
+	case ct.Timer%ct.Counter == 0:
+		lambda = fmt.Sprintf("λ = %d", ct.Timer/ct.Counter)
+	default:
+		lambda = fmt.Sprintf("λ = %d/%d ≈ %.3f", ct.Timer, ct.Counter, ct.Value())
+	}
+	if len(ct.Deadlocks) < 1 {
+		return lambda
+	}
+	dead := fmt.Sprintf("Deadlock: %d circuit(s) without tokens", len(ct.Deadlocks))
+	if !ct.Defined() {
+		return dead
+	}
+	return dead + ", elsewhere " + lambda
+}
+
+// components splits the graph into strongly connected components by
+// the Tarjan algorithm, only the arcs accepted by use are followed.
+func components(nodes []node, arcs []arc, use func(a arc) bool) [][]node {
+	out := make(map[node][]arc, len(nodes))
+	for _, a := range arcs {
+		if use(a) {
+			out[a.from] = append(out[a.from], a)
+		}
+	}
+	index := make(map[node]int, len(nodes))
+	low := make(map[node]int, len(nodes))
+	onStack := make(map[node]bool, len(nodes))
+	var stack []node
+	var result [][]node
+	var visit func(v node)
+	visit = func(v node) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, a := range out[v] {
+			if _, ok := index[a.to]; !ok {
+				visit(a.to)
+				if low[a.to] < low[v] {
+					low[v] = low[a.to]
+				}
+			} else if onStack[a.to] && index[a.to] < low[v] {
+				low[v] = index[a.to]
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var comp []node
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			comp = append(comp, w)
+			if w == v {
+				break
+			}
+		}
+		result = append(result, comp)
+	}
+	for _, v := range nodes {
+		if _, ok := index[v]; !ok {
+			visit(v)
+		}
+	}
+	return result
+}
+
+// inside gives the arcs with both ends in the component,
+// the component is a circuit if there is any.
+func inside(comp []node, arcs []arc, use func(a arc) bool) []arc {
+	set := make(map[node]bool, len(comp))
+	for _, v := range comp {
+		set[v] = true
+	}
+	var result []arc
+	for _, a := range arcs {
+		if use(a) && set[a.from] && set[a.to] {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// circuit walks back the chosen incoming arcs from v until it loops,
+// the arcs of the loop are returned in the forward order.
+func circuit(v node, policy map[node]arc) []arc {
+	seen := make(map[node]bool)
+	for !seen[v] {
+		seen[v] = true
+		v = policy[v].from
+	}
+	var result []arc
+	for start := v; ; {
+		a := policy[v]
+		result = append([]arc{a}, result...)
+		if v = a.from; v == start {
+			break
+		}
+	}
+	return result
+}
+
+// howard finds the circuit of the greatest Σtimer/Σcounter ratio in a strongly
+// connected component by the policy iteration of Howard, every circuit of the
+// component should hold tokens. A policy picks an incoming arc for each node.
+func howard(comp []node, arcs []arc) []arc {
+	into := make(map[node][]arc, len(comp))
+	for _, a := range arcs {
+		into[a.to] = append(into[a.to], a)
+	}
+	policy := make(map[node]arc, len(comp))
+	for _, v := range comp {
+		best := into[v][0]
+		for _, a := range into[v][1:] {
+			if a.p.Timer > best.p.Timer {
+				best = a
+			}
+		}
+		policy[v] = best
+	}
+
+	eta := make(map[node]float64, len(comp))
+	value := make(map[node]float64, len(comp))
+	for {
+		// value determination
+		done := make(map[node]bool, len(comp))
+		var resolve func(v node)
+		resolve = func(v node) {
+			if done[v] {
+				return
+			}
+			c := circuit(v, policy)
+			var timer, counter int
+			for _, a := range c {
+				timer += a.p.Timer
+				counter += a.p.Counter
+			}
+			// the root keeps its value from the previous policy,
+			// otherwise the iteration may cycle on equal ratios
+			root := c[0].from
+			if !done[root] {
+				eta[root] = float64(timer) / float64(counter)
+				done[root] = true
+			}
+			var walk func(v node)
+			walk = func(v node) {
+				if done[v] {
+					return
+				}
+				a := policy[v]
+				walk(a.from)
+				eta[v] = eta[a.from]
+				value[v] = value[a.from] + float64(a.p.Timer) - eta[v]*float64(a.p.Counter)
+				done[v] = true
+			}
+			for _, a := range c {
+				walk(a.to)
+			}
+			walk(v)
+		}
+		for _, v := range comp {
+			resolve(v)
+		}
+
+		// policy improvement, a better ratio comes first
+		changed := false
+		for _, v := range comp {
+			for _, a := range into[v] {
+				if eta[a.from] > eta[v]+howardEps && eta[a.from] > eta[policy[v].from]+howardEps {
+					policy[v] = a
+					changed = true
+				}
+			}
+		}
+		if changed {
+			continue
+		}
+		for _, v := range comp {
+			best := value[v]
+			for _, a := range into[v] {
+				if math.Abs(eta[a.from]-eta[v]) > howardEps {
+					continue
+				}
+				x := value[a.from] + float64(a.p.Timer) - eta[v]*float64(a.p.Counter)
+				if x > best+howardEps {
+					best = x
+					policy[v] = a
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	var best node
+	for i, v := range comp {
+		if i == 0 || eta[v] > eta[best] {
+			best = v
+		}
+	}
+	return circuit(best, policy)
+}
+
+// CycleTime computes the cycle time of the graph with its groups unrolled.
+// The components holding a circuit without tokens are reported as deadlocks
+// and left out of the cycle time.
+func (g *Graph) CycleTime() *CycleTime {
+	nodes, arcs := g.flatten("")
+	ct := &CycleTime{
+		CriticalPlaces:      make(map[Ref]bool),
+		CriticalTransitions: make(map[Ref]bool),
+		DeadPlaces:          make(map[Ref]bool),
+	}
+	all := func(a arc) bool { return true }
+	empty := func(a arc) bool { return a.p.Counter == 0 }
+
+	dead := make(map[node]bool)
+	for _, comp := range components(nodes, arcs, empty) {
+		if c := inside(comp, arcs, empty); len(c) > 0 {
+			// every node of the component has an empty arc coming in,
+			// walking them back finds an elementary circuit
+			policy := make(map[node]arc, len(comp))
+			for _, a := range c {
+				policy[a.to] = a
+				ct.DeadPlaces[a.ref()] = true
+			}
+			ct.Deadlocks = append(ct.Deadlocks, circuitRefs(circuit(comp[0], policy)))
+			for _, v := range comp {
+				dead[v] = true
+			}
+		}
+	}
+	var critical []arc
+next:
+	for _, comp := range components(nodes, arcs, all) {
+		for _, v := range comp {
+			if dead[v] {
+				continue next
+			}
+		}
+		c := inside(comp, arcs, all)
+		if len(c) < 1 {
+			continue
+		}
+		best := howard(comp, c)
+		var timer, counter int
+		for _, a := range best {
+			timer += a.p.Timer
+			counter += a.p.Counter
+		}
+		if ct.Counter == 0 || timer*ct.Counter > ct.Timer*counter {
+			ct.Timer, ct.Counter = timer, counter
+			critical = best
+		}
+	}
+	ct.Critical = circuitRefs(critical)
+	for _, a := range critical {
+		ct.CriticalPlaces[a.ref()] = true
+		ct.CriticalTransitions[a.from.ref()] = true
+		ct.CriticalTransitions[a.to.ref()] = true
+	}
+	return ct
+}
+
+func circuitRefs(arcs []arc) (refs []Ref) {
+	for _, a := range arcs {
+		refs = append(refs, a.from.ref())
+	}
+	return
+}
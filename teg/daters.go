@@ -0,0 +1,221 @@
+package teg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+// DaterEvents is how many events the dater trajectories are computed for by default.
+const DaterEvents = 20
+
+const (
+	DaterInf = dioid.Inf  // the event never happens
+	DaterEps = -dioid.Inf // the event is not constrained at all
+)
+
+func daterPlus(x, timer int) int {
+	if x == DaterInf || x == DaterEps {
+		return x
+	}
+	return x + timer
+}
+
+func DaterString(x int) string {
+	switch x {
+	case DaterInf:
+		return "∞"
+	case DaterEps:
+		return "ε"
+	}
+	return fmt.Sprint(x)
+}
+
+// SerieDaters expands a serie into the dates x(k) of its first n events,
+// x(k) is the greatest δ exponent among the monomials with a γ exponent
+// not greater than k.
+func SerieDaters(s dioid.Serie, n int) []int {
+	x := make([]int, n)
+	for k := range x {
+		x[k] = DaterEps
+	}
+	apply := func(m dioid.Gd) {
+		if m == dioid.Eps || m.G >= n {
+			return
+		}
+		k := m.G
+		if k < 0 {
+			k = 0
+		}
+		for ; k < n; k++ {
+			if m.D > x[k] {
+				x[k] = m.D
+			}
+		}
+	}
+	for _, m := range s.P {
+		apply(m)
+	}
+	if s.Q.IsEps() {
+		return x
+	}
+	for _, m := range s.Q {
+		apply(m)
+		if s.R.IsE() || s.R.G < 1 {
+			continue
+		}
+		for m.G+s.R.G < n && m.D != DaterInf {
+			m.G, m.D = m.G+s.R.G, daterPlus(m.D, s.R.D)
+			apply(m)
+		}
+	}
+	return x
+}
+
+// Trajectory gives the serie ⊕ γ^k δ^x(k) of the daters, the events
+// which never happen or are unconstrained have nothing to draw.
+func Trajectory(x []int) dioid.Serie {
+	var p dioid.Poly
+	for k, d := range x {
+		if d != DaterEps && d != DaterInf {
+			p = append(p, dioid.Gd{G: k, D: d})
+		}
+	}
+	if len(p) < 1 {
+		return serieEps
+	}
+	return dioid.Serie{P: p, Q: dioid.Poly{dioid.Eps}, R: dioid.E}
+}
+
+// Daters computes the earliest firing dates x(k) of the first n events of every
+// transition by the recurrence x(k) = A0 x(k) ⊕ A1 x(k-1) ⊕ ... ⊕ B u(k), a place
+// with counter m and timer τ puts τ into the matrix Am. Like in the simulation the
+// initial tokens are available at the instant zero. The inputs of the graph fire at
+// the dates u gives for their ids, the transitions held by a circuit without tokens
+// never fire.
+func (g *Graph) Daters(u map[string][]int, n int) (refs []Ref, x map[Ref][]int) {
+	nodes, arcs := g.flatten("")
+	dates := make(map[node][]int, len(nodes))
+	into := make(map[node][]arc, len(nodes))
+	indegree := make(map[node]int, len(nodes))
+	for _, a := range arcs {
+		into[a.to] = append(into[a.to], a)
+		if a.p.Counter == 0 {
+			indegree[a.to]++
+		}
+	}
+	// order the transitions along the places without tokens,
+	// what is left behind waits for itself forever
+	order := make([]node, 0, len(nodes))
+	for _, v := range nodes {
+		dates[v] = make([]int, n)
+		if indegree[v] == 0 {
+			order = append(order, v)
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for _, a := range arcs {
+			if a.from != order[i] || a.p.Counter != 0 {
+				continue
+			}
+			if indegree[a.to]--; indegree[a.to] == 0 {
+				order = append(order, a.to)
+			}
+		}
+	}
+	for _, v := range nodes {
+		if indegree[v] > 0 {
+			for k := range dates[v] {
+				dates[v][k] = DaterInf
+			}
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, v := range order {
+			date := DaterEps
+			if v.path == "" && v.t.Kind == TransitionInput {
+				if list, ok := u[v.t.Id]; ok && k < len(list) {
+					date = list[k]
+				}
+			}
+			for _, a := range into[v] {
+				d := 0 // an initial token
+				if k >= a.p.Counter {
+					d = daterPlus(dates[a.from][k-a.p.Counter], a.p.Timer)
+				}
+				if d > date {
+					date = d
+				}
+			}
+			dates[v][k] = date
+		}
+	}
+	x = make(map[Ref][]int, len(nodes))
+	for _, v := range nodes {
+		refs = append(refs, v.ref())
+		x[v.ref()] = dates[v]
+	}
+	return
+}
+
+// Labels names the transitions after their labels put on a single line,
+// the labels of the enclosing groups come first. The inputs and outputs
+// of groups are left out, they stand for the transitions inside.
+func (g *Graph) Labels() map[Ref]string {
+	labels := make(map[Ref]string)
+	g.labels("", "", labels)
+	return labels
+}
+
+// PlaceLabels names the places like Labels names the transitions, so that
+// no two places share a name the later ones of a same label get a #n suffix.
+func (g *Graph) PlaceLabels() map[Ref]string {
+	labels := make(map[Ref]string)
+	var order []Ref
+	g.placeLabels("", "", labels, &order)
+	names := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		names[l] = true
+	}
+	taken := make(map[string]bool, len(labels))
+	for _, ref := range order {
+		name := labels[ref]
+		l := name
+		for n := 2; taken[l] || (l != name && names[l]); n++ {
+			l = fmt.Sprintf("%s#%d", name, n)
+		}
+		taken[l] = true
+		labels[ref] = l
+	}
+	return labels
+}
+
+func (g *Graph) labels(path, prefix string, labels map[Ref]string) {
+	for i, t := range g.Transitions {
+		labels[Ref{path, t.Id}] = prefix + label(t.Label, "t", i)
+	}
+	for i, gr := range g.Groups {
+		gr.Graph.labels(path+"/"+gr.Id, prefix+label(gr.Label, "g", i)+"/", labels)
+	}
+}
+
+func (g *Graph) placeLabels(path, prefix string, labels map[Ref]string, order *[]Ref) {
+	for i, p := range g.Places {
+		ref := Ref{path, p.Id}
+		labels[ref] = prefix + label(p.Label, "p", i)
+		*order = append(*order, ref)
+	}
+	for i, gr := range g.Groups {
+		gr.Graph.placeLabels(path+"/"+gr.Id, prefix+label(gr.Label, "g", i)+"/", labels, order)
+	}
+}
+
+func label(s, kind string, i int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) < 1 {
+		return fmt.Sprintf("%s%d", kind, i+1)
+	}
+	return s
+}
@@ -0,0 +1,170 @@
+package teg
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dotName names the item of the group instance at the path in DOT,
+// the names of clusters start with cluster_ as Graphviz wants.
+func dotName(path, id string) string {
+	return strings.TrimPrefix(path+"/"+id, "/")
+}
+
+// dotInches gives the size in inches, Graphviz counts 72 points an inch.
+func dotInches(size float64) string {
+	return strconv.FormatFloat(size/72, 'f', 3, 64)
+}
+
+// dotMarking puts the tokens of the place over its timer.
+func dotMarking(p *Place) string {
+	var label string
+	switch {
+	case p.Counter > 0 && p.Counter <= 3:
+		label = strings.Repeat("•", p.Counter)
+	case p.Counter > 3:
+		label = strconv.Itoa(p.Counter)
+	}
+	if p.Timer != 0 {
+		if len(label) > 0 {
+			label += "\n"
+		}
+		label += strconv.Itoa(p.Timer)
+	}
+	return label
+}
+
+// WriteDOT writes the graph for Graphviz: places are circles with their
+// tokens and timers, transitions are bars and groups are clusters. The
+// inputs and outputs of groups are left out, the arcs go straight to the
+// transitions they stand for. The names of the nodes are the ids of the
+// items prefixed by the path of their group, see ApplyDOT.
+func WriteDOT(w io.Writer, g *Graph) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "digraph %s {\n", strconv.Quote(g.Id))
+	fmt.Fprintf(buf, "  rankdir=LR;\n")
+	fmt.Fprintf(buf, "  node [fontname=\"Georgia\", fontsize=10, fixedsize=true];\n")
+	g.dot(buf, "", "  ")
+	fmt.Fprintf(buf, "}\n")
+	return buf.Flush()
+}
+
+func (g *Graph) dot(w io.Writer, path, indent string) {
+	label := func(s string) string {
+		if s = strings.Join(strings.Fields(s), " "); len(s) < 1 {
+			return ""
+		}
+		return ", xlabel=" + strconv.Quote(s)
+	}
+	arc := func(from, to string) {
+		fmt.Fprintf(w, "%s%s -> %s;\n", indent, strconv.Quote(from), strconv.Quote(to))
+	}
+	for _, p := range g.Places {
+		fmt.Fprintf(w, "%s%s [shape=circle, width=%s, label=%s%s];\n", indent,
+			strconv.Quote(dotName(path, p.Id)), dotInches(PlaceSize),
+			strconv.Quote(dotMarking(p)), label(p.Label))
+	}
+	for _, t := range g.Transitions {
+		color := "black"
+		if t.Kind == TransitionInput || t.Kind == TransitionOutput {
+			color = "\"#2980b9\""
+		}
+		width, height := t.Size()
+		fmt.Fprintf(w, "%s%s [shape=box, style=filled, color=%s, width=%s, height=%s, label=\"\"%s];\n",
+			indent, strconv.Quote(dotName(path, t.Id)), color,
+			dotInches(width), dotInches(height), label(t.Label))
+	}
+	for _, gr := range g.Groups {
+		inner := path + "/" + gr.Id
+		fmt.Fprintf(w, "%ssubgraph %s {\n", indent, strconv.Quote("cluster_"+dotName(path, gr.Id)))
+		fmt.Fprintf(w, "%s  style=rounded;\n", indent)
+		if s := strings.Join(strings.Fields(gr.Label), " "); len(s) > 0 {
+			fmt.Fprintf(w, "%s  label=%s;\n", indent, strconv.Quote(s))
+		}
+		gr.Graph.dot(w, inner, indent+"  ")
+		fmt.Fprintf(w, "%s}\n", indent)
+		for _, t := range gr.Inputs {
+			for _, p := range t.In {
+				arc(dotName(path, p.Id), dotName(inner, t.Proxy.Id))
+			}
+		}
+		for _, t := range gr.Outputs {
+			for _, p := range t.Out {
+				arc(dotName(inner, t.Proxy.Id), dotName(path, p.Id))
+			}
+		}
+	}
+	for _, t := range g.Transitions {
+		for _, p := range t.In {
+			arc(dotName(path, p.Id), dotName(path, t.Id))
+		}
+		for _, p := range t.Out {
+			arc(dotName(path, t.Id), dotName(path, p.Id))
+		}
+	}
+}
+
+// dotLayout is the part of the dot -Tjson output the positions are read from,
+// the objects are the clusters and the nodes.
+type dotLayout struct {
+	BB      string
+	Objects []struct {
+		Name, Pos, BB string
+	}
+}
+
+// ApplyDOT moves the items to the positions Graphviz gave to the nodes
+// of WriteDOT, data is the output of dot -Tjson. The groups get the
+// corners of their clusters. The items Graphviz does not know stay.
+func (g *Graph) ApplyDOT(data []byte) error {
+	var layout dotLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return fmt.Errorf("reading the layout: %v", err)
+	}
+	floats := func(s string, n int) ([]float64, error) {
+		list := strings.Split(s, ",")
+		if len(list) != n {
+			return nil, fmt.Errorf("reading the layout: bad coordinates %q", s)
+		}
+		f := make([]float64, n)
+		for i := range list {
+			var err error
+			if f[i], err = strconv.ParseFloat(strings.TrimSpace(list[i]), 64); err != nil {
+				return nil, fmt.Errorf("reading the layout: bad coordinates %q", s)
+			}
+		}
+		return f, nil
+	}
+	bb, err := floats(layout.BB, 4)
+	if err != nil {
+		return err
+	}
+	// Graphviz has the y axis up
+	top := bb[3]
+	boxes := make(map[string]box, len(layout.Objects))
+	for _, obj := range layout.Objects {
+		switch {
+		case len(obj.Pos) > 0:
+			pos, err := floats(obj.Pos, 2)
+			if err != nil {
+				return err
+			}
+			boxes[obj.Name] = box{x: pos[0], y: top - pos[1]}
+		case len(obj.BB) > 0:
+			bb, err := floats(obj.BB, 4)
+			if err != nil {
+				return err
+			}
+			boxes[obj.Name] = box{
+				x: (bb[0] + bb[2]) / 2, y: top - (bb[1]+bb[3])/2,
+				w: bb[2] - bb[0], h: bb[3] - bb[1],
+			}
+		}
+	}
+	g.arrange("", boxes)
+	return nil
+}
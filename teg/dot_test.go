@@ -0,0 +1,39 @@
+package teg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+func (t *testSuite) TestWriteDOT() {
+	g, u, t1, t2, _ := chain()
+	gr, err := g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Nil(err)
+	var buf bytes.Buffer
+	t.Nil(WriteDOT(&buf, g))
+	out := buf.String()
+	t.True(strings.Contains(out, fmt.Sprintf("subgraph %q {", "cluster_"+gr.Id)))
+	t.True(strings.Contains(out, fmt.Sprintf("%q [shape=box", gr.Id+"/"+t1.Id)))
+	t.True(strings.Contains(out, fmt.Sprintf("%q -> %q;", u.Out[0].Id, gr.Id+"/"+t1.Id)))
+	t.True(strings.Contains(out, `label="•\n3"`))
+	t.True(strings.Contains(out, `xlabel="u"`))
+}
+
+func (t *testSuite) TestApplyDOT() {
+	g, u, t1, _, _ := chain()
+	p := u.Out[0]
+	p.InControl = &ControlPoint{X: 1, Y: 1}
+	t.Nil(g.ApplyDOT([]byte(fmt.Sprintf(`{"bb": "0,0,400,100", "objects": [
+		{"name": %q, "pos": "100,80"},
+		{"name": %q, "pos": "200,50"}
+	]}`, p.Id, t1.Id))))
+	t.Equal(100.0, p.X)
+	t.Equal(20.0, p.Y)
+	t.True(p.InControl == nil)
+	t.Equal(197.0, t1.X)
+	t.Equal(35.0, t1.Y)
+	t.Equal(0.0, u.X)
+
+	t.Not(g.ApplyDOT([]byte(`{"bb": "0,0", "objects": []}`)) == nil)
+}
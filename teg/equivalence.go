@@ -0,0 +1,169 @@
+package teg
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+// Relation is the order of two transfers, entry by entry.
+type Relation int
+
+const (
+	TransferEqual Relation = iota
+	TransferLess
+	TransferGreater
+	TransferDifferent
+)
+
+func (r Relation) String() string {
+	return [...]string{"=", "≤", "≥", "≠"}[r]
+}
+
+func (r Relation) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// witnessEvents bounds the events looked through for a witness.
+const witnessEvents = 1024
+
+// Witness finds a monomial of s1 which is not below s2, the one at the first
+// event the daters of s1 are later than those of s2. It is false when s1 ≤ s2.
+// The witness is ε when the daters only part after witnessEvents events.
+func Witness(s1, s2 dioid.Serie) (dioid.Gd, bool) {
+	if dioid.SerieEqual(dioid.SerieOplus(s1, s2), s2) {
+		return dioid.Eps, false
+	}
+	for n := 64; n <= witnessEvents; n *= 2 {
+		x1, x2 := SerieDaters(s1, n), SerieDaters(s2, n)
+		for k := range x1 {
+			if x1[k] > x2[k] {
+				return dioid.Gd{G: k, D: x1[k]}, true
+			}
+		}
+	}
+	return dioid.Eps, true
+}
+
+// Comparison tells how the transfer H1 of a graph compares to the transfer H2
+// of another one. When they are not equal, the witness is a monomial below
+// the entry of Output and Input in one transfer but not in the other, H1 when
+// InFirst. First and Second are the entries.
+type Comparison struct {
+	Relation      Relation
+	Output, Input string    `json:",omitempty"`
+	Witness       *dioid.Gd `json:",omitempty"`
+	InFirst       bool      `json:",omitempty"`
+	First, Second string    `json:",omitempty"`
+}
+
+// matching orders the labels of the second graph like those of the first one,
+// perm[i] is the index in l2 of l1[i]. The order is kept unless both lists
+// are made of the same distinct labels.
+func matching(l1, l2 []string) (perm []int) {
+	perm = make([]int, len(l1))
+	for i := range perm {
+		perm[i] = i
+	}
+	distinct := func(list []string) map[string]int {
+		index := make(map[string]int, len(list))
+		for j, l := range list {
+			index[l] = j
+		}
+		if len(index) != len(list) {
+			return nil
+		}
+		return index
+	}
+	index1, index2 := distinct(l1), distinct(l2)
+	if index1 == nil || index2 == nil || len(index1) != len(index2) {
+		return
+	}
+	byLabel := make([]int, len(l1))
+	for i, l := range l1 {
+		j, ok := index2[l]
+		if !ok {
+			return
+		}
+		byLabel[i] = j
+	}
+	return byLabel
+}
+
+// Compare decides how the transfers of the graphs are ordered. The inputs and
+// the outputs are matched by their labels when both graphs use the same ones,
+// by their order otherwise, their numbers must be the same.
+func (g *Graph) Compare(g2 *Graph) (*Comparison, error) {
+	h1, in1, out1 := g.Transfer()
+	h2, in2, out2 := g2.Transfer()
+	if len(in1) != len(in2) || len(out1) != len(out2) {
+		return nil, fmt.Errorf("the inputs and outputs do not match, %d×%d against %d×%d",
+			len(in1), len(out1), len(in2), len(out2))
+	}
+	names := func(g *Graph, list []*Transition) []string {
+		labels := g.Labels()
+		names := make([]string, len(list))
+		for i, t := range list {
+			names[i] = labels[Ref{"", t.Id}]
+		}
+		return names
+	}
+	ins, outs := names(g, in1), names(g, out1)
+	pin, pout := matching(ins, names(g2, in2)), matching(outs, names(g2, out2))
+
+	less, greater := true, true
+	c := &Comparison{}
+	for i := range out1 {
+		for j := range in1 {
+			a, b := h1[i][j], h2[pout[i]][pin[j]]
+			m1, ok1 := Witness(a, b)
+			m2, ok2 := Witness(b, a)
+			if c.Witness == nil && (ok1 || ok2) {
+				c.Output, c.Input = outs[i], ins[j]
+				c.First, c.Second = a.String(), b.String()
+				c.InFirst = ok1
+				if ok1 {
+					c.Witness = &m1
+				} else {
+					c.Witness = &m2
+				}
+			}
+			less = less && !ok1
+			greater = greater && !ok2
+		}
+	}
+	switch {
+	case less && greater:
+		c.Relation = TransferEqual
+	case less:
+		c.Relation = TransferLess
+	case greater:
+		c.Relation = TransferGreater
+	default:
+		c.Relation = TransferDifferent
+	}
+	return c, nil
+}
+
+func (c *Comparison) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "H1 %s H2\n", c.Relation)
+	if c.Witness == nil {
+		return buf.String()
+	}
+	in, out := "H2", "H1"
+	if c.InFirst {
+		in, out = out, in
+	}
+	if c.Witness.IsEps() {
+		fmt.Fprintf(&buf, "%s[%s, %s] is not below %s[%s, %s], no witness among the first %d events\n",
+			in, c.Output, c.Input, out, c.Output, c.Input, witnessEvents)
+	} else {
+		fmt.Fprintf(&buf, "%s is below %s[%s, %s] but not below %s[%s, %s]\n",
+			c.Witness, in, c.Output, c.Input, out, c.Output, c.Input)
+	}
+	fmt.Fprintf(&buf, "H1[%s, %s] = %s\n", c.Output, c.Input, c.First)
+	fmt.Fprintf(&buf, "H2[%s, %s] = %s\n", c.Output, c.Input, c.Second)
+	return buf.String()
+}
@@ -0,0 +1,65 @@
+package teg
+
+import "fmt"
+
+func (t *testSuite) TestCompare() {
+	g1, _, _, _, _ := sample()
+	g2, _, _, t2, _ := sample()
+	c, err := g1.Compare(g2)
+	t.Nil(err)
+	t.Equal(TransferEqual, c.Relation)
+	t.True(c.Witness == nil)
+
+	// the token takes a unit longer to come back to t1
+	t2.Out[0].Timer = 3
+	c, err = g1.Compare(g2)
+	t.Nil(err)
+	t.Equal(TransferLess, c.Relation)
+	t.Equal("y", c.Output)
+	t.Equal("u", c.Input)
+	t.False(c.InFirst)
+	t.Equal("g^2d^9", c.Witness.String())
+	c, err = g2.Compare(g1)
+	t.Nil(err)
+	t.Equal(TransferGreater, c.Relation)
+	t.True(c.InFirst)
+
+	_, err = g1.Compare(New())
+	t.Not(err == nil)
+}
+
+func (t *testSuite) TestCompareByLabels() {
+	g1, u, _, _, _ := sample()
+	v := g1.AddTransition(0, 0)
+	v.Kind, v.Label = TransitionInput, "v"
+	g2, _, _, _, _ := sample()
+	w := g2.AddTransition(0, 0)
+	w.Kind, w.Label = TransitionInput, "v"
+	// the inputs of g2 come in the other order
+	g2.Transitions[0], g2.Transitions[len(g2.Transitions)-1] = w, g2.Transitions[0]
+	c, err := g1.Compare(g2)
+	t.Nil(err)
+	t.Equal(TransferEqual, c.Relation)
+	u.Label = "u1"
+	c, err = g1.Compare(g2)
+	t.Nil(err)
+	t.Equal(TransferDifferent, c.Relation)
+}
+
+func (t *testSuite) TestCompareDuplicateLabels() {
+	t.Equal("[0 1]", fmt.Sprint(matching([]string{"a", "a"}, []string{"a", "b"})))
+	t.Equal("[0 1]", fmt.Sprint(matching([]string{"a", "b"}, []string{"b", "b"})))
+	t.Equal("[1 0]", fmt.Sprint(matching([]string{"a", "b"}, []string{"b", "a"})))
+
+	g1, _, _, _, _ := sample()
+	v := g1.AddTransition(0, 0)
+	v.Kind, v.Label = TransitionInput, "u"
+	g2, _, _, _, _ := sample()
+	w := g2.AddTransition(0, 0)
+	w.Kind, w.Label = TransitionInput, "v"
+	g2.Transitions[0], g2.Transitions[len(g2.Transitions)-1] = w, g2.Transitions[0]
+	// the inputs are matched by their order, the unlinked ones differ
+	c, err := g1.Compare(g2)
+	t.Nil(err)
+	t.Equal(TransferDifferent, c.Relation)
+}
@@ -0,0 +1,320 @@
+package teg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// FormatVersion is the version of the documents written by Encode.
+//
+// Version 1 files are the bare JSON of a Model,
+// since version 2 the model comes in a Document.
+const FormatVersion = 2
+
+// Document is the envelope of a saved model.
+type Document struct {
+	Version int
+	Model   *Model
+}
+
+// migrations upgrade a document of version v to v+1, they work on the
+// generic JSON so the structs only have to know the latest version.
+var migrations = map[int]func(doc map[string]interface{}) (map[string]interface{}, error){
+	1: func(doc map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"Version": 2, "Model": doc}, nil
+	},
+}
+
+// version guesses the format version of a decoded document.
+func version(doc map[string]interface{}) (int, error) {
+	v, ok := doc["Version"]
+	if !ok {
+		if _, ok := doc["Transitions"]; ok {
+			return 1, nil
+		}
+		return 0, errors.New("not a teg document")
+	}
+	n, ok := v.(float64)
+	if !ok || n != float64(int(n)) || n < 1 {
+		return 0, fmt.Errorf("invalid format version %v", v)
+	}
+	return int(n), nil
+}
+
+// Decode reads a document of any known version and brings it up to date,
+// the model is validated. The errors of validation are *ValidationError.
+func Decode(data []byte) (model *Model, err error) {
+	var doc map[string]interface{}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return
+	}
+	v, err := version(doc)
+	if err != nil {
+		return
+	}
+	if v > FormatVersion {
+		return nil, fmt.Errorf("format version %d is newer than supported %d", v, FormatVersion)
+	}
+	for ; v < FormatVersion; v++ {
+		migrate, ok := migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration from format version %d", v)
+		}
+		if doc, err = migrate(doc); err != nil {
+			return nil, fmt.Errorf("migration from format version %d: %v", v, err)
+		}
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return
+	}
+	document := &Document{}
+	if err = json.Unmarshal(data, document); err != nil {
+		return
+	}
+	if document.Model == nil {
+		return nil, errors.New("document has no model")
+	}
+	if err = document.Model.Validate(); err != nil {
+		return nil, err
+	}
+	return document.Model, nil
+}
+
+// Encode writes the model as a document of the current version.
+func Encode(w io.Writer, m *Model) error {
+	return json.NewEncoder(w).Encode(&Document{Version: FormatVersion, Model: m})
+}
+
+// ValidationError lists every problem found in a model.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid model: " + e.Problems[0]
+	}
+	return fmt.Sprintf("invalid model, %d problems: %s",
+		len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+type validator struct {
+	problems []string
+	// shared holds the first group having a model of each id,
+	// the groups with models of the same id share the graph
+	shared map[string]*GroupModel
+}
+
+func (v *validator) errorf(prefix, format string, args ...interface{}) {
+	v.problems = append(v.problems, prefix+fmt.Sprintf(format, args...))
+}
+
+// Validate checks that the model can be constructed: every id should lead
+// somewhere, places should not be linked twice, the iostate of the groups
+// should match their inputs and outputs and the groups with models of the
+// same id should have the same model.
+func (m *Model) Validate() error {
+	v := &validator{shared: make(map[string]*GroupModel)}
+	v.model(m, "")
+	if len(v.problems) > 0 {
+		return &ValidationError{v.problems}
+	}
+	return nil
+}
+
+func (v *validator) model(m *Model, prefix string) {
+	ids := make(map[string]bool)
+	unique := func(id, what string) {
+		if len(id) < 1 {
+			v.errorf(prefix, "%s without id", what)
+		} else if ids[id] {
+			v.errorf(prefix, "%s %s: duplicate id", what, id)
+		}
+		ids[id] = true
+	}
+
+	// a place is known by the transitions on both of its sides
+	linkedIn := make(map[string]int)
+	linkedOut := make(map[string]int)
+	// the places of the inputs and outputs of groups are saved
+	// along with the free ones, the places of transitions are not
+	byTransitions := make(map[string]bool)
+	link := func(places []*PlaceModel, counts map[string]int) {
+		for _, p := range places {
+			if p == nil {
+				v.errorf(prefix, "null place")
+				continue
+			}
+			counts[p.Id]++
+			if counts[p.Id] == 2 {
+				v.errorf(prefix, "place %s is linked twice", p.Id)
+			}
+		}
+	}
+	kinds := make(map[string]int)
+	for _, t := range m.Transitions {
+		if t == nil {
+			v.errorf(prefix, "null transition")
+			continue
+		}
+		unique(t.Id, "transition")
+		kinds[t.Id] = t.Kind
+		if t.Kind < TransitionInternal || t.Kind > TransitionExposed {
+			v.errorf(prefix, "transition %s: unknown kind %d", t.Id, t.Kind)
+		}
+		link(t.In, linkedOut)
+		link(t.Out, linkedIn)
+		for _, p := range t.In {
+			if p != nil {
+				byTransitions[p.Id] = true
+			}
+		}
+		for _, p := range t.Out {
+			if p != nil {
+				byTransitions[p.Id] = true
+			}
+		}
+	}
+	for _, g := range m.Groups {
+		if g == nil {
+			v.errorf(prefix, "null group")
+			continue
+		}
+		unique(g.Id, "group")
+		for _, t := range g.Inputs {
+			if t != nil {
+				unique(t.Id, "transition")
+				link(t.In, linkedOut)
+			}
+		}
+		for _, t := range g.Outputs {
+			if t != nil {
+				unique(t.Id, "transition")
+				link(t.Out, linkedIn)
+			}
+		}
+		v.group(g, prefix)
+	}
+	for _, p := range m.Places {
+		if p == nil {
+			v.errorf(prefix, "null place")
+			continue
+		}
+		if byTransitions[p.Id] {
+			v.errorf(prefix, "place %s is both free and linked", p.Id)
+			continue
+		}
+		if linkedIn[p.Id] == 0 && linkedOut[p.Id] == 0 {
+			unique(p.Id, "place")
+		}
+	}
+	for id := range linkedIn {
+		unique(id, "place")
+	}
+	for id := range linkedOut {
+		if linkedIn[id] == 0 {
+			unique(id, "place")
+		}
+	}
+	for _, info := range m.Infos {
+		if info == nil {
+			v.errorf(prefix, "null plane")
+			continue
+		}
+		if kind, ok := kinds[info.IoId]; !ok {
+			v.errorf(prefix, "plane of %s: no such transition", info.IoId)
+		} else if kind == TransitionInternal {
+			v.errorf(prefix, "plane of %s: transition is not an input or output", info.IoId)
+		}
+	}
+}
+
+func (g *GroupModel) name() string {
+	if len(g.Label) < 1 {
+		return g.Id
+	}
+	return g.Label
+}
+
+func (v *validator) group(g *GroupModel, prefix string) {
+	prefix = fmt.Sprintf("%sgroup %s: ", prefix, g.name())
+	if g.Model == nil {
+		v.errorf(prefix, "no model")
+		return
+	}
+	if first, ok := v.shared[g.Model.Id]; !ok {
+		v.shared[g.Model.Id] = g
+		v.model(g.Model, prefix)
+	} else if !reflect.DeepEqual(first.Model, g.Model) {
+		// only the first model would be built
+		v.errorf(prefix, "model %s differs from the one of group %s", g.Model.Id, first.name())
+	}
+
+	inner := make(map[string]bool)
+	transitions := make(map[string]bool)
+	for _, t := range g.Model.Transitions {
+		if t == nil {
+			continue
+		}
+		transitions[t.Id] = true
+		for _, p := range t.In {
+			if p != nil {
+				inner[p.Id] = true
+			}
+		}
+		for _, p := range t.Out {
+			if p != nil {
+				inner[p.Id] = true
+			}
+		}
+	}
+
+	proxied := make(map[string]string)
+	io := make(map[string]bool, len(g.Inputs)+len(g.Outputs))
+	// the inputs lead to places of the model by their outgoing arcs,
+	// the outputs by their incoming ones
+	check := func(t *TransitionModel, input bool) {
+		if t == nil {
+			v.errorf(prefix, "null transition")
+			return
+		}
+		io[t.Id] = true
+		proxy, ok := g.Iostate[t.Id]
+		switch {
+		case !ok:
+			v.errorf(prefix, "transition %s has no iostate", t.Id)
+		case !transitions[proxy]:
+			v.errorf(prefix, "transition %s: proxy %s is not in the model", t.Id, proxy)
+		case len(t.ProxyId) > 0 && t.ProxyId != proxy:
+			v.errorf(prefix, "transition %s: proxy %s, iostate says %s", t.Id, t.ProxyId, proxy)
+		}
+		if other, ok := proxied[proxy]; ok && len(proxy) > 0 {
+			v.errorf(prefix, "transitions %s and %s share proxy %s", other, t.Id, proxy)
+		}
+		proxied[proxy] = t.Id
+		places := t.In
+		if input {
+			places = t.Out
+		}
+		for _, p := range places {
+			if p != nil && !inner[p.Id] {
+				v.errorf(prefix, "transition %s: place %s is not in the model", t.Id, p.Id)
+			}
+		}
+	}
+	for _, t := range g.Inputs {
+		check(t, true)
+	}
+	for _, t := range g.Outputs {
+		check(t, false)
+	}
+	for id := range g.Iostate {
+		if !io[id] {
+			v.errorf(prefix, "iostate of %s: no such input or output", id)
+		}
+	}
+}
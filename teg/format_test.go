@@ -0,0 +1,59 @@
+package teg
+
+func (t *testSuite) TestDecodeVersion1() {
+	m, err := Decode([]byte(`{"Id":"a","Places":[{"Id":"p","Counter":2}],"Transitions":[],"Groups":[]}`))
+	t.Nil(err)
+	t.Equal("a", m.Id)
+	t.Equal(2, m.Places[0].Counter)
+}
+
+func (t *testSuite) TestDecodeNewer() {
+	_, err := Decode([]byte(`{"Version":99,"Model":{}}`))
+	t.Not(err == nil)
+	_, err = Decode([]byte(`{"Foo":1}`))
+	t.Not(err == nil, "not a teg document")
+}
+
+func (t *testSuite) TestValidate() {
+	problems := func(doc string) []string {
+		_, err := Decode([]byte(doc))
+		verr, ok := err.(*ValidationError)
+		t.True(ok, doc)
+		if !ok {
+			return nil
+		}
+		return verr.Problems
+	}
+	t.Equal([]string{
+		"transition t: duplicate id",
+		"place p is linked twice",
+		"plane of x: no such transition",
+	}, problems(`{"Version":2,"Model":{"Id":"a","Transitions":[
+		{"Id":"t","Out":[{"Id":"p"}]},
+		{"Id":"t","Out":[{"Id":"p"}]}],
+		"Infos":[{"IoId":"x"}]}}`))
+	// the problems of groups tell the way to them
+	t.Equal([]string{
+		"group G: group H: no model",
+		"group G: transition i: proxy x is not in the model",
+		"group G: iostate of j: no such input or output",
+	}, problems(`{"Version":2,"Model":{"Id":"a","Groups":[{"Id":"g","Label":"G",
+		"Model":{"Id":"m","Transitions":[{"Id":"v","Kind":1}],
+			"Groups":[{"Id":"h","Label":"H"}]},
+		"Inputs":[{"Id":"i"}],"Iostate":{"i":"x","j":"v"}}]}}`))
+	// groups with models of the same id share the graph,
+	// so the models should be the same
+	t.Equal([]string{
+		"group h: model m differs from the one of group g",
+	}, problems(`{"Version":2,"Model":{"Id":"a","Groups":[
+		{"Id":"g","Model":{"Id":"m","Transitions":[{"Id":"v","Kind":1}]},
+			"Inputs":[{"Id":"i"}],"Iostate":{"i":"v"}},
+		{"Id":"h","Model":{"Id":"m","Transitions":[{"Id":"w","Kind":1}]},
+			"Inputs":[{"Id":"j"}],"Iostate":{"j":"w"}}]}}`))
+	_, err := Decode([]byte(`{"Version":2,"Model":{"Id":"a","Groups":[
+		{"Id":"g","Model":{"Id":"m","Transitions":[{"Id":"v","Kind":1}]},
+			"Inputs":[{"Id":"i"}],"Iostate":{"i":"v"}},
+		{"Id":"h","Model":{"Id":"m","Transitions":[{"Id":"v","Kind":1}]},
+			"Inputs":[{"Id":"j"}],"Iostate":{"j":"v"}}]}}`))
+	t.Nil(err)
+}
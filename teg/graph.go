@@ -0,0 +1,814 @@
+// Package teg is the core of the workshop: timed event graphs, how they are
+// saved and what can be computed on them. It does not depend on Qt, the
+// editor in tegview wraps it and other programs may use it directly.
+package teg
+
+import (
+	"fmt"
+
+	"github.com/xlab/teg-workshop/dioid"
+	"github.com/xlab/teg-workshop/util"
+)
+
+// Graph is a timed event graph. Places link at most one upstream transition
+// to at most one downstream transition, groups hold graphs of their own.
+// The places linked to the inputs and outputs of groups are in Places too.
+type Graph struct {
+	Id          string
+	Places      []*Place
+	Transitions []*Transition
+	Groups      []*Group
+	Infos       []*Info
+	Parent      *Graph
+}
+
+// Place holds Counter initial tokens, a token stays there Timer units of time
+// before it is available to the downstream transition. X and Y are the centre.
+type Place struct {
+	Id         string
+	X, Y       float64
+	Counter    int
+	Timer      int
+	Label      string
+	InControl  *ControlPoint
+	OutControl *ControlPoint
+	In, Out    *Transition
+}
+
+// Transition is positioned by its corner. The inputs and outputs of a group
+// stand for the transitions of the group's graph, Proxy is the one behind.
+type Transition struct {
+	Id         string
+	X, Y       float64
+	Label      string
+	Horizontal bool
+	Kind       int
+	In, Out    []*Place
+	Proxy      *Transition
+	Group      *Group
+}
+
+// Group folds a graph into a single item. Groups built from the same model
+// share the graph, the inputs and outputs are their own.
+type Group struct {
+	Id      string
+	X, Y    float64
+	Label   string
+	Folded  bool
+	Graph   *Graph
+	Inputs  []*Transition
+	Outputs []*Transition
+}
+
+// New returns an empty graph.
+func New() *Graph {
+	return &Graph{Id: util.GenUUID()}
+}
+
+// Serie returns the γ^counter δ^timer monomial of the place as a serie.
+func (p *Place) Serie() dioid.Serie {
+	return dioid.Serie{
+		P: dioid.Poly{dioid.Eps},
+		Q: dioid.Poly{{G: p.Counter, D: p.Timer}},
+		R: dioid.E,
+	}
+}
+
+// Find returns the place, transition or group of the graph with the id,
+// the inputs and outputs of groups included.
+func (g *Graph) Find(id string) interface{} {
+	for _, p := range g.Places {
+		if p.Id == id {
+			return p
+		}
+	}
+	for _, t := range g.Transitions {
+		if t.Id == id {
+			return t
+		}
+	}
+	for _, gr := range g.Groups {
+		if gr.Id == id {
+			return gr
+		}
+		for _, t := range gr.Inputs {
+			if t.Id == id {
+				return t
+			}
+		}
+		for _, t := range gr.Outputs {
+			if t.Id == id {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// Info returns the plane of the input or output transition, nil if none.
+func (g *Graph) Info(id string) *Info {
+	for _, info := range g.Infos {
+		if info.IoId == id {
+			return info
+		}
+	}
+	return nil
+}
+
+func (g *Graph) AddPlace(x, y float64) *Place {
+	p := &Place{Id: util.GenUUID(), X: x, Y: y}
+	g.Places = append(g.Places, p)
+	return p
+}
+
+func (g *Graph) AddTransition(x, y float64) *Transition {
+	t := &Transition{Id: util.GenUUID(), X: x, Y: y}
+	g.Transitions = append(g.Transitions, t)
+	return t
+}
+
+// Link makes p an input place of t if inbound, an output place otherwise.
+// The inputs of a graph have no input places, the outputs no output ones.
+func (t *Transition) Link(p *Place, inbound bool) error {
+	switch {
+	case inbound && t.Kind == TransitionInput:
+		return fmt.Errorf("transition %s is an input", t.Id)
+	case !inbound && t.Kind == TransitionOutput:
+		return fmt.Errorf("transition %s is an output", t.Id)
+	case inbound && p.Out != nil:
+		return fmt.Errorf("place %s already has a downstream transition", p.Id)
+	case !inbound && p.In != nil:
+		return fmt.Errorf("place %s already has an upstream transition", p.Id)
+	}
+	if inbound {
+		p.Out = t
+		t.In = append(t.In, p)
+	} else {
+		p.In = t
+		t.Out = append(t.Out, p)
+	}
+	if t.Proxy != nil {
+		t.Group.UpdateIO()
+	}
+	return nil
+}
+
+// Unlink removes the arc between t and p, if there is one.
+func (t *Transition) Unlink(p *Place, inbound bool) {
+	if t.unlink(p, inbound) && t.Proxy != nil {
+		t.Group.UpdateIO()
+	}
+}
+
+func (t *Transition) unlink(p *Place, inbound bool) bool {
+	if inbound && p.Out == t {
+		p.Out, p.OutControl = nil, nil
+		t.In = removePlace(t.In, p)
+		return true
+	} else if !inbound && p.In == t {
+		p.In, p.InControl = nil, nil
+		t.Out = removePlace(t.Out, p)
+		return true
+	}
+	return false
+}
+
+func removePlace(list []*Place, p *Place) []*Place {
+	for i, p2 := range list {
+		if p2 == p {
+			return append(list[:i:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+func (g *Graph) RemovePlace(p *Place) {
+	if p.In != nil {
+		p.In.Unlink(p, false)
+	}
+	if p.Out != nil {
+		p.Out.Unlink(p, true)
+	}
+	g.Places = removePlace(g.Places, p)
+}
+
+// RemoveTransition removes t along with its arcs, the inputs and outputs
+// of groups go away with their group.
+func (g *Graph) RemoveTransition(t *Transition) {
+	if t.Proxy != nil {
+		return
+	}
+	for _, p := range append([]*Place{}, t.In...) {
+		t.unlink(p, true)
+	}
+	for _, p := range append([]*Place{}, t.Out...) {
+		t.unlink(p, false)
+	}
+	for i, t2 := range g.Transitions {
+		if t2 == t {
+			g.Transitions = append(g.Transitions[:i:i], g.Transitions[i+1:]...)
+			break
+		}
+	}
+	if g.Parent == nil {
+		return
+	}
+	for _, gr := range g.Parent.Groups {
+		if gr.Graph == g {
+			gr.UpdateIO()
+		}
+	}
+}
+
+// RemoveGroup removes the group, the places linked to it stay unlinked.
+func (g *Graph) RemoveGroup(gr *Group) {
+	for _, t := range gr.Inputs {
+		t.release()
+	}
+	for _, t := range gr.Outputs {
+		t.release()
+	}
+	gr.Inputs, gr.Outputs = nil, nil
+	for i, gr2 := range g.Groups {
+		if gr2 == gr {
+			g.Groups = append(g.Groups[:i:i], g.Groups[i+1:]...)
+			return
+		}
+	}
+}
+
+// kindInGroup tells what t would be in a group holding the places,
+// the transitions with arcs on both sides of the border are exposed.
+func (t *Transition) kindInGroup(places map[*Place]bool) int {
+	if t.Proxy != nil {
+		return TransitionInternal
+	}
+	var interlinkIn, interlinkOut, outlinkIn, outlinkOut bool
+	for _, p := range t.In {
+		interlinkIn = interlinkIn || places[p]
+		outlinkIn = outlinkIn || !places[p]
+	}
+	for _, p := range t.Out {
+		interlinkOut = interlinkOut || places[p]
+		outlinkOut = outlinkOut || !places[p]
+	}
+	switch {
+	case !interlinkIn && (outlinkIn || interlinkOut) && !outlinkOut:
+		return TransitionInput
+	case !interlinkOut && (outlinkOut || interlinkIn) && !outlinkIn:
+		return TransitionOutput
+	case !(outlinkIn || outlinkOut):
+		return TransitionInternal
+	}
+	return TransitionExposed
+}
+
+// AddGroup moves the items into the graph of a new group. A place should
+// have its transitions among the items, a transition should not have arcs
+// crossing the border on both of its sides.
+func (g *Graph) AddGroup(places []*Place, transitions []*Transition, groups []*Group) (*Group, error) {
+	inner := make(map[*Place]bool, len(places))
+	moved := make(map[*Transition]bool, len(transitions))
+	for _, p := range places {
+		inner[p] = true
+	}
+	for _, t := range transitions {
+		if t.Proxy != nil {
+			return nil, fmt.Errorf("transition %s belongs to a group", t.Id)
+		}
+		moved[t] = true
+	}
+	for _, gr := range groups {
+		for _, t := range gr.Inputs {
+			moved[t] = true
+		}
+		for _, t := range gr.Outputs {
+			moved[t] = true
+		}
+	}
+	for _, p := range places {
+		if (p.In != nil && !moved[p.In]) || (p.Out != nil && !moved[p.Out]) {
+			return nil, fmt.Errorf("place %s is linked outside of the group", p.Id)
+		}
+	}
+	for _, t := range transitions {
+		if t.kindInGroup(inner) == TransitionExposed {
+			return nil, fmt.Errorf("transition %s is linked both inside and outside of the group", t.Id)
+		}
+	}
+	for _, gr := range groups {
+		for _, t := range gr.Inputs {
+			for _, p := range t.In {
+				if !inner[p] {
+					return nil, fmt.Errorf("group %s is linked outside of the group", gr.Id)
+				}
+			}
+		}
+		for _, t := range gr.Outputs {
+			for _, p := range t.Out {
+				if !inner[p] {
+					return nil, fmt.Errorf("group %s is linked outside of the group", gr.Id)
+				}
+			}
+		}
+	}
+
+	sub := New()
+	sub.Parent = g
+	gr := &Group{Id: util.GenUUID(), Graph: sub}
+	for i, p := range places {
+		if i == 0 || p.X < gr.X {
+			gr.X = p.X
+		}
+		if i == 0 || p.Y < gr.Y {
+			gr.Y = p.Y
+		}
+		g.Places = removePlace(g.Places, p)
+	}
+	for i, t := range transitions {
+		if (i == 0 && len(places) == 0) || t.X < gr.X {
+			gr.X = t.X
+		}
+		if (i == 0 && len(places) == 0) || t.Y < gr.Y {
+			gr.Y = t.Y
+		}
+	}
+	sub.Places = append(sub.Places, places...)
+	for _, t := range transitions {
+		for i, t2 := range g.Transitions {
+			if t2 == t {
+				g.Transitions = append(g.Transitions[:i:i], g.Transitions[i+1:]...)
+				break
+			}
+		}
+	}
+	sub.Transitions = append(sub.Transitions, transitions...)
+	for _, gr2 := range groups {
+		for i, gr3 := range g.Groups {
+			if gr3 == gr2 {
+				g.Groups = append(g.Groups[:i:i], g.Groups[i+1:]...)
+				break
+			}
+		}
+		gr2.Graph.Parent = sub
+	}
+	sub.Groups = append(sub.Groups, groups...)
+	// the planes of moved inputs and outputs stay with their transitions
+	var infos []*Info
+	for _, info := range g.Infos {
+		if t, ok := sub.Find(info.IoId).(*Transition); ok && t.Proxy == nil {
+			sub.Infos = append(sub.Infos, info)
+		} else {
+			infos = append(infos, info)
+		}
+	}
+	g.Infos = infos
+	g.Groups = append(g.Groups, gr)
+	gr.UpdateIO()
+	return gr, nil
+}
+
+// UpdateIO makes an input of the group for every transition of its graph
+// that is fed from outside, an output for every one that feeds outside.
+// It should be called after the graph of the group has been edited.
+// The arcs crossing the border are moved to the inputs and outputs,
+// the inputs and outputs kept from before keep their ids.
+func (gr *Group) UpdateIO() {
+	old := make(map[*Transition]*Transition, len(gr.Inputs)+len(gr.Outputs))
+	for _, t := range gr.Inputs {
+		old[t.Proxy] = t
+	}
+	for _, t := range gr.Outputs {
+		old[t.Proxy] = t
+	}
+	gr.Inputs, gr.Outputs = nil, nil
+	places := make(map[*Place]bool, len(gr.Graph.Places))
+	for _, p := range gr.Graph.Places {
+		places[p] = true
+	}
+	for _, t := range gr.Graph.Transitions {
+		kind := t.Kind
+		if kind == TransitionInternal {
+			if kind = t.kindInGroup(places); kind == TransitionInternal {
+				continue
+			}
+		}
+		if kind != TransitionInput && kind != TransitionOutput {
+			continue
+		}
+		// an input of the group is an output for the parent graph
+		c, ok := old[t]
+		if ok && (kind == TransitionInput) != (c.Kind == TransitionOutput) {
+			c.release()
+			ok = false
+		}
+		if !ok {
+			c = &Transition{
+				Id:         util.GenUUID(),
+				X:          t.X,
+				Y:          t.Y,
+				Label:      t.Label,
+				Horizontal: t.Horizontal,
+				Proxy:      t,
+				Group:      gr,
+			}
+		}
+		switch kind {
+		case TransitionInput:
+			if !ok {
+				for _, p := range t.In {
+					p.Out, p.OutControl = c, nil
+				}
+				c.In, t.In = t.In, nil
+			}
+			c.Out = append([]*Place{}, t.Out...)
+			t.Kind = TransitionInput
+			c.Kind = TransitionOutput // for the parent graph
+			gr.Inputs = append(gr.Inputs, c)
+		case TransitionOutput:
+			if !ok {
+				for _, p := range t.Out {
+					p.In, p.InControl = c, nil
+				}
+				c.Out, t.Out = t.Out, nil
+			}
+			c.In = append([]*Place{}, t.In...)
+			t.Kind = TransitionOutput
+			c.Kind = TransitionInput // for the parent graph
+			gr.Outputs = append(gr.Outputs, c)
+		}
+		delete(old, t)
+	}
+	// the inputs and outputs that are gone leave their places unlinked
+	for _, c := range old {
+		c.release()
+	}
+}
+
+// release unlinks the places of the parent graph from an input or output
+// of a group, the places of the group's graph are linked to the proxy.
+func (c *Transition) release() {
+	for _, p := range c.In {
+		if p.Out == c {
+			p.Out, p.OutControl = nil, nil
+		}
+	}
+	for _, p := range c.Out {
+		if p.In == c {
+			p.In, p.InControl = nil, nil
+		}
+	}
+}
+
+// Clone copies the items into the graph along with the arcs between them.
+// The copies of groups share the graph of their originals, their inputs and
+// outputs are linked to the copies of the places. The copies are returned
+// in the order of the items.
+func (g *Graph) Clone(places []*Place, transitions []*Transition, groups []*Group) ([]*Place, []*Transition, []*Group) {
+	copies := make(map[*Place]*Place, len(places))
+	clonedPlaces := make([]*Place, len(places))
+	for i, p := range places {
+		c := &Place{
+			Id:      util.GenUUID(),
+			X:       p.X,
+			Y:       p.Y,
+			Counter: p.Counter,
+			Timer:   p.Timer,
+			Label:   p.Label,
+		}
+		copies[p] = c
+		clonedPlaces[i] = c
+	}
+	g.Places = append(g.Places, clonedPlaces...)
+	clonedTransitions := make([]*Transition, len(transitions))
+	for i, t := range transitions {
+		c := &Transition{
+			Id:         util.GenUUID(),
+			X:          t.X,
+			Y:          t.Y,
+			Label:      t.Label,
+			Horizontal: t.Horizontal,
+			Kind:       t.Kind,
+		}
+		c.cloneArcs(t, copies)
+		clonedTransitions[i] = c
+	}
+	g.Transitions = append(g.Transitions, clonedTransitions...)
+	clonedGroups := make([]*Group, len(groups))
+	for i, gr := range groups {
+		c := &Group{
+			Id:     util.GenUUID(),
+			X:      gr.X,
+			Y:      gr.Y,
+			Label:  gr.Label,
+			Folded: gr.Folded,
+			Graph:  gr.Graph,
+		}
+		c.UpdateIO()
+		io := make(map[*Transition]*Transition, len(gr.Inputs)+len(gr.Outputs))
+		for _, t := range gr.Inputs {
+			io[t.Proxy] = t
+		}
+		for _, t := range gr.Outputs {
+			io[t.Proxy] = t
+		}
+		for _, t := range append(append([]*Transition{}, c.Inputs...), c.Outputs...) {
+			if old, ok := io[t.Proxy]; ok {
+				t.X, t.Y = old.X, old.Y
+				t.Label, t.Horizontal = old.Label, old.Horizontal
+				t.cloneArcs(old, copies)
+			}
+		}
+		g.Groups = append(g.Groups, c)
+		clonedGroups[i] = c
+	}
+	return clonedPlaces, clonedTransitions, clonedGroups
+}
+
+// cloneArcs links c to the copies of the places of t that have one.
+func (c *Transition) cloneArcs(t *Transition, copies map[*Place]*Place) {
+	for _, p := range t.In {
+		if p2, ok := copies[p]; ok && p2.Out == nil {
+			p2.Out = c
+			c.In = append(c.In, p2)
+			if p.OutControl != nil {
+				cp := *p.OutControl
+				p2.OutControl = &cp
+			}
+		}
+	}
+	for _, p := range t.Out {
+		if p2, ok := copies[p]; ok && p2.In == nil {
+			p2.In = c
+			c.Out = append(c.Out, p2)
+			if p.InControl != nil {
+				cp := *p.InControl
+				p2.InControl = &cp
+			}
+		}
+	}
+}
+
+// Ungroup puts a copy of the group's graph in place of the group,
+// the places linked to the group get linked to the copies of the
+// transitions behind its inputs and outputs. The copies are returned
+// in the order of the items of the group's graph.
+func (g *Graph) Ungroup(gr *Group) (places []*Place, transitions []*Transition, groups []*Group) {
+	sub := gr.Graph
+	places, transitions, groups = g.Clone(sub.Places, sub.Transitions, sub.Groups)
+	copies := make(map[*Transition]*Transition, len(sub.Transitions))
+	for i, t := range sub.Transitions {
+		copies[t] = transitions[i]
+	}
+	type arc struct {
+		t       *Transition
+		p       *Place
+		inbound bool
+	}
+	var arcs []arc
+	for _, t := range gr.Inputs {
+		for _, p := range t.In {
+			arcs = append(arcs, arc{copies[t.Proxy], p, true})
+		}
+	}
+	for _, t := range gr.Outputs {
+		for _, p := range t.Out {
+			arcs = append(arcs, arc{copies[t.Proxy], p, false})
+		}
+	}
+	g.RemoveGroup(gr)
+	// the border of the group is gone
+	for _, t := range transitions {
+		t.Kind = TransitionInternal
+	}
+	for _, a := range arcs {
+		a.t.Link(a.p, a.inbound)
+	}
+	for _, gr2 := range groups {
+		gr2.Graph.Parent = g
+	}
+	return
+}
+
+// Model returns the serialized form of the graph.
+func (g *Graph) Model() *Model {
+	m := &Model{
+		Id:          g.Id,
+		Places:      make([]*PlaceModel, 0, len(g.Places)),
+		Transitions: make([]*TransitionModel, len(g.Transitions)),
+		Groups:      make([]*GroupModel, len(g.Groups)),
+	}
+	linked := make(map[*Place]bool, len(g.Places))
+	for i, t := range g.Transitions {
+		m.Transitions[i] = t.Model()
+		for _, p := range t.In {
+			linked[p] = true
+		}
+		for _, p := range t.Out {
+			linked[p] = true
+		}
+	}
+	for _, p := range g.Places {
+		if !linked[p] {
+			m.Places = append(m.Places, p.Model())
+		}
+	}
+	for i, gr := range g.Groups {
+		m.Groups[i] = gr.Model()
+	}
+	for _, info := range g.Infos {
+		c := *info
+		m.Infos = append(m.Infos, &c)
+	}
+	return m
+}
+
+func (p *Place) Model() *PlaceModel {
+	m := &PlaceModel{
+		Id:      p.Id,
+		X:       p.X,
+		Y:       p.Y,
+		Counter: p.Counter,
+		Timer:   p.Timer,
+		Label:   p.Label,
+	}
+	if p.In != nil && p.InControl != nil {
+		cp := *p.InControl
+		m.InControl = &cp
+	}
+	if p.Out != nil && p.OutControl != nil {
+		cp := *p.OutControl
+		m.OutControl = &cp
+	}
+	return m
+}
+
+func (t *Transition) Model() *TransitionModel {
+	m := &TransitionModel{
+		Id:         t.Id,
+		X:          t.X,
+		Y:          t.Y,
+		Label:      t.Label,
+		Horizontal: t.Horizontal,
+		Kind:       t.Kind,
+		In:         make([]*PlaceModel, len(t.In)),
+		Out:        make([]*PlaceModel, len(t.Out)),
+	}
+	if t.Proxy != nil {
+		m.ProxyId = t.Proxy.Id
+	}
+	for i, p := range t.In {
+		m.In[i] = p.Model()
+	}
+	for i, p := range t.Out {
+		m.Out[i] = p.Model()
+	}
+	return m
+}
+
+func (gr *Group) Model() *GroupModel {
+	m := &GroupModel{
+		Id:      gr.Id,
+		X:       gr.X,
+		Y:       gr.Y,
+		Label:   gr.Label,
+		Folded:  gr.Folded,
+		Model:   gr.Graph.Model(),
+		Inputs:  make([]*TransitionModel, len(gr.Inputs)),
+		Outputs: make([]*TransitionModel, len(gr.Outputs)),
+		Iostate: make(map[string]string, len(gr.Inputs)+len(gr.Outputs)),
+	}
+	for i, t := range gr.Inputs {
+		m.Inputs[i] = t.Model()
+		m.Iostate[t.Id] = t.Proxy.Id
+	}
+	for i, t := range gr.Outputs {
+		m.Outputs[i] = t.Model()
+		m.Iostate[t.Id] = t.Proxy.Id
+	}
+	return m
+}
+
+// Build constructs the graph of a model, the model is validated first.
+// The groups with models of the same id share the graph.
+func Build(m *Model) (*Graph, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return build(m, make(map[string]*Graph)), nil
+}
+
+func build(m *Model, graphs map[string]*Graph) *Graph {
+	g := &Graph{Id: m.Id}
+	places := make(map[string]*Place)
+	place := func(pm *PlaceModel) *Place {
+		p, ok := places[pm.Id]
+		if !ok {
+			p = &Place{
+				Id:      pm.Id,
+				X:       pm.X,
+				Y:       pm.Y,
+				Counter: pm.Counter,
+				Timer:   pm.Timer,
+				Label:   pm.Label,
+			}
+			places[pm.Id] = p
+			g.Places = append(g.Places, p)
+		}
+		if pm.InControl != nil {
+			cp := *pm.InControl
+			p.InControl = &cp
+		}
+		if pm.OutControl != nil {
+			cp := *pm.OutControl
+			p.OutControl = &cp
+		}
+		return p
+	}
+	transition := func(tm *TransitionModel) *Transition {
+		return &Transition{
+			Id:         tm.Id,
+			X:          tm.X,
+			Y:          tm.Y,
+			Label:      tm.Label,
+			Horizontal: tm.Horizontal,
+			Kind:       tm.Kind,
+		}
+	}
+	for _, gm := range m.Groups {
+		sub, ok := graphs[gm.Model.Id]
+		if !ok {
+			sub = build(gm.Model, graphs)
+			sub.Parent = g
+			graphs[gm.Model.Id] = sub
+		}
+		gr := &Group{
+			Id:     gm.Id,
+			X:      gm.X,
+			Y:      gm.Y,
+			Label:  gm.Label,
+			Folded: gm.Folded,
+			Graph:  sub,
+		}
+		for _, tm := range gm.Inputs {
+			t := transition(tm)
+			t.Group = gr
+			t.Proxy = sub.Find(gm.Iostate[tm.Id]).(*Transition)
+			for _, pm := range tm.In {
+				p := place(pm)
+				p.Out = t
+				t.In = append(t.In, p)
+			}
+			t.Out = append(t.Out, t.Proxy.Out...)
+			gr.Inputs = append(gr.Inputs, t)
+		}
+		for _, tm := range gm.Outputs {
+			t := transition(tm)
+			t.Group = gr
+			t.Proxy = sub.Find(gm.Iostate[tm.Id]).(*Transition)
+			for _, pm := range tm.Out {
+				p := place(pm)
+				p.In = t
+				t.Out = append(t.Out, p)
+			}
+			t.In = append(t.In, t.Proxy.In...)
+			gr.Outputs = append(gr.Outputs, t)
+		}
+		g.Groups = append(g.Groups, gr)
+	}
+	for _, tm := range m.Transitions {
+		t := transition(tm)
+		for _, pm := range tm.In {
+			p := place(pm)
+			p.Out = t
+			t.In = append(t.In, p)
+		}
+		for _, pm := range tm.Out {
+			p := place(pm)
+			p.In = t
+			t.Out = append(t.Out, p)
+		}
+		g.Transitions = append(g.Transitions, t)
+	}
+	for _, pm := range m.Places {
+		place(pm)
+	}
+	for _, info := range m.Infos {
+		t, ok := g.Find(info.IoId).(*Transition)
+		if !ok || t.Proxy != nil || t.Kind == TransitionInternal {
+			continue
+		}
+		c := *info
+		g.Infos = append(g.Infos, &c)
+	}
+	return g
+}
+
+// Load decodes a document of any known version and builds its graph.
+func Load(data []byte) (*Graph, error) {
+	m, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return Build(m)
+}
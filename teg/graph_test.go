@@ -0,0 +1,173 @@
+package teg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/remogatto/prettytest"
+)
+
+type testSuite struct {
+	prettytest.Suite
+}
+
+func TestRunner(t *testing.T) {
+	prettytest.RunWithFormatter(
+		t,
+		new(prettytest.TDDFormatter),
+		new(testSuite),
+	)
+}
+
+// sample builds u → t1 ⇄ t2 → y, the place from t1 to t2 holds
+// a token for 3 units of time and the one back waits 2 units.
+func sample() (g *Graph, u, t1, t2, y *Transition) {
+	g = New()
+	u, t1 = g.AddTransition(0, 0), g.AddTransition(100, 0)
+	t2, y = g.AddTransition(200, 0), g.AddTransition(300, 0)
+	u.Kind, y.Kind = TransitionInput, TransitionOutput
+	u.Label, t1.Label, t2.Label, y.Label = "u", "t1", "t2", "y"
+	link := func(from, to *Transition, counter, timer int) {
+		p := g.AddPlace(0, 0)
+		p.Counter, p.Timer = counter, timer
+		from.Link(p, false)
+		to.Link(p, true)
+	}
+	link(u, t1, 0, 0)
+	link(t1, t2, 1, 3)
+	link(t2, t1, 0, 2)
+	link(t2, y, 0, 0)
+	return
+}
+
+// chain builds the sample without the place back to t1,
+// t1 and t2 can be grouped then.
+func chain() (g *Graph, u, t1, t2, y *Transition) {
+	g, u, t1, t2, y = sample()
+	g.RemovePlace(t2.Out[0])
+	return
+}
+
+func (t *testSuite) TestLink() {
+	g := New()
+	a, b := g.AddTransition(0, 0), g.AddTransition(0, 0)
+	p := g.AddPlace(0, 0)
+	t.Nil(a.Link(p, false))
+	t.Nil(b.Link(p, true))
+	t.True(p.In == a && p.Out == b)
+	t.Not(a.Link(p, false) == nil, "a place has one upstream transition")
+	a.Kind = TransitionOutput
+	t.Not(a.Link(g.AddPlace(0, 0), false) == nil, "an output has no output places")
+
+	b.Unlink(p, true)
+	t.True(p.Out == nil)
+	t.Equal(0, len(b.In))
+	t.Nil(b.Link(p, true))
+
+	g.RemoveTransition(b)
+	t.True(p.Out == nil)
+	t.Equal(1, len(g.Transitions))
+	g.RemovePlace(p)
+	t.Equal(0, len(a.Out))
+	t.Equal(1, len(g.Places))
+}
+
+func (t *testSuite) TestGroup() {
+	g, _, t1, t2, _ := chain()
+	h := g.TransferReport().String()
+	gr, err := g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Nil(err)
+	t.Equal(2, len(g.Transitions))
+	t.Equal(1, len(gr.Graph.Places))
+	t.Equal(1, len(gr.Inputs))
+	t.Equal(1, len(gr.Outputs))
+	t.True(gr.Inputs[0].Proxy == t1)
+	t.True(gr.Outputs[0].Proxy == t2)
+	// the parent places are linked to the inputs and outputs
+	t.True(g.Transitions[0].Out[0].Out == gr.Inputs[0])
+	t.True(g.Transitions[1].In[0].In == gr.Outputs[0])
+	t.Equal(TransitionOutput, gr.Inputs[0].Kind)
+	t.Equal(TransitionInput, gr.Outputs[0].Kind)
+	t.Equal(h, g.TransferReport().String())
+}
+
+func (t *testSuite) TestGroupExposed() {
+	g, _, t1, t2, _ := sample()
+	// t2 feeds both t1 inside and y outside
+	_, err := g.AddGroup([]*Place{t1.Out[0], t2.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Not(err == nil)
+	_, err = g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1}, nil)
+	t.Not(err == nil, "the place leads outside")
+	t.Equal(4, len(g.Transitions))
+}
+
+func (t *testSuite) TestUngroup() {
+	g, _, t1, t2, _ := chain()
+	gr, err := g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Nil(err)
+	sig := g.TransferReport().String()
+	places, transitions, _ := g.Ungroup(gr)
+	t.Equal(1, len(places))
+	t.Equal(2, len(transitions))
+	t.Equal(0, len(g.Groups))
+	t.Equal(4, len(g.Transitions))
+	t.Equal(3, len(g.Places))
+	for _, tr := range transitions {
+		t.Equal(TransitionInternal, tr.Kind)
+		t.Not(tr.Id == t1.Id || tr.Id == t2.Id, "the copies get new ids")
+	}
+	t.Equal(sig, g.TransferReport().String())
+	t.Nil(g.Model().Validate())
+}
+
+func (t *testSuite) TestUpdateIO() {
+	g, u, t1, t2, _ := chain()
+	gr, err := g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Nil(err)
+	in, out := gr.Inputs[0], gr.Outputs[0]
+	// a second input inside the group
+	v := gr.Graph.AddTransition(0, 0)
+	v.Kind = TransitionInput
+	t.Nil(v.Link(gr.Graph.AddPlace(0, 0), false))
+	t.Nil(t2.Link(v.Out[0], true))
+	gr.UpdateIO()
+	t.Equal(2, len(gr.Inputs))
+	t.True(gr.Inputs[0] == in && gr.Outputs[0] == out, "the inputs and outputs are kept")
+	t.True(u.Out[0].Out == in)
+
+	gr.Graph.RemoveTransition(t2)
+	t.Equal(0, len(gr.Outputs))
+	t.True(g.Transitions[1].In[0].In == nil, "the place of the output is unlinked")
+}
+
+func (t *testSuite) TestClone() {
+	g, u, t1, t2, _ := chain()
+	gr, err := g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Nil(err)
+	places, transitions, groups := g.Clone(
+		[]*Place{u.Out[0]}, []*Transition{u}, []*Group{gr})
+	t.Equal(1, len(places))
+	t.True(transitions[0].Out[0] == places[0])
+	t.True(places[0].Id != u.Out[0].Id)
+	t.True(groups[0].Graph == gr.Graph, "the copies share the graph")
+	t.Equal(1, len(groups[0].Inputs))
+	t.True(groups[0].Inputs[0] != gr.Inputs[0])
+	t.True(places[0].Out == groups[0].Inputs[0])
+	t.True(gr.Inputs[0].In[0] == u.Out[0])
+	t.Equal(2, len(g.Groups))
+	t.Nil(g.Model().Validate())
+}
+
+func (t *testSuite) TestRoundTrip() {
+	g, _, t1, t2, _ := chain()
+	_, err := g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Nil(err)
+	var buf bytes.Buffer
+	t.Nil(Encode(&buf, g.Model()))
+	g2, err := Load(buf.Bytes())
+	t.Nil(err)
+	var buf2 bytes.Buffer
+	t.Nil(Encode(&buf2, g2.Model()))
+	t.Equal(buf.String(), buf2.String())
+	t.Equal(g.Signature(), g2.Signature())
+}
@@ -0,0 +1,38 @@
+package teg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xlab/teg-workshop/util"
+)
+
+// WriteLatex writes the series of the input and output planes as an align*
+// environment of LaTeX math, the planes are named after the labels of their
+// transitions. The series are written from their structure, see dioid.Serie.Latex.
+func WriteLatex(w io.Writer, g *Graph) error {
+	labels := g.Labels()
+	var rows []string
+	for _, kind := range []int{TransitionInput, TransitionOutput} {
+		for _, t := range g.Transitions {
+			info := g.Info(t.Id)
+			if t.Kind != kind || info == nil {
+				continue
+			}
+			rows = append(rows, fmt.Sprintf("  \\text{%s} &= %s",
+				util.TexEscape(labels[Ref{"", t.Id}]), info.Serie.Latex()))
+		}
+	}
+	buf := bufio.NewWriter(w)
+	if len(rows) < 1 {
+		fmt.Fprintf(buf, "%% no input or output planes\n")
+		return buf.Flush()
+	}
+	fmt.Fprintf(buf, "%% needs \\usepackage{amsmath}\n")
+	fmt.Fprintf(buf, "\\begin{align*}\n")
+	fmt.Fprintf(buf, "%s\n", strings.Join(rows, " \\\\\n"))
+	fmt.Fprintf(buf, "\\end{align*}\n")
+	return buf.Flush()
+}
@@ -0,0 +1,27 @@
+package teg
+
+import (
+	"bytes"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+func (t *testSuite) TestWriteLatex() {
+	g, u, _, _, y := sample()
+	u.Label = "u_1"
+	g.Infos = []*Info{
+		{IoId: y.Id, Serie: dioid.Serie{P: dioid.Poly{dioid.Eps}, Q: dioid.Poly{{G: 0, D: 3}}, R: dioid.Gd{G: 1, D: 5}}},
+		{IoId: u.Id, Serie: serieE},
+	}
+	var buf bytes.Buffer
+	t.Nil(g.Export(&buf, "latex"))
+	t.Equal("% needs \\usepackage{amsmath}\n\\begin{align*}\n"+
+		"  \\text{u\\_1} &= e \\\\\n"+
+		"  \\text{y} &= \\delta^{3}(\\gamma\\delta^{5})^{\\ast}\n"+
+		"\\end{align*}\n", buf.String())
+
+	g.Infos = nil
+	buf.Reset()
+	t.Nil(WriteLatex(&buf, g))
+	t.Equal("% no input or output planes\n", buf.String())
+}
@@ -0,0 +1,502 @@
+package teg
+
+import (
+	"math"
+	"sort"
+)
+
+// The sizes of the items as the editor draws them, the layouts need them
+// since transitions are positioned by their corner.
+const (
+	PlaceSize        = 50.0
+	TransitionWidth  = 6.0
+	TransitionHeight = 30.0
+	GroupMinSize     = 170.0
+	GroupMargin      = 10.0
+	GroupIOSpacing   = 20.0
+)
+
+// layout spacing between the layers and between the items of a layer
+const (
+	layerGap = 60.0
+	rowGap   = 30.0
+)
+
+// the number of sweeps reordering the layers
+const layerSweeps = 12
+
+// Size returns the width and the height of the transition as drawn.
+func (t *Transition) Size() (w, h float64) {
+	if t.Horizontal {
+		return TransitionHeight, TransitionWidth
+	}
+	return TransitionWidth, TransitionHeight
+}
+
+// box is where a layout puts an item, x and y are the centre.
+type box struct {
+	x, y, w, h float64
+}
+
+// arrange moves the items of the graph instance at the path to the boxes
+// the layout gave to their names, see dotName. The control points are
+// dropped since they no longer fit, the editor makes them anew.
+func (g *Graph) arrange(path string, boxes map[string]box) {
+	for _, p := range g.Places {
+		if b, ok := boxes[dotName(path, p.Id)]; ok {
+			p.X, p.Y = b.x, b.y
+			p.InControl, p.OutControl = nil, nil
+		}
+	}
+	for _, t := range g.Transitions {
+		if b, ok := boxes[dotName(path, t.Id)]; ok {
+			w, h := t.Size()
+			t.X, t.Y = b.x-w/2, b.y-h/2
+		}
+	}
+	for _, gr := range g.Groups {
+		inner := path + "/" + gr.Id
+		if b, ok := boxes["cluster_"+dotName(path, gr.Id)]; ok {
+			gr.X, gr.Y = b.x-b.w/2, b.y-b.h/2
+			// the inputs and outputs go along the sides of the frame,
+			// the editor lines them up again anyway
+			for i, list := range [][]*Transition{gr.Inputs, gr.Outputs} {
+				x, y := gr.X+GroupMargin, gr.Y+GroupMargin
+				for _, t := range list {
+					w, h := t.Size()
+					if t.Horizontal {
+						t.X, t.Y = x, gr.Y+float64(i)*b.h-h/2
+						x += w + GroupIOSpacing
+					} else {
+						t.X, t.Y = gr.X+float64(i)*b.w-w/2, y
+						y += h + GroupIOSpacing
+					}
+				}
+			}
+		}
+		gr.Graph.arrange(inner, boxes)
+	}
+}
+
+// Layout arranges the graph in layers along the places, see LayerLayout.
+// The layers go from left to right, or from top to bottom when most of
+// the transitions are horizontal. The graphs of groups are arranged on
+// their own and each group takes the room of its graph.
+func (g *Graph) Layout() {
+	boxes := make(map[string]box)
+	g.layout("", boxes)
+	g.arrange("", boxes)
+}
+
+// FlowsDown tells if the layers should go from top to bottom, that is
+// when there are more horizontal transitions than vertical ones.
+func FlowsDown(horizontal, vertical int) bool {
+	return horizontal > vertical
+}
+
+// layout puts the boxes of the graph instance at the path around (0, 0)
+// and tells the size of the area they take.
+func (g *Graph) layout(path string, boxes map[string]box) (w, h float64) {
+	// the nodes of the layered graph are the places, the transitions
+	// and the groups, the inputs and outputs of groups lead to the groups
+	var names []string
+	var nodes []*LayerNode
+	index := make(map[interface{}]int)
+	add := func(it interface{}, name string, w, h float64) {
+		index[it] = len(nodes)
+		names = append(names, name)
+		nodes = append(nodes, &LayerNode{W: w, H: h})
+	}
+	var horizontal int
+	for _, t := range g.Transitions {
+		if t.Horizontal {
+			horizontal++
+		}
+		w, h := t.Size()
+		add(t, dotName(path, t.Id), w, h)
+	}
+	for _, gr := range g.Groups {
+		w, h := gr.Graph.layout(path+"/"+gr.Id, boxes)
+		w, h = w+2*GroupMargin, h+2*GroupMargin
+		if gr.Folded {
+			w, h = 0, 0
+		}
+		add(gr, "cluster_"+dotName(path, gr.Id), math.Max(w, GroupMinSize), math.Max(h, GroupMinSize))
+		for _, t := range append(gr.Inputs, gr.Outputs...) {
+			index[t] = index[gr]
+		}
+	}
+	for _, p := range g.Places {
+		add(p, dotName(path, p.Id), PlaceSize, PlaceSize)
+	}
+	for _, p := range g.Places {
+		if u, ok := index[p.In]; ok {
+			nodes[u].Next = append(nodes[u].Next, index[p])
+		}
+		if u, ok := index[p.Out]; ok {
+			nodes[index[p]].Next = append(nodes[index[p]].Next, u)
+		}
+	}
+
+	w, h = LayerLayout(nodes, FlowsDown(horizontal, len(g.Transitions)-horizontal))
+	for i, n := range nodes {
+		boxes[names[i]] = box{x: n.X, y: n.Y, w: n.W, h: n.H}
+	}
+	return
+}
+
+// LayerNode is a node of a layered layout. W and H are its size and
+// Next are the nodes its arcs lead to, the layout puts its centre in X and Y.
+type LayerNode struct {
+	W, H float64
+	Next []int
+	X, Y float64
+}
+
+// LayerLayout arranges the nodes in layers along their arcs, from left to
+// right or from top to bottom when down is set, and tells the size of the
+// area they take around (0, 0). The arcs closing circuits are turned back
+// where the walk from the sources meets them. The long arcs go through
+// hidden nodes, so the order of each layer can follow the medians of the
+// neighbours and keep the arcs from crossing.
+func LayerLayout(nodes []*LayerNode, down bool) (w, h float64) {
+	if len(nodes) < 1 {
+		return 0, 0
+	}
+	succ := make([][]int, len(nodes))
+	for v, n := range nodes {
+		for _, u := range n.Next {
+			if u != v {
+				succ[v] = append(succ[v], u)
+			}
+		}
+	}
+	layer, order, back := layering(succ)
+
+	// the arcs of the proper graph join adjacent layers only,
+	// the hidden nodes come after the given ones
+	ups, downs := make([][]int, len(nodes)), make([][]int, len(nodes))
+	layerOf := append([]int(nil), layer...)
+	link := func(a, b int) {
+		downs[a] = append(downs[a], b)
+		ups[b] = append(ups[b], a)
+	}
+	for _, v := range order {
+		for _, u := range succ[v] {
+			a, b := v, u
+			if back[[2]int{v, u}] {
+				a, b = u, v
+			}
+			for l := layerOf[a] + 1; l < layerOf[b]; l++ {
+				ups, downs = append(ups, nil), append(downs, nil)
+				layerOf = append(layerOf, l)
+				link(a, len(ups)-1)
+				a = len(ups) - 1
+			}
+			link(a, b)
+		}
+	}
+	var layers [][]int
+	for _, v := range order {
+		for len(layers) <= layer[v] {
+			layers = append(layers, nil)
+		}
+		layers[layer[v]] = append(layers[layer[v]], v)
+	}
+	for v := len(nodes); v < len(ups); v++ {
+		layers[layerOf[v]] = append(layers[layerOf[v]], v)
+	}
+
+	// the median heuristic, the order with the fewest crossings is kept
+	pos := make([]float64, len(ups))
+	number := func() {
+		for _, list := range layers {
+			for i, v := range list {
+				pos[v] = float64(i)
+			}
+		}
+	}
+	number()
+	best, fewest := copyLayers(layers), crossings(layers, downs, pos)
+	for sweep := 0; sweep < layerSweeps && fewest > 0; sweep++ {
+		if sweep%2 == 0 {
+			for l := 1; l < len(layers); l++ {
+				sortByMedian(layers[l], ups, pos)
+			}
+		} else {
+			for l := len(layers) - 2; l >= 0; l-- {
+				sortByMedian(layers[l], downs, pos)
+			}
+		}
+		transpose(layers, ups, downs, pos)
+		if n := crossings(layers, downs, pos); n < fewest {
+			best, fewest = copyLayers(layers), n
+		}
+	}
+	layers = best
+	number()
+
+	// the sizes along the layers and across them, hidden nodes take none
+	size := func(v int) (along, across float64) {
+		if v >= len(nodes) {
+			return 0, 0
+		}
+		if down {
+			return nodes[v].H, nodes[v].W
+		}
+		return nodes[v].W, nodes[v].H
+	}
+	at := make([]float64, len(ups))
+	var x float64
+	for _, list := range layers {
+		var extent float64
+		for _, v := range list {
+			along, _ := size(v)
+			extent = math.Max(extent, along)
+		}
+		for _, v := range list {
+			at[v] = x + extent/2
+		}
+		x += extent + layerGap
+	}
+	// the nodes are stacked in their layers first, then the layers
+	// are moved towards the neighbours a few times
+	across := make([]float64, len(ups))
+	for _, list := range layers {
+		var y float64
+		for _, v := range list {
+			_, s := size(v)
+			across[v] = y + s/2
+			y += s + rowGap
+		}
+	}
+	for sweep := 0; sweep < 4; sweep++ {
+		for l := 1; l < len(layers); l++ {
+			place(layers[l], ups, across, size)
+		}
+		for l := len(layers) - 2; l >= 0; l-- {
+			place(layers[l], downs, across, size)
+		}
+	}
+
+	// the area of the given nodes is centred on (0, 0)
+	x0, y0 := math.MaxFloat64, math.MaxFloat64
+	x1, y1 := -math.MaxFloat64, -math.MaxFloat64
+	for v, n := range nodes {
+		n.X, n.Y = at[v], across[v]
+		if down {
+			n.X, n.Y = n.Y, n.X
+		}
+		x0, y0 = math.Min(x0, n.X-n.W/2), math.Min(y0, n.Y-n.H/2)
+		x1, y1 = math.Max(x1, n.X+n.W/2), math.Max(y1, n.Y+n.H/2)
+	}
+	for _, n := range nodes {
+		n.X -= (x0 + x1) / 2
+		n.Y -= (y0 + y1) / 2
+	}
+	return x1 - x0, y1 - y0
+}
+
+// layering assigns the nodes to layers by the longest path from the
+// sources, the arcs closing circuits are left aside as back arcs.
+// The order is the one of the depth-first walk from the sources.
+func layering(succ [][]int) (layer, order []int, back map[[2]int]bool) {
+	const (
+		unseen = iota
+		onStack
+		done
+	)
+	state := make([]int, len(succ))
+	back = make(map[[2]int]bool)
+	var visit func(v int)
+	visit = func(v int) {
+		state[v] = onStack
+		for _, u := range succ[v] {
+			switch state[u] {
+			case unseen:
+				visit(u)
+			case onStack:
+				back[[2]int{v, u}] = true
+			}
+		}
+		state[v] = done
+		order = append(order, v)
+	}
+	indegree := make([]int, len(succ))
+	for _, list := range succ {
+		for _, u := range list {
+			indegree[u]++
+		}
+	}
+	// sources first, then whatever is left in circuits
+	for v := range succ {
+		if indegree[v] == 0 && state[v] == unseen {
+			visit(v)
+		}
+	}
+	for v := range succ {
+		if state[v] == unseen {
+			visit(v)
+		}
+	}
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	layer = make([]int, len(succ))
+	for _, v := range order {
+		for _, u := range succ[v] {
+			if !back[[2]int{v, u}] && layer[u] < layer[v]+1 {
+				layer[u] = layer[v] + 1
+			}
+		}
+	}
+	return
+}
+
+func copyLayers(layers [][]int) [][]int {
+	c := make([][]int, len(layers))
+	for i := range layers {
+		c[i] = append([]int(nil), layers[i]...)
+	}
+	return c
+}
+
+// crossings counts the pairs of arcs crossing between the layers.
+func crossings(layers [][]int, downs [][]int, pos []float64) (n int) {
+	for _, list := range layers {
+		var arcs [][2]float64
+		for _, v := range list {
+			for _, u := range downs[v] {
+				arcs = append(arcs, [2]float64{pos[v], pos[u]})
+			}
+		}
+		for i := range arcs {
+			for j := i + 1; j < len(arcs); j++ {
+				if (arcs[i][0]-arcs[j][0])*(arcs[i][1]-arcs[j][1]) < 0 {
+					n++
+				}
+			}
+		}
+	}
+	return
+}
+
+// median is the middle position of the neighbours,
+// or the own position of the node if it has none.
+func median(v int, adj [][]int, pos []float64) float64 {
+	if len(adj[v]) < 1 {
+		return pos[v]
+	}
+	list := make([]float64, len(adj[v]))
+	for i, u := range adj[v] {
+		list[i] = pos[u]
+	}
+	sort.Float64s(list)
+	m := len(list) / 2
+	if len(list)%2 == 0 {
+		return (list[m-1] + list[m]) / 2
+	}
+	return list[m]
+}
+
+type byMedian struct {
+	nodes   []int
+	medians []float64
+}
+
+func (b byMedian) Len() int           { return len(b.nodes) }
+func (b byMedian) Less(i, j int) bool { return b.medians[i] < b.medians[j] }
+func (b byMedian) Swap(i, j int) {
+	b.nodes[i], b.nodes[j] = b.nodes[j], b.nodes[i]
+	b.medians[i], b.medians[j] = b.medians[j], b.medians[i]
+}
+
+// sortByMedian orders the layer by the medians of the neighbours
+// in the adjacent layer and numbers it again.
+func sortByMedian(list []int, adj [][]int, pos []float64) {
+	medians := make([]float64, len(list))
+	for i, v := range list {
+		medians[i] = median(v, adj, pos)
+	}
+	sort.Stable(byMedian{list, medians})
+	for i, v := range list {
+		pos[v] = float64(i)
+	}
+}
+
+// crossed counts the crossings of the arcs of u with the arcs of v,
+// u being before v in their layer.
+func crossed(u, v int, adj [][]int, pos []float64) (n int) {
+	for _, a := range adj[u] {
+		for _, b := range adj[v] {
+			if pos[a] > pos[b] {
+				n++
+			}
+		}
+	}
+	return
+}
+
+// transpose swaps the neighbours in the layers while
+// it leaves fewer arcs crossing, the medians miss some.
+func transpose(layers [][]int, ups, downs [][]int, pos []float64) {
+	for improved := true; improved; {
+		improved = false
+		for _, list := range layers {
+			for i := 0; i+1 < len(list); i++ {
+				u, v := list[i], list[i+1]
+				if crossed(v, u, ups, pos)+crossed(v, u, downs, pos) <
+					crossed(u, v, ups, pos)+crossed(u, v, downs, pos) {
+					list[i], list[i+1] = v, u
+					pos[u], pos[v] = pos[v], pos[u]
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+// place moves the nodes of the layer as close to the medians of their
+// neighbours as their order and sizes let them. The nodes pushing each
+// other make blocks, a block sits at the mean of what its nodes want.
+func place(list []int, adj [][]int, across []float64, size func(int) (float64, float64)) {
+	type block struct {
+		first, last int
+		sum         float64 // of the wanted positions less the offsets
+		length      float64 // from the centre of the first node to the last
+		at          float64 // the centre of the first node
+	}
+	gap := func(i int) float64 {
+		_, s1 := size(list[i-1])
+		_, s2 := size(list[i])
+		return (s1+s2)/2 + rowGap
+	}
+	var blocks []*block
+	for i, v := range list {
+		want := median(v, adj, across)
+		b := &block{first: i, last: i, sum: want, at: want}
+		for len(blocks) > 0 {
+			prev := blocks[len(blocks)-1]
+			if prev.at+prev.length+gap(b.first) <= b.at {
+				break
+			}
+			shift := prev.length + gap(b.first)
+			prev.sum += b.sum - shift*float64(b.last-b.first+1)
+			prev.length = shift + b.length
+			prev.last = b.last
+			prev.at = prev.sum / float64(prev.last-prev.first+1)
+			blocks, b = blocks[:len(blocks)-1], prev
+		}
+		blocks = append(blocks, b)
+	}
+	for _, b := range blocks {
+		y := b.at
+		for i := b.first; i <= b.last; i++ {
+			if i > b.first {
+				y += gap(i)
+			}
+			across[list[i]] = y
+		}
+	}
+}
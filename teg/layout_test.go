@@ -0,0 +1,57 @@
+package teg
+
+func (t *testSuite) TestLayout() {
+	g, u, t1, t2, y := sample()
+	g.Layout()
+	t.True(u.X < u.Out[0].X && u.Out[0].X < t1.X)
+	t.True(t1.X < t2.X && t2.X < y.X)
+	for _, p := range g.Places {
+		t.True(p.InControl == nil && p.OutControl == nil)
+	}
+
+	g, _, t1, t2, _ = chain()
+	gr, err := g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Nil(err)
+	g.Layout()
+	t.True(g.Places[0].X < gr.X)
+	t.True(gr.Inputs[0].X < gr.Outputs[0].X)
+	t.True(t1.X < t2.X)
+}
+
+func (t *testSuite) TestLayerLayout() {
+	// a → d and b → c cross unless the second layer is swapped
+	nodes := []*LayerNode{
+		{W: 10, H: 10, Next: []int{3}},
+		{W: 10, H: 10, Next: []int{2}},
+		{W: 10, H: 10},
+		{W: 10, H: 10},
+	}
+	w, h := LayerLayout(nodes, false)
+	a, b, c, d := nodes[0], nodes[1], nodes[2], nodes[3]
+	t.True((a.Y-b.Y)*(d.Y-c.Y) > 0)
+	t.True(a.X < d.X && b.X < c.X)
+	t.Equal(80.0, w)
+	t.Equal(50.0, h)
+
+	// a circuit goes down, the arc back takes room beside it
+	nodes = []*LayerNode{
+		{W: 10, H: 10, Next: []int{1}},
+		{W: 10, H: 10, Next: []int{2}},
+		{W: 10, H: 10, Next: []int{0}},
+	}
+	_, h = LayerLayout(nodes, true)
+	t.Equal(nodes[0].X, nodes[2].X)
+	t.Not(nodes[0].X == nodes[1].X)
+	t.True(nodes[0].Y < nodes[1].Y && nodes[1].Y < nodes[2].Y)
+	t.Equal(150.0, h)
+
+	// a chain stays straight
+	nodes = []*LayerNode{
+		{W: 10, H: 10, Next: []int{1}},
+		{W: 10, H: 30, Next: []int{2}},
+		{W: 10, H: 10},
+	}
+	LayerLayout(nodes, false)
+	t.Equal(nodes[0].Y, nodes[1].Y)
+	t.Equal(nodes[1].Y, nodes[2].Y)
+}
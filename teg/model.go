@@ -0,0 +1,72 @@
+package teg
+
+import "github.com/xlab/teg-workshop/dioid"
+
+const (
+	TransitionInternal = iota
+	TransitionInput
+	TransitionOutput
+	TransitionExposed
+)
+
+// The models below are what gets saved. A place is written along with each
+// transition it is linked to, the places linked to no transition are listed
+// in Places. Transitions are positioned by their corner, places by their centre.
+
+type ControlPoint struct {
+	X, Y     float64
+	Modified bool
+}
+
+type PlaceModel struct {
+	Id         string
+	X, Y       float64
+	Counter    int
+	Timer      int
+	Label      string
+	InControl  *ControlPoint
+	OutControl *ControlPoint
+}
+
+type TransitionModel struct {
+	Id         string
+	X, Y       float64
+	In, Out    []*PlaceModel
+	ProxyId    string
+	Label      string
+	Horizontal bool
+	Kind       int
+}
+
+// GroupModel holds the model of a group. The inputs of the group take parent
+// places in and give the places of the model out, the outputs do the reverse.
+// Iostate maps the ids of the inputs and outputs to the ids of the transitions
+// of the model behind them.
+type GroupModel struct {
+	Id      string
+	X, Y    float64
+	Inputs  []*TransitionModel
+	Outputs []*TransitionModel
+	Iostate map[string]string
+	Label   string
+	Folded  bool
+	Model   *Model
+}
+
+// Info is the plane of an input or output transition. The serie of an output
+// holding a reference is the reference the inputs are computed from.
+type Info struct {
+	IoId      string
+	Color     string
+	Enabled   bool
+	Reference bool `json:",omitempty"`
+	Serie     dioid.Serie
+}
+
+type Model struct {
+	Id          string
+	Places      []*PlaceModel
+	Transitions []*TransitionModel
+	Groups      []*GroupModel
+	Infos       []*Info `json:",omitempty"`
+}
@@ -0,0 +1,480 @@
+package teg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xlab/teg-workshop/util"
+)
+
+// PNML is the Petri Net Markup Language, the nets are written as P/T nets.
+// The initial marking is the standard one, the timers of places and the
+// kinds of transitions are tool specific data. Groups are nested pages,
+// the arcs of the inputs and outputs of a group lead to reference transitions
+// of the transitions behind them. Positions are the ones of the editor:
+// places by their centre, transitions by their corner.
+const (
+	PNMLNamespace = "http://www.pnml.org/version-2009/grammar/pnml"
+	PNMLNetType   = "http://www.pnml.org/version-2009/grammar/ptnet"
+	PNMLTool      = "teg-workshop"
+)
+
+type pnmlDoc struct {
+	XMLName xml.Name   `xml:"pnml"`
+	Xmlns   string     `xml:"xmlns,attr,omitempty"`
+	Nets    []*pnmlNet `xml:"net"`
+}
+
+type pnmlNet struct {
+	Id    string      `xml:"id,attr"`
+	Type  string      `xml:"type,attr"`
+	Pages []*pnmlPage `xml:"page"`
+}
+
+type pnmlText struct {
+	Text string `xml:"text"`
+}
+
+type pnmlPosition struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+}
+
+type pnmlGraphics struct {
+	Position *pnmlPosition `xml:"position"`
+}
+
+// pnmlTool is the data of this tool, the data of other tools is ignored.
+type pnmlTool struct {
+	Tool       string `xml:"tool,attr"`
+	Version    string `xml:"version,attr"`
+	Timer      int    `xml:"timer,omitempty"`
+	Kind       string `xml:"kind,omitempty"`
+	Horizontal bool   `xml:"horizontal,omitempty"`
+	Folded     bool   `xml:"folded,omitempty"`
+}
+
+type pnmlNode struct {
+	Id       string        `xml:"id,attr"`
+	Ref      string        `xml:"ref,attr,omitempty"`
+	Name     *pnmlText     `xml:"name,omitempty"`
+	Graphics *pnmlGraphics `xml:"graphics,omitempty"`
+	Marking  *pnmlText     `xml:"initialMarking,omitempty"`
+	Tools    []*pnmlTool   `xml:"toolspecific"`
+}
+
+type pnmlArc struct {
+	Id          string    `xml:"id,attr"`
+	Source      string    `xml:"source,attr"`
+	Target      string    `xml:"target,attr"`
+	Inscription *pnmlText `xml:"inscription,omitempty"`
+}
+
+type pnmlPage struct {
+	pnmlNode
+	Places         []*pnmlNode `xml:"place"`
+	Transitions    []*pnmlNode `xml:"transition"`
+	RefPlaces      []*pnmlNode `xml:"referencePlace"`
+	RefTransitions []*pnmlNode `xml:"referenceTransition"`
+	Arcs           []*pnmlArc  `xml:"arc"`
+	Pages          []*pnmlPage `xml:"page"`
+}
+
+var pnmlKinds = map[int]string{
+	TransitionInput:  "input",
+	TransitionOutput: "output",
+}
+
+// pnmlId makes an XML id of the item of the group instance at the path,
+// ids should not start with a digit.
+func pnmlId(path, id string) string {
+	s := strings.TrimPrefix(strings.Replace(path, "/", ".", -1)+"."+id, ".")
+	if len(s) > 0 && (s[0] >= '0' && s[0] <= '9' || s[0] == '-' || s[0] == '.') {
+		s = "_" + s
+	}
+	return s
+}
+
+func pnmlName(label string) *pnmlText {
+	if len(label) < 1 {
+		return nil
+	}
+	return &pnmlText{label}
+}
+
+func pnmlAt(x, y float64) *pnmlGraphics {
+	return &pnmlGraphics{&pnmlPosition{x, y}}
+}
+
+func (tool *pnmlTool) empty() bool {
+	return *tool == pnmlTool{Tool: tool.Tool, Version: tool.Version}
+}
+
+func newPnmlTool() *pnmlTool {
+	return &pnmlTool{Tool: PNMLTool, Version: strconv.Itoa(FormatVersion)}
+}
+
+// WritePNML writes the graph as a PNML document, the planes of
+// the inputs and outputs are left out.
+func WritePNML(w io.Writer, g *Graph) error {
+	top := &pnmlPage{}
+	top.Id = pnmlId("", g.Id) + ".page"
+	g.pnmlPage("", top)
+	doc := &pnmlDoc{
+		Xmlns: PNMLNamespace,
+		Nets:  []*pnmlNet{{Id: pnmlId("", g.Id), Type: PNMLNetType, Pages: []*pnmlPage{top}}},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (g *Graph) pnmlPage(path string, page *pnmlPage) {
+	arc := func(source, target string) {
+		page.Arcs = append(page.Arcs, &pnmlArc{
+			Id:     source + "-" + target,
+			Source: source,
+			Target: target,
+		})
+	}
+	for _, p := range g.Places {
+		node := &pnmlNode{
+			Id:       pnmlId(path, p.Id),
+			Name:     pnmlName(p.Label),
+			Graphics: pnmlAt(p.X, p.Y),
+		}
+		if p.Counter > 0 {
+			node.Marking = &pnmlText{strconv.Itoa(p.Counter)}
+		}
+		if tool := newPnmlTool(); p.Timer != 0 {
+			tool.Timer = p.Timer
+			node.Tools = append(node.Tools, tool)
+		}
+		page.Places = append(page.Places, node)
+	}
+	for _, t := range g.Transitions {
+		id := pnmlId(path, t.Id)
+		node := &pnmlNode{
+			Id:       id,
+			Name:     pnmlName(t.Label),
+			Graphics: pnmlAt(t.X, t.Y),
+		}
+		tool := newPnmlTool()
+		tool.Kind, tool.Horizontal = pnmlKinds[t.Kind], t.Horizontal
+		if !tool.empty() {
+			node.Tools = append(node.Tools, tool)
+		}
+		page.Transitions = append(page.Transitions, node)
+		for _, p := range t.In {
+			arc(pnmlId(path, p.Id), id)
+		}
+		for _, p := range t.Out {
+			arc(id, pnmlId(path, p.Id))
+		}
+	}
+	for _, gr := range g.Groups {
+		inner := path + "/" + gr.Id
+		sub := &pnmlPage{}
+		sub.Id = pnmlId(path, gr.Id)
+		sub.Name = pnmlName(gr.Label)
+		sub.Graphics = pnmlAt(gr.X, gr.Y)
+		if tool := newPnmlTool(); gr.Folded {
+			tool.Folded = true
+			sub.Tools = append(sub.Tools, tool)
+		}
+		gr.Graph.pnmlPage(inner, sub)
+		page.Pages = append(page.Pages, sub)
+		ref := func(t *Transition) string {
+			node := &pnmlNode{
+				Id:       pnmlId(path, t.Id),
+				Ref:      pnmlId(inner, t.Proxy.Id),
+				Name:     pnmlName(t.Label),
+				Graphics: pnmlAt(t.X, t.Y),
+			}
+			page.RefTransitions = append(page.RefTransitions, node)
+			return node.Id
+		}
+		for _, t := range gr.Inputs {
+			id := ref(t)
+			for _, p := range t.In {
+				arc(pnmlId(path, p.Id), id)
+			}
+		}
+		for _, t := range gr.Outputs {
+			id := ref(t)
+			for _, p := range t.Out {
+				arc(id, pnmlId(path, p.Id))
+			}
+		}
+	}
+}
+
+// pnmlReader builds a graph from the pages of a net,
+// the problems found on the way are reported together.
+type pnmlReader struct {
+	validator
+	places      map[string]*Place
+	transitions map[string]*Transition
+	refs        map[string]string
+	graphs      map[interface{}]*Graph
+	groups      []*Group
+	arcs        []*pnmlArc
+}
+
+func (r *pnmlReader) node(id, what string) bool {
+	if len(id) < 1 {
+		r.errorf("", "%s without id", what)
+		return false
+	}
+	if _, ok := r.places[id]; ok {
+		r.errorf("", "%s %s: duplicate id", what, id)
+		return false
+	}
+	if _, ok := r.transitions[id]; ok {
+		r.errorf("", "%s %s: duplicate id", what, id)
+		return false
+	}
+	if _, ok := r.refs[id]; ok {
+		r.errorf("", "%s %s: duplicate id", what, id)
+		return false
+	}
+	return true
+}
+
+func pnmlOwnTool(tools []*pnmlTool) *pnmlTool {
+	for _, tool := range tools {
+		if tool.Tool == PNMLTool {
+			return tool
+		}
+	}
+	return &pnmlTool{}
+}
+
+func pnmlPos(node *pnmlNode) (x, y float64) {
+	if node.Graphics != nil && node.Graphics.Position != nil {
+		return node.Graphics.Position.X, node.Graphics.Position.Y
+	}
+	return 0, 0
+}
+
+func pnmlLabel(node *pnmlNode) string {
+	if node.Name == nil {
+		return ""
+	}
+	return strings.TrimSpace(node.Name.Text)
+}
+
+// page reads the page into the graph, prefix is stripped
+// from the ids of the items written by WritePNML.
+func (r *pnmlReader) page(page *pnmlPage, g *Graph, prefix string) {
+	strip := func(id string) string {
+		if len(prefix) > 0 && strings.HasPrefix(id, prefix) {
+			return id[len(prefix):]
+		}
+		return id
+	}
+	for _, node := range page.Places {
+		if !r.node(node.Id, "place") {
+			continue
+		}
+		x, y := pnmlPos(node)
+		p := &Place{
+			Id:    strip(node.Id),
+			X:     x,
+			Y:     y,
+			Label: pnmlLabel(node),
+			Timer: pnmlOwnTool(node.Tools).Timer,
+		}
+		if node.Marking != nil {
+			n, err := strconv.Atoi(strings.TrimSpace(node.Marking.Text))
+			if err != nil || n < 0 {
+				r.errorf("", "place %s: invalid initial marking %q", node.Id, node.Marking.Text)
+			}
+			p.Counter = n
+		}
+		r.places[node.Id] = p
+		r.graphs[p] = g
+		g.Places = append(g.Places, p)
+	}
+	for _, node := range page.Transitions {
+		if !r.node(node.Id, "transition") {
+			continue
+		}
+		x, y := pnmlPos(node)
+		tool := pnmlOwnTool(node.Tools)
+		t := &Transition{
+			Id:         strip(node.Id),
+			X:          x,
+			Y:          y,
+			Label:      pnmlLabel(node),
+			Horizontal: tool.Horizontal,
+		}
+		for kind, name := range pnmlKinds {
+			if tool.Kind == name {
+				t.Kind = kind
+			}
+		}
+		r.transitions[node.Id] = t
+		r.graphs[t] = g
+		g.Transitions = append(g.Transitions, t)
+	}
+	for _, node := range append(page.RefPlaces, page.RefTransitions...) {
+		if r.node(node.Id, "reference") {
+			r.refs[node.Id] = node.Ref
+		}
+	}
+	r.arcs = append(r.arcs, page.Arcs...)
+	for _, sub := range page.Pages {
+		x, y := pnmlPos(&sub.pnmlNode)
+		gr := &Group{
+			Id:     strip(sub.Id),
+			X:      x,
+			Y:      y,
+			Label:  pnmlLabel(&sub.pnmlNode),
+			Folded: pnmlOwnTool(sub.Tools).Folded,
+			Graph:  &Graph{Id: util.GenUUID(), Parent: g},
+		}
+		g.Groups = append(g.Groups, gr)
+		r.groups = append(r.groups, gr)
+		r.page(sub, gr.Graph, sub.Id+".")
+	}
+}
+
+// resolve follows the references to the place or transition behind the id.
+func (r *pnmlReader) resolve(id string) (interface{}, bool) {
+	seen := make(map[string]bool)
+	for !seen[id] {
+		seen[id] = true
+		if p, ok := r.places[id]; ok {
+			return p, true
+		}
+		if t, ok := r.transitions[id]; ok {
+			return t, true
+		}
+		next, ok := r.refs[id]
+		if !ok {
+			return nil, false
+		}
+		id = next
+	}
+	return nil, false
+}
+
+// link adds the arc, a place of the parent page may be linked to the
+// transition of a group, the group gets an input or output for it.
+func (r *pnmlReader) link(a *pnmlArc) {
+	source, ok := r.resolve(a.Source)
+	if !ok {
+		r.errorf("", "arc %s: no such source %s", a.Id, a.Source)
+		return
+	}
+	target, ok := r.resolve(a.Target)
+	if !ok {
+		r.errorf("", "arc %s: no such target %s", a.Id, a.Target)
+		return
+	}
+	if a.Inscription != nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(a.Inscription.Text)); err != nil || n != 1 {
+			r.errorf("", "arc %s: weight %q, the arcs of an event graph have weight 1",
+				a.Id, strings.TrimSpace(a.Inscription.Text))
+			return
+		}
+	}
+	var p *Place
+	var t *Transition
+	inbound := false
+	switch source.(type) {
+	case *Place:
+		p, inbound = source.(*Place), true
+		if t, ok = target.(*Transition); !ok {
+			r.errorf("", "arc %s links two places", a.Id)
+			return
+		}
+	case *Transition:
+		t = source.(*Transition)
+		if p, ok = target.(*Place); !ok {
+			r.errorf("", "arc %s links two transitions", a.Id)
+			return
+		}
+	}
+	switch {
+	case inbound && p.Out != nil:
+		r.errorf("", "place %s has several output arcs, the net is not an event graph", p.Id)
+		return
+	case !inbound && p.In != nil:
+		r.errorf("", "place %s has several input arcs, the net is not an event graph", p.Id)
+		return
+	}
+	if gp, gt := r.graphs[p], r.graphs[t]; gp != gt && gt.Parent != gp {
+		r.errorf("", "arc %s: place %s and transition %s are not on the same page "+
+			"or on a page and a page right inside it", a.Id, p.Id, t.Id)
+		return
+	}
+	if inbound {
+		p.Out = t
+		t.In = append(t.In, p)
+	} else {
+		p.In = t
+		t.Out = append(t.Out, p)
+	}
+}
+
+// ReadPNML reads the first net of a PNML document. The nets which are not
+// event graphs are rejected, the errors of validation are *ValidationError.
+func ReadPNML(data []byte) (*Graph, error) {
+	doc := &pnmlDoc{}
+	if err := xml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Nets) < 1 {
+		return nil, fmt.Errorf("not a PNML document, no nets found")
+	}
+	net := doc.Nets[0]
+	r := &pnmlReader{
+		places:      make(map[string]*Place),
+		transitions: make(map[string]*Transition),
+		refs:        make(map[string]string),
+		graphs:      make(map[interface{}]*Graph),
+	}
+	if len(doc.Nets) > 1 {
+		r.errorf("", "%d nets in the document, expected one", len(doc.Nets))
+	}
+	g := &Graph{Id: net.Id}
+	for _, page := range net.Pages {
+		r.page(page, g, "")
+	}
+	for _, a := range r.arcs {
+		r.link(a)
+	}
+	for _, gr := range r.groups {
+		places := make(map[*Place]bool, len(gr.Graph.Places))
+		for _, p := range gr.Graph.Places {
+			places[p] = true
+		}
+		for _, t := range gr.Graph.Transitions {
+			if t.kindInGroup(places) == TransitionExposed {
+				r.errorf("", "transition %s is linked both inside and outside of page %s", t.Id, gr.Id)
+			}
+		}
+	}
+	if len(r.problems) > 0 {
+		return nil, &ValidationError{r.problems}
+	}
+	for _, gr := range r.groups {
+		gr.UpdateIO()
+	}
+	if err := g.Model().Validate(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
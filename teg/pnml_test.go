@@ -0,0 +1,37 @@
+package teg
+
+import "bytes"
+
+func (t *testSuite) TestPNMLRoundTrip() {
+	g, _, t1, t2, _ := chain()
+	t1.Out[0].Timer = 4
+	_, err := g.AddGroup([]*Place{t1.Out[0]}, []*Transition{t1, t2}, nil)
+	t.Nil(err)
+	var buf bytes.Buffer
+	t.Nil(WritePNML(&buf, g))
+	g2, err := ReadPNML(buf.Bytes())
+	t.Nil(err)
+	if err != nil {
+		return
+	}
+	t.Equal(1, len(g2.Groups))
+	t.Equal(1, len(g2.Groups[0].Inputs))
+	t.Equal(1, len(g2.Groups[0].Outputs))
+	t.Equal(g.TransferReport().String(), g2.TransferReport().String())
+	t.Equal(g.Groups[0].Graph.Places[0].Id, g2.Groups[0].Graph.Places[0].Id)
+}
+
+func (t *testSuite) TestPNMLNotEventGraph() {
+	_, err := ReadPNML([]byte(`<pnml><net id="n" type="x"><page id="top">
+		<place id="p"><initialMarking><text>1</text></initialMarking></place>
+		<transition id="a"/><transition id="b"/>
+		<arc id="1" source="p" target="a"/>
+		<arc id="2" source="p" target="b"/>
+		<arc id="3" source="a" target="p"><inscription><text>2</text></inscription></arc>
+	</page></net></pnml>`))
+	verr, ok := err.(*ValidationError)
+	t.True(ok)
+	if ok {
+		t.Equal(2, len(verr.Problems))
+	}
+}
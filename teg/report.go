@@ -0,0 +1,301 @@
+package teg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+// Formats lists the formats Export understands.
+var Formats = []string{"json", "pnml", "latex", "dot"}
+
+// Export writes the graph in the format, json is the document
+// of the current version like the editor saves it, latex gives
+// just the series of the planes and dot is for Graphviz.
+func (g *Graph) Export(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		return Encode(w, g.Model())
+	case "pnml":
+		return WritePNML(w, g)
+	case "latex":
+		return WriteLatex(w, g)
+	case "dot":
+		return WriteDOT(w, g)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// TransferReport is the transfer matrix H of the graph,
+// rows are indexed by outputs and columns by inputs.
+type TransferReport struct {
+	Inputs  []string
+	Outputs []string
+	H       [][]string
+}
+
+func (g *Graph) TransferReport() *TransferReport {
+	labels := g.Labels()
+	h, inputs, outputs := g.Transfer()
+	r := &TransferReport{H: make([][]string, len(outputs))}
+	for _, t := range inputs {
+		r.Inputs = append(r.Inputs, labels[Ref{"", t.Id}])
+	}
+	for i, t := range outputs {
+		r.Outputs = append(r.Outputs, labels[Ref{"", t.Id}])
+		r.H[i] = make([]string, len(inputs))
+		for j := range inputs {
+			r.H[i][j] = h[i][j].String()
+		}
+	}
+	return r
+}
+
+func (r *TransferReport) String() string {
+	if len(r.Inputs) < 1 || len(r.Outputs) < 1 {
+		return "No inputs or outputs\n"
+	}
+	var buf bytes.Buffer
+	for i, y := range r.Outputs {
+		for j, u := range r.Inputs {
+			fmt.Fprintf(&buf, "H[%s, %s] = %s\n", y, u, r.H[i][j])
+		}
+	}
+	return buf.String()
+}
+
+// ControllerReport is the controller of Synthesize, rows are indexed by
+// the inputs u, the columns of P by the new inputs v and those of F by
+// the outputs. P or F is left out when it was not asked for.
+type ControllerReport struct {
+	Inputs  []string
+	Outputs []string
+	P       [][]string `json:",omitempty"`
+	F       [][]string `json:",omitempty"`
+}
+
+func (c *Controller) Report() *ControllerReport {
+	strs := func(m dioid.Matrix) (list [][]string) {
+		for i := range m {
+			list = append(list, make([]string, len(m[i])))
+			for j := range m[i] {
+				list[i][j] = m[i][j].String()
+			}
+		}
+		return
+	}
+	return &ControllerReport{
+		Inputs:  c.Inputs,
+		Outputs: c.Outputs,
+		P:       strs(c.P),
+		F:       strs(c.F),
+	}
+}
+
+func (r *ControllerReport) String() string {
+	var buf bytes.Buffer
+	for i, row := range r.P {
+		for j, s := range row {
+			fmt.Fprintf(&buf, "P[%s, v %s] = %s\n", r.Inputs[i], r.Inputs[j], s)
+		}
+	}
+	for i, row := range r.F {
+		for j, s := range row {
+			fmt.Fprintf(&buf, "F[%s, %s] = %s\n", r.Inputs[i], r.Outputs[j], s)
+		}
+	}
+	return buf.String()
+}
+
+// CycleReport is the cycle time of the graph, circuits are
+// listed by the labels of their transitions.
+type CycleReport struct {
+	Text      string
+	Defined   bool
+	Timer     int
+	Counter   int
+	Value     float64    `json:",omitempty"`
+	Critical  []string   `json:",omitempty"`
+	Deadlocks [][]string `json:",omitempty"`
+}
+
+func (g *Graph) CycleReport() *CycleReport {
+	labels := g.Labels()
+	circuit := func(refs []Ref) (list []string) {
+		for _, r := range refs {
+			list = append(list, labels[r])
+		}
+		return
+	}
+	ct := g.CycleTime()
+	r := &CycleReport{
+		Text:     ct.String(),
+		Defined:  ct.Defined(),
+		Timer:    ct.Timer,
+		Counter:  ct.Counter,
+		Critical: circuit(ct.Critical),
+	}
+	if ct.Defined() {
+		r.Value = ct.Value()
+	}
+	for _, c := range ct.Deadlocks {
+		r.Deadlocks = append(r.Deadlocks, circuit(c))
+	}
+	return r
+}
+
+func (r *CycleReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, r.Text)
+	if len(r.Critical) > 0 {
+		fmt.Fprintf(&buf, "Critical circuit: %s\n", strings.Join(r.Critical, " → "))
+	}
+	for _, c := range r.Deadlocks {
+		fmt.Fprintf(&buf, "Circuit without tokens: %s\n", strings.Join(c, " → "))
+	}
+	return buf.String()
+}
+
+// Dates are the daters of a transition, the events which never
+// happen are ∞ and the unconstrained ones are ε.
+type Dates []int
+
+func (d Dates) MarshalJSON() ([]byte, error) {
+	list := make([]interface{}, len(d))
+	for k, x := range d {
+		if x == DaterInf || x == DaterEps {
+			list[k] = DaterString(x)
+		} else {
+			list[k] = x
+		}
+	}
+	return json.Marshal(list)
+}
+
+// DaterReport holds the earliest firing dates of the first events
+// of every transition, see Daters. Refs are the transitions.
+type DaterReport struct {
+	Events      int
+	Transitions []string
+	Dates       []Dates
+	Refs        []Ref `json:"-"`
+}
+
+// DaterReport takes the series of the planes of the inputs as u.
+func (g *Graph) DaterReport(n int) *DaterReport {
+	labels := g.Labels()
+	u := make(map[string][]int)
+	for _, t := range g.Transitions {
+		if info := g.Info(t.Id); info != nil && t.Kind == TransitionInput {
+			u[t.Id] = SerieDaters(info.Serie, n)
+		}
+	}
+	refs, x := g.Daters(u, n)
+	r := &DaterReport{Events: n}
+	for _, ref := range refs {
+		label, ok := labels[ref]
+		if !ok {
+			continue
+		}
+		r.Transitions = append(r.Transitions, label)
+		r.Dates = append(r.Dates, x[ref])
+		r.Refs = append(r.Refs, ref)
+	}
+	return r
+}
+
+func (r *DaterReport) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "k\t")
+	for k := 0; k < r.Events; k++ {
+		fmt.Fprintf(w, "%d\t", k)
+	}
+	fmt.Fprintln(w)
+	for i, label := range r.Transitions {
+		dates := make([]string, len(r.Dates[i]))
+		for k, d := range r.Dates[i] {
+			dates[k] = DaterString(d)
+		}
+		fmt.Fprintf(w, "%s\t%s\t\n", label, strings.Join(dates, "\t"))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// SimState is the state of the token game after a step, the places
+// holding as many tokens as needed are listed in Flooded.
+type SimState struct {
+	Clock   int
+	Fired   []string
+	Tokens  map[string]int
+	Flooded []string `json:",omitempty"`
+}
+
+// SimReport is the token game played from the initial marking,
+// it stops early when no transition may fire anymore. That is
+// a deadlock unless every transition fires without limit.
+type SimReport struct {
+	Steps    []*SimState
+	Deadlock bool
+}
+
+func (g *Graph) Simulate(steps int) *SimReport {
+	labels := g.Labels()
+	places := g.PlaceLabels()
+	s := NewSimulation(g)
+	r := &SimReport{}
+	for i := 0; i < steps; i++ {
+		if !s.Step() {
+			r.Deadlock = !s.Saturated()
+			break
+		}
+		step := &SimState{Clock: s.Clock, Tokens: make(map[string]int, len(places))}
+		for ref, label := range labels {
+			if s.Fired(ref) {
+				step.Fired = append(step.Fired, label)
+			}
+		}
+		sort.Strings(step.Fired)
+		for ref, label := range places {
+			step.Tokens[label], _ = s.Count(ref)
+			if s.Flooded(ref) {
+				step.Flooded = append(step.Flooded, label)
+			}
+		}
+		sort.Strings(step.Flooded)
+		r.Steps = append(r.Steps, step)
+	}
+	return r
+}
+
+func (r *SimReport) String() string {
+	var buf bytes.Buffer
+	for _, step := range r.Steps {
+		flooded := make(map[string]bool, len(step.Flooded))
+		for _, label := range step.Flooded {
+			flooded[label] = true
+		}
+		tokens := make([]string, 0, len(step.Tokens))
+		for label, n := range step.Tokens {
+			if flooded[label] {
+				tokens = append(tokens, label+"=∞")
+			} else {
+				tokens = append(tokens, fmt.Sprintf("%s=%d", label, n))
+			}
+		}
+		sort.Strings(tokens)
+		fmt.Fprintf(&buf, "t = %d\tfired %s\t%s\n", step.Clock,
+			strings.Join(step.Fired, ", "), strings.Join(tokens, ", "))
+	}
+	if r.Deadlock {
+		fmt.Fprintln(&buf, "Deadlock, no transition may fire")
+	}
+	return buf.String()
+}
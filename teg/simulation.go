@@ -0,0 +1,360 @@
+package teg
+
+import (
+	"sort"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+// simPlace holds the tokens of a place, each token is known
+// by the time it becomes available to the output transition.
+// The tokens of every copy of a shared graph are kept apart.
+// From the instant flood on the place holds as many tokens
+// as needed, it is DaterInf when that never happens.
+type simPlace struct {
+	Ref
+	p      *Place
+	tokens []int
+	to     *simTransition
+	flood  int
+}
+
+// ready gives the earliest instant the place has a token available.
+func (sp *simPlace) ready() (int, bool) {
+	at := sp.flood
+	if len(sp.tokens) > 0 && sp.tokens[0] < at {
+		at = sp.tokens[0]
+	}
+	return at, at != DaterInf
+}
+
+func (sp *simPlace) available(clock int) bool {
+	at, ok := sp.ready()
+	return ok && at <= clock
+}
+
+// simTransition is a transition of the token game. It fires without limit
+// from the instant flood on, like the places it is the input transition of.
+// A source fires its k-th event at dates[k] of the serie of its plane, the
+// dates of a growing serie are extended on demand.
+type simTransition struct {
+	node
+	in     []*simPlace
+	out    []*simPlace
+	flood  int
+	source bool
+	serie  *dioid.Serie
+	dates  []int
+	events int
+}
+
+// schedule sets the dates of a source from the serie of its plane. When the
+// serie does not grow the dates get constant after a while, the source fires
+// without limit from then on.
+func (t *simTransition) schedule(s dioid.Serie) {
+	if !s.Q.IsEps() && !s.R.IsE() && s.R.G >= 1 && s.R.G != dioid.Inf &&
+		s.R.D > 0 && s.R.D != dioid.Inf {
+		t.serie = &s
+		return
+	}
+	last := 0
+	list := s.P
+	if !s.Q.IsEps() {
+		list = append(append(dioid.Poly{}, s.P...), s.Q...)
+	}
+	for _, m := range list {
+		if !m.IsEps() && m.G != dioid.Inf && m.G > last {
+			last = m.G
+		}
+	}
+	x := SerieDaters(s, last+1)
+	t.dates, t.flood = x[:last], x[last]
+}
+
+// date gives the date of the next event of a source.
+func (t *simTransition) date() (int, bool) {
+	if t.events >= len(t.dates) && t.serie != nil {
+		t.dates = SerieDaters(*t.serie, 2*len(t.dates)+DaterEvents)
+	}
+	if t.events >= len(t.dates) || t.dates[t.events] == DaterInf {
+		return 0, false
+	}
+	return t.dates[t.events], true
+}
+
+// enabled tells whether t may fire at the clock, a transition
+// firing without limit has no events to play anymore.
+func (t *simTransition) enabled(clock int) bool {
+	if t.flood <= clock {
+		return false
+	}
+	if t.source {
+		at, ok := t.date()
+		return ok && at <= clock
+	}
+	for _, sp := range t.in {
+		if !sp.available(clock) {
+			return false
+		}
+	}
+	return true
+}
+
+// next returns the earliest instant after the clock when t gets
+// enabled with the tokens already in its places.
+func (t *simTransition) next(clock int) (int, bool) {
+	at := clock + 1
+	if t.source {
+		d, ok := t.date()
+		if !ok {
+			return 0, false
+		}
+		if d > at {
+			at = d
+		}
+	}
+	for _, sp := range t.in {
+		r, ok := sp.ready()
+		if !ok {
+			return 0, false
+		}
+		if r > at {
+			at = r
+		}
+	}
+	return at, at < t.flood
+}
+
+// Simulation plays the token game on a graph. Initial tokens are available
+// at the instant zero, a token that enters a place becomes available
+// once the place's timer has elapsed. Places have only one output
+// transition so the enabled transitions never compete for tokens.
+// Like in Daters the inputs fire at the dates given by the series of their
+// planes, the sources which are not driven that way are not constrained
+// at all: they and what they alone lead to fire without limit at once.
+type Simulation struct {
+	Graph *Graph
+	Clock int
+	Steps int
+
+	places      map[Ref]*simPlace
+	transitions []*simTransition
+	marking     *Marking
+}
+
+// Marking is the state of the token game after a step. Every step makes
+// a new one, so a marking may be read while the next step is computed.
+type Marking struct {
+	Clock int
+
+	counts  map[Ref]int
+	changed map[Ref]bool
+	fired   map[Ref]bool
+	flooded map[Ref]bool
+}
+
+func NewSimulation(g *Graph) *Simulation {
+	s := &Simulation{Graph: g}
+	s.Reset()
+	return s
+}
+
+// Reset restores the initial marking of the graph.
+func (s *Simulation) Reset() {
+	s.Clock, s.Steps = 0, 0
+	s.places = make(map[Ref]*simPlace)
+	s.transitions = nil
+	nodes := make(map[node]*simTransition)
+	transition := func(n node) *simTransition {
+		t, ok := nodes[n]
+		if !ok {
+			t = &simTransition{node: n, flood: DaterInf}
+			nodes[n] = t
+			s.transitions = append(s.transitions, t)
+		}
+		return t
+	}
+	var build func(g *Graph, path string)
+	build = func(g *Graph, path string) {
+		for _, t := range g.Transitions {
+			transition(node{path, t})
+		}
+		for _, p := range g.Places {
+			sp := &simPlace{Ref: Ref{path, p.Id}, p: p, flood: DaterInf}
+			sp.tokens = make([]int, p.Counter)
+			s.places[sp.Ref] = sp
+			if p.In != nil {
+				t := transition(p.In.node(path))
+				t.out = append(t.out, sp)
+			}
+			if p.Out != nil {
+				sp.to = transition(p.Out.node(path))
+				sp.to.in = append(sp.to.in, sp)
+			}
+		}
+		for _, gr := range g.Groups {
+			build(gr.Graph, path+"/"+gr.Id)
+		}
+	}
+	build(s.Graph, "")
+
+	for _, t := range s.transitions {
+		if len(t.in) > 0 {
+			continue
+		}
+		t.source = true
+		if info := s.Graph.Info(t.t.Id); info != nil && t.path == "" && t.t.Kind == TransitionInput {
+			t.schedule(info.Serie)
+		} else {
+			t.flood = DaterEps
+		}
+	}
+	// what the flooded places alone lead to gets flooded as well,
+	// the circuits never do as they wait for themselves
+	for changed := true; changed; {
+		changed = false
+		for _, t := range s.transitions {
+			if !t.source {
+				flood := DaterEps
+				for _, sp := range t.in {
+					if sp.flood > flood {
+						flood = sp.flood
+					}
+				}
+				if flood < t.flood {
+					t.flood, changed = flood, true
+				}
+			}
+			for _, sp := range t.out {
+				if flood := daterPlus(t.flood, sp.p.Timer); flood < sp.flood {
+					sp.flood, changed = flood, true
+				}
+			}
+		}
+	}
+	s.publish(nil, nil)
+}
+
+// Step fires every transition enabled at the current instant. When none
+// is enabled the clock first advances to the instant when one gets enabled,
+// Step returns false if there is no such instant: the graph is deadlocked
+// unless it is Saturated.
+func (s *Simulation) Step() bool {
+	enabled := s.enabled()
+	if len(enabled) == 0 {
+		next, ok := 0, false
+		for _, t := range s.transitions {
+			if at, ok2 := t.next(s.Clock); ok2 && (!ok || at < next) {
+				next, ok = at, true
+			}
+		}
+		if !ok {
+			return false
+		}
+		s.Clock = next
+		enabled = s.enabled()
+	}
+	changed := make(map[Ref]bool)
+	fired := make(map[Ref]bool, len(enabled))
+	for _, t := range enabled {
+		for _, sp := range t.in {
+			// a flooded place gives its token without losing any
+			if len(sp.tokens) > 0 && sp.tokens[0] <= s.Clock {
+				sp.tokens = sp.tokens[1:]
+				changed[sp.Ref] = true
+			}
+		}
+		for _, sp := range t.out {
+			sp.tokens = append(sp.tokens, s.Clock+sp.p.Timer)
+			sort.Ints(sp.tokens)
+			changed[sp.Ref] = true
+		}
+		if t.source {
+			t.events++
+		}
+		fired[t.ref()] = true
+	}
+	s.Steps++
+	s.publish(changed, fired)
+	return true
+}
+
+func (s *Simulation) enabled() (list []*simTransition) {
+	for _, t := range s.transitions {
+		if t.enabled(s.Clock) {
+			list = append(list, t)
+		}
+	}
+	return
+}
+
+func (s *Simulation) publish(changed, fired map[Ref]bool) {
+	counts := make(map[Ref]int, len(s.places))
+	flooded := make(map[Ref]bool)
+	for m, sp := range s.places {
+		counts[m] = len(sp.tokens)
+		if sp.flood <= s.Clock {
+			flooded[m] = true
+		}
+	}
+	s.marking = &Marking{s.Clock, counts, changed, fired, flooded}
+}
+
+// Saturated tells whether every transition fires without limit by now,
+// the token game has no events left then although it is not deadlocked.
+func (s *Simulation) Saturated() bool {
+	for _, t := range s.transitions {
+		if t.flood > s.Clock {
+			return false
+		}
+	}
+	return true
+}
+
+// Marking returns the state after the last step.
+func (s *Simulation) Marking() *Marking {
+	return s.marking
+}
+
+// Count returns the tokens held by the place after the last step.
+func (s *Simulation) Count(place Ref) (int, bool) {
+	return s.marking.Count(place)
+}
+
+// Flooded tells whether the place is flooded after the last step.
+func (s *Simulation) Flooded(place Ref) bool {
+	return s.marking.Flooded(place)
+}
+
+// Changed tells whether the last step moved tokens into or out of the place.
+func (s *Simulation) Changed(place Ref) bool {
+	return s.marking.Changed(place)
+}
+
+// Fired tells whether the transition fired on the last step.
+func (s *Simulation) Fired(transition Ref) bool {
+	return s.marking.Fired(transition)
+}
+
+// Count returns the tokens held by the place, the ones still waiting
+// for the timer included. It is false for the places it does not know.
+func (m *Marking) Count(place Ref) (int, bool) {
+	n, ok := m.counts[place]
+	return n, ok
+}
+
+// Flooded tells whether the place holds as many tokens as needed,
+// Count gives only the ones held besides.
+func (m *Marking) Flooded(place Ref) bool {
+	return m.flooded[place]
+}
+
+// Changed tells whether the step moved tokens into or out of the place.
+func (m *Marking) Changed(place Ref) bool {
+	return m.changed[place]
+}
+
+// Fired tells whether the transition fired on the step.
+func (m *Marking) Fired(transition Ref) bool {
+	return m.fired[transition]
+}
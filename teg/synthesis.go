@@ -0,0 +1,158 @@
+package teg
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+// Controller is the control of a graph by a precompensator P from the new
+// inputs v to the inputs u and by an output feedback F from the outputs y,
+// u = P(v ⊕ F y). Rows of P and F are indexed by the inputs, the columns
+// of F by the outputs. Either may be nil when it is left out.
+type Controller struct {
+	P, F            dioid.Matrix
+	Inputs, Outputs []string
+}
+
+// Synthesize computes the greatest causal controller which keeps the transfer
+// H of the graph, H P (F H P)* = H, so that the inputs are delayed as late
+// as possible. P is the causal projection of H\H and F the one of HP\HP/HP.
+// It fails when the series cannot be realized by a graph, that happens when
+// an input reaches no output.
+func (g *Graph) Synthesize(precompensator, feedback bool) (*Controller, error) {
+	if !precompensator && !feedback {
+		return nil, errors.New("nothing to synthesize, neither a precompensator nor a feedback")
+	}
+	h, inputs, outputs := g.Transfer()
+	if len(inputs) < 1 || len(outputs) < 1 {
+		return nil, errors.New("the model has no inputs or no outputs")
+	}
+	labels := g.Labels()
+	c := &Controller{}
+	for _, t := range inputs {
+		c.Inputs = append(c.Inputs, labels[Ref{"", t.Id}])
+	}
+	for _, t := range outputs {
+		c.Outputs = append(c.Outputs, labels[Ref{"", t.Id}])
+	}
+	if precompensator {
+		c.P = dioid.MatrixCausalProjection(dioid.MatrixLeftDiv(h, h))
+		if err := realizable("P", c.P, c.Inputs, c.Inputs); err != nil {
+			return nil, err
+		}
+		h = dioid.MatrixOtimes(h, c.P)
+	}
+	if feedback {
+		c.F = dioid.MatrixCausalProjection(dioid.MatrixRightDiv(dioid.MatrixLeftDiv(h, h), h))
+		if err := realizable("F", c.F, c.Inputs, c.Outputs); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// realizable checks that every entry of the matrix is a causal periodic serie,
+// the entries are named by the labels of their rows and columns.
+func realizable(name string, m dioid.Matrix, rows, cols []string) error {
+	finite := func(x dioid.Gd) bool {
+		return x.G >= 0 && x.D >= 0 && x.G != dioid.Inf && x.D != dioid.Inf
+	}
+	for i := range m {
+		for j, s := range m[i] {
+			ok := true
+			for _, x := range append(append(dioid.Poly{}, s.P...), s.Q...) {
+				if !x.IsEps() && !finite(x) {
+					ok = false
+				}
+			}
+			if !s.Q.IsEps() && !s.R.IsE() && (!finite(s.R) || s.R.G < 1) {
+				ok = false
+			}
+			if !ok {
+				return fmt.Errorf("the controller cannot be realized, %s[%s, %s] = %s",
+					name, rows[i], cols[j], s)
+			}
+		}
+	}
+	return nil
+}
+
+// Graph realizes the controller, its inputs are v and y and its outputs
+// are to be linked to u. A monomial γ^n δ^t of an entry becomes a place of
+// n tokens held for t units of time, the periodic part is a circuit.
+func (c *Controller) Graph() *Graph {
+	g := New()
+	transition := func(kind int, label string) *Transition {
+		t := g.AddTransition(0, 0)
+		t.Kind, t.Label = kind, label
+		return t
+	}
+	link := func(from, to *Transition, x dioid.Gd) {
+		p := g.AddPlace(0, 0)
+		p.Counter, p.Timer = x.G, x.D
+		from.Link(p, false)
+		to.Link(p, true)
+	}
+	realize := func(from, to *Transition, s dioid.Serie) {
+		for _, x := range s.P {
+			if !x.IsEps() {
+				link(from, to, x)
+			}
+		}
+		if s.Q.IsEps() {
+			return
+		}
+		if s.R.IsE() {
+			for _, x := range s.Q {
+				if !x.IsEps() {
+					link(from, to, x)
+				}
+			}
+			return
+		}
+		// x = Q from ⊕ R x gives x = R* Q from
+		x1, x2 := g.AddTransition(0, 0), g.AddTransition(0, 0)
+		for _, x := range s.Q {
+			if !x.IsEps() {
+				link(from, x1, x)
+			}
+		}
+		link(x1, x2, s.R)
+		link(x2, x1, dioid.E)
+		link(x1, to, dioid.E)
+	}
+
+	v := make([]*Transition, len(c.Inputs))
+	for j, label := range c.Inputs {
+		v[j] = transition(TransitionInput, "v "+label)
+	}
+	w := v
+	if c.F != nil {
+		y := make([]*Transition, len(c.Outputs))
+		for i, label := range c.Outputs {
+			y[i] = transition(TransitionInput, label)
+		}
+		w = make([]*Transition, len(c.Inputs))
+		for j := range c.Inputs {
+			w[j] = g.AddTransition(0, 0)
+			link(v[j], w[j], dioid.E)
+			for i := range c.Outputs {
+				realize(y[i], w[j], c.F[j][i])
+			}
+		}
+	}
+	for k, label := range c.Inputs {
+		u := transition(TransitionOutput, label)
+		if c.P == nil {
+			link(w[k], u, dioid.E)
+			continue
+		}
+		for j := range c.Inputs {
+			realize(w[j], u, c.P[k][j])
+		}
+	}
+	g.Layout()
+	return g
+}
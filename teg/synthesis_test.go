@@ -0,0 +1,39 @@
+package teg
+
+import (
+	"fmt"
+
+	"github.com/xlab/teg-workshop/dioid"
+)
+
+func (t *testSuite) TestSynthesize() {
+	g, _, _, _, _ := sample()
+	h, _, _ := g.Transfer()
+	c, err := g.Synthesize(true, true)
+	t.Nil(err)
+	t.Equal("[u]", fmt.Sprint(c.Inputs))
+	t.Equal("[y]", fmt.Sprint(c.Outputs))
+	t.Equal("(gd^5)*", c.P[0][0].String())
+	t.Equal("d^2x(gd^5)*", c.F[0][0].String())
+	// the controlled graph keeps the transfer
+	hp := dioid.MatrixOtimes(h, c.P)
+	closed := dioid.MatrixOtimes(hp, dioid.MatrixStar(dioid.MatrixOtimes(c.F, hp)))
+	t.Equal(h.String(), closed.String())
+
+	_, err = g.Synthesize(false, false)
+	t.Not(err == nil)
+	_, err = New().Synthesize(true, false)
+	t.Not(err == nil)
+}
+
+func (t *testSuite) TestControllerGraph() {
+	g, _, _, _, _ := sample()
+	c, err := g.Synthesize(true, true)
+	t.Nil(err)
+	h, inputs, outputs := c.Graph().Transfer()
+	t.Equal(2, len(inputs))
+	t.Equal(1, len(outputs))
+	// u = P v ⊕ P F y
+	t.Equal(c.P[0][0].String(), h[0][0].String())
+	t.Equal(dioid.SerieOtimes(c.P[0][0], c.F[0][0]).String(), h[0][1].String())
+}
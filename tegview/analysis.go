@@ -0,0 +1,255 @@
+package tegview
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/xlab/teg-workshop/dioid"
+	"github.com/xlab/teg-workshop/planeview"
+	core "github.com/xlab/teg-workshop/teg"
+)
+
+var serieEps = dioid.Serie{P: dioid.Poly{dioid.Eps}, Q: dioid.Poly{dioid.Eps}, R: dioid.E}
+
+// graphCache keeps the graph converted from a teg along with
+// the signature of the teg at the time.
+type graphCache struct {
+	sync.Mutex
+	sig   string
+	graph *core.Graph
+}
+
+// graph converts the teg for the analyses of the core package, the analyses
+// name the items by refs of the same ids and paths. The graph is converted
+// anew only when the signature of the teg changes, it must not be modified.
+func (tg *teg) graph() (*core.Graph, error) {
+	sig := tg.signature()
+	tg.built.Lock()
+	defer tg.built.Unlock()
+	if tg.built.graph != nil && tg.built.sig == sig {
+		return tg.built.graph, nil
+	}
+	g, err := core.Build(tg.Model(false))
+	if err != nil {
+		return nil, fmt.Errorf("The model cannot be analysed: %v", err)
+	}
+	tg.built.sig, tg.built.graph = sig, g
+	return g, nil
+}
+
+// signature sums up what the analyses see of the teg, the positions
+// of the items are left out.
+func (tg *teg) signature() string {
+	var buf bytes.Buffer
+	tg.sign(&buf)
+	for _, t := range tg.transitions {
+		if info, ok := tg.infos[t.net.Id]; ok {
+			fmt.Fprintf(&buf, "i%s:%v:%v:%s;", t.net.Id, info.Enabled(), info.IsReference(), info.Dioid())
+		}
+	}
+	return buf.String()
+}
+
+func (tg *teg) sign(buf *bytes.Buffer) {
+	id := func(t *transition) string {
+		if t == nil {
+			return ""
+		}
+		return t.net.Id
+	}
+	signTransition := func(t *transition) {
+		fmt.Fprintf(buf, "t%s:%d:%q:%s;", t.net.Id, t.net.Kind, t.net.Label, id(t.proxy))
+	}
+	for _, t := range tg.transitions {
+		signTransition(t)
+	}
+	for _, p := range tg.places {
+		fmt.Fprintf(buf, "p%s:%d:%d:%q:%s:%s;", p.net.Id, p.net.Counter, p.net.Timer, p.net.Label, id(p.in), id(p.out))
+	}
+	for _, g := range tg.groups {
+		fmt.Fprintf(buf, "g%s:%q{", g.net.Id, g.net.Label)
+		for _, t := range g.inputs {
+			signTransition(t)
+		}
+		for _, t := range g.outputs {
+			signTransition(t)
+		}
+		if g.model != nil {
+			g.model.sign(buf)
+		}
+		buf.WriteString("}")
+	}
+}
+
+// fail hands an error of the analyses over to the view, the same
+// error is handed over once until the analyses work again.
+func (tg *teg) fail(err error) {
+	var text string
+	if err != nil {
+		text = err.Error()
+	}
+	if text == tg.failure {
+		return
+	}
+	tg.failure = text
+	if err != nil {
+		select {
+		case tg.failures <- err:
+		default:
+		}
+	}
+}
+
+// ref returns the real transition behind t like the analyses know it,
+// the proxies of a group lead into the model of the group.
+func (t *transition) ref(path string) core.Ref {
+	for t.proxy != nil {
+		path += "/" + t.group.net.Id
+		t = t.proxy
+	}
+	return core.Ref{Path: path, Id: t.net.Id}
+}
+
+func (p *place) ref(path string) core.Ref {
+	return core.Ref{Path: path, Id: p.net.Id}
+}
+
+// transferSignature tells when the planes are to be computed anew, it takes
+// the series of the inputs and the references of the outputs along the graph.
+func (tg *teg) transferSignature(g *core.Graph) string {
+	sig := g.Signature()
+	for _, t := range tg.transitions {
+		if info, ok := tg.infos[t.net.Id]; ok && (info.IsInput() || info.IsReference()) {
+			sig += "\n" + t.net.Id + " " + info.Dioid().String()
+		}
+	}
+	return sig
+}
+
+// updateTransfer computes the output planes from the input planes, y = H ⊗ u
+// where H is the transfer matrix and u holds the series of the input planes.
+// When some outputs hold references z the inputs are computed first as the
+// latest ones, u = H \ z, see core.JustInTime. It runs again when the graph,
+// a serie of the inputs or a reference changes. The cycle time depends on
+// the graph and is updated along.
+func (tg *teg) updateTransfer() (updated bool) {
+	g, err := tg.graph()
+	tg.fail(err)
+	if err != nil {
+		return false
+	}
+	if tg.transferSignature(g) == tg.transferSig {
+		return false
+	}
+	cycle := g.CycleTime()
+	if tg.cycle == nil || tg.cycle.String() != cycle.String() {
+		updated = true
+	}
+	tg.cycle = cycle
+	refs := make(map[string]dioid.Serie)
+	for id, info := range tg.infos {
+		if info.IsReference() {
+			refs[id] = info.Dioid()
+		}
+	}
+	for id, serie := range g.JustInTime(refs) {
+		if info, ok := tg.infos[id]; ok && info.Dioid().String() != serie.String() {
+			info.SetDioid(serie)
+			updated = true
+		}
+	}
+	h, inputs, outputs := g.Transfer()
+	u := make([]dioid.Serie, len(inputs))
+	for j, t := range inputs {
+		u[j] = serieEps
+		if info, ok := tg.infos[t.Id]; ok {
+			if s := info.Dioid(); s.P != nil || s.Q != nil {
+				u[j] = s
+			}
+		}
+	}
+	for i, t := range outputs {
+		info, ok := tg.infos[t.Id]
+		if !ok || info.IsReference() {
+			continue
+		}
+		y := serieEps
+		for j := range inputs {
+			y = dioid.SerieOplus(y, dioid.SerieOtimes(h[i][j], u[j]))
+		}
+		if info.Dioid().String() != y.String() {
+			info.SetDioid(y)
+			updated = true
+		}
+	}
+	// the inputs may have changed by now
+	tg.transferSig = tg.transferSignature(g)
+	return
+}
+
+// controllerPlanes lists the series of the controller,
+// the planes are named like the entries of core.ControllerReport.
+func controllerPlanes(c *core.Controller) (planes []*planeview.Plane) {
+	add := func(name, row, col string, serie dioid.Serie) {
+		label := fmt.Sprintf("%s[%s, %s]", name, row, col)
+		plane := planeview.NewPlane(name+"/"+row+"/"+col, label, false)
+		plane.SetColor(PlaneColors[len(planes)%9])
+		plane.SetDioid(serie)
+		planes = append(planes, plane)
+	}
+	for i := range c.P {
+		for j, s := range c.P[i] {
+			add("P", c.Inputs[i], "v "+c.Inputs[j], s)
+		}
+	}
+	for i := range c.F {
+		for j, s := range c.F[i] {
+			add("F", c.Inputs[i], c.Outputs[j], s)
+		}
+	}
+	return
+}
+
+// simulation plays the token game on the teg being edited,
+// the teg is converted when the simulation starts.
+type simulation struct {
+	*core.Simulation
+	model *teg
+	sig   string
+}
+
+func newSimulation(tg *teg) (*simulation, error) {
+	s := &simulation{model: tg}
+	if err := s.reset(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reset restores the initial marking of the teg.
+func (s *simulation) reset() error {
+	g, err := s.model.graph()
+	if err != nil {
+		return err
+	}
+	s.sig = g.Signature()
+	s.Simulation = core.NewSimulation(g)
+	return nil
+}
+
+// stale tells whether the teg has been edited since the simulation started,
+// a teg which cannot be converted anymore is stale as well.
+func (s *simulation) stale() bool {
+	g, err := s.model.graph()
+	return err != nil || s.sig != g.Signature()
+}
+
+// simCount returns the tokens the marking gives to the place,
+// the ones still waiting for the timer included.
+func simCount(m *core.Marking, path string, p *place) int {
+	if n, ok := m.Count(p.ref(path)); ok {
+		return n
+	}
+	return p.net.Counter
+}
@@ -0,0 +1,28 @@
+package tegview
+
+func (t *testSuite) TestGraphCache() {
+	tg, _, p, _ := chain()
+	g1, err := tg.graph()
+	t.Nil(err)
+	g2, _ := tg.graph()
+	t.True(g1 == g2)
+	// moving an item keeps the graph, editing it does not
+	p.Shift(10, 10)
+	g2, _ = tg.graph()
+	t.True(g1 == g2)
+	p.net.Counter = 2
+	g2, _ = tg.graph()
+	t.False(g1 == g2)
+	t.Equal(2, g2.Places[0].Counter)
+}
+
+func (t *testSuite) TestGraphFailure() {
+	tg, _, p, _ := chain()
+	p.net.Id = ""
+	_, err := tg.graph()
+	t.Not(err == nil)
+	// the planes are left alone and the error is handed over once
+	t.False(tg.updateTransfer())
+	t.False(tg.updateTransfer())
+	t.Equal(1, len(tg.failures))
+}
@@ -1,16 +1,24 @@
+//go:build !headless
+// +build !headless
+
 package tegview
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"math"
+	"os"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/xlab/teg-workshop/geometry"
 	"github.com/xlab/teg-workshop/planeview"
-	"github.com/xlab/teg-workshop/util"
+	"github.com/xlab/teg-workshop/render"
+	core "github.com/xlab/teg-workshop/teg"
 	"gopkg.in/qml.v1"
 	"gopkg.in/xlab/clipboard.v2"
 )
@@ -25,6 +33,15 @@ const (
 	EventKeyRelease
 )
 
+const (
+	SimStart = iota
+	SimStop
+	SimStep
+	SimRun
+	SimPause
+	SimReset
+)
+
 const (
 	MaxPlaceCounter = 9999
 	MinPlaceCounter = 0
@@ -40,11 +57,14 @@ const (
 	KeyCodeF = 70
 	KeyCodeG = 71
 	KeyCodeT = 84
+	KeyCodeU = 85
+	KeyCodeY = 89
 	KeyCodeJ = 74
 	KeyCodeN = 78
 	KeyCodeK = 75
 	KeyCodeM = 77
 	KeyCodeO = 79
+	KeyCodeR = 82
 	KeyCodeZ = 90
 )
 
@@ -60,8 +80,26 @@ type keyEvent struct {
 	kind    int
 }
 
+type simEvent struct {
+	kind int
+}
+
+type historyEvent struct {
+	redo bool
+}
+
 type stopEvent struct{}
 
+// focusEvent selects the item alone.
+type focusEvent struct {
+	it item
+}
+
+// synthesisEvent inserts the controller of the model.
+type synthesisEvent struct {
+	precompensator, feedback bool
+}
+
 type Ctrl struct {
 	CanvasWidth        float64
 	CanvasHeight       float64
@@ -71,18 +109,43 @@ type Ctrl struct {
 	CanvasWindowWidth  float64
 	Zoom               float64
 
-	Title     string
-	ErrorText string
+	Title       string
+	ErrorText   string
+	DaterText   string
+	CompareText string
+
+	Diagnostics *List
 
 	ModifierKeyControl bool
 	ModifierKeyShift   bool
 	ModifierKeyAlt     bool
 
+	ShowCritical bool
+	CycleText    string
+
+	Simulating bool
+	SimRunning bool
+	SimClock   int
+
+	CanUndo bool
+	CanRedo bool
+
+	// the edit under way, see begin
+	tracker *tracker
+
 	model   *teg
+	sim     *simulation
 	clip    *clipboard.Clipboard
 	events  chan interface{}
 	actions chan interface{}
 	errors  chan error
+
+	// marking is the state of sim the renderer draws, it is replaced
+	// after every step since the renderer runs in its own goroutine.
+	marking struct {
+		sync.Mutex
+		m *core.Marking
+	}
 }
 
 func (c *Ctrl) KeyPressed(keycode int, text string) {
@@ -109,6 +172,38 @@ func (c *Ctrl) MouseMoved(x, y float64) {
 	c.events <- &mouseEvent{x: x, y: y, kind: EventMouseMove}
 }
 
+func (c *Ctrl) SimStart() {
+	c.events <- &simEvent{kind: SimStart}
+}
+
+func (c *Ctrl) SimStop() {
+	c.events <- &simEvent{kind: SimStop}
+}
+
+func (c *Ctrl) SimStep() {
+	c.events <- &simEvent{kind: SimStep}
+}
+
+func (c *Ctrl) SimRun() {
+	c.events <- &simEvent{kind: SimRun}
+}
+
+func (c *Ctrl) SimPause() {
+	c.events <- &simEvent{kind: SimPause}
+}
+
+func (c *Ctrl) SimReset() {
+	c.events <- &simEvent{kind: SimReset}
+}
+
+func (c *Ctrl) Undo() {
+	c.events <- &historyEvent{}
+}
+
+func (c *Ctrl) Redo() {
+	c.events <- &historyEvent{redo: true}
+}
+
 func (c *Ctrl) WindowCoordsToRelativeGlobal(x, y float64) (x1, y1 float64) {
 	xGlobal := c.CanvasWindowX + x
 	yGlobal := c.CanvasWindowY + y
@@ -134,12 +229,16 @@ func (c *Ctrl) unscaleY(y float64) float64 {
 }
 
 func (c *Ctrl) Json() {
-	data, err := json.Marshal(c.model)
+	var buf bytes.Buffer
+	if err := core.Encode(&buf, c.model.Model(false)); err != nil {
+		log.Println(err)
+	}
+	model, err := core.Decode(buf.Bytes())
 	if err != nil {
 		log.Println(err)
+		return
 	}
-	teg := newTeg()
-	if err := json.Unmarshal(data, teg); err != nil {
+	if err := newTeg().Construct(model); err != nil {
 		log.Println(err)
 	}
 }
@@ -156,6 +255,28 @@ func (c *Ctrl) SaveFile(name string) {
 	c.actions <- actionSaveFile{name}
 }
 
+// process draws the model as the controller shows it, once for every task.
+func (tr *tegRenderer) process(model *teg, c *Ctrl) {
+	<-tr.task
+	tr.zoom = c.Zoom
+	tr.canvasWidth = c.CanvasWidth
+	tr.canvasHeight = c.CanvasHeight
+	tr.viewboxWidth = c.CanvasWindowWidth
+	tr.viewboxHeight = c.CanvasWindowHeight
+	tr.viewboxX = c.CanvasWindowX
+	tr.viewboxY = c.CanvasWindowY
+	tr.connections = c.ModifierKeyAlt
+	tr.sim = c.currentMarking()
+	tr.cycle = nil
+	if c.ShowCritical {
+		tr.cycle = model.cycle
+	}
+	tr.renderModel(model, "", pt(0, 0), false)
+	tr.Screen = tr.buf
+	tr.buf = newTegBuffer()
+	tr.ready <- nil
+}
+
 type ScreenshotScene struct {
 	CanvasWidth   float64
 	CanvasHeight  float64
@@ -164,25 +285,53 @@ type ScreenshotScene struct {
 }
 
 func (c *Ctrl) PrepareScene() *ScreenshotScene {
-	margin := 100.0
 	x0, y0, x1, y1 := detectBounds(c.model.Items())
 	w, h := x1-x0, y1-y0
 	return &ScreenshotScene{
-		Width:  w + 2*margin,
-		Height: h + 2*margin,
-		X:      x0 + c.CanvasWindowWidth/2 - margin,
-		Y:      y0 + c.CanvasWindowHeight/2 - margin,
+		Width:  w + 2*SceneMargin,
+		Height: h + 2*SceneMargin,
+		X:      x0 + c.CanvasWindowWidth/2 - SceneMargin,
+		Y:      y0 + c.CanvasWindowHeight/2 - SceneMargin,
 	}
 }
 
 func (c *Ctrl) SaveImage(name string, width int, height int, data *qml.Map) bool {
-	err := util.SaveCanvasImage(name, width, height, data)
+	err := saveCanvasImage(name, width, height, data)
 	if err != nil {
 		return false
 	}
 	return true
 }
 
+// IsPicture tells whether the snapshot should be drawn by ExportPicture.
+func (c *Ctrl) IsPicture(name string) bool {
+	return len(pictureFormat(name)) > 0
+}
+
+// ExportPicture draws the scene into a SVG, PDF or TikZ file, the simulation
+// and the critical circuit are drawn like they are shown.
+func (c *Ctrl) ExportPicture(name string) bool {
+	tr := newTegRenderer()
+	tr.sim = c.currentMarking()
+	if c.ShowCritical {
+		tr.cycle = c.model.cycle
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	err = render.WritePicture(f, tr.picture(c.model), pictureFormat(name))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return true
+}
+
 func (c *Ctrl) PlaneView() {
 	infos := make([]*planeview.Plane, 0, len(c.model.infos))
 	for _, p := range c.model.infos {
@@ -190,11 +339,131 @@ func (c *Ctrl) PlaneView() {
 	}
 	c.actions <- actionPlaneView{
 		models: infos,
-		id:     c.model.id,
+		id:     c.model.net.Id,
 		title:  c.Title,
 	}
 }
 
+func (c *Ctrl) Daters() {
+	table, planes, err := c.model.DaterTable(DaterEvents)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.actions <- actionDaters{
+		table:  table,
+		models: planes,
+		id:     c.model.net.Id + "_daters",
+		title:  c.Title + " daters",
+	}
+}
+
+// Lint checks the structure of the model, the diagnostics are shown in a panel.
+func (c *Ctrl) Lint() {
+	c.actions <- actionLint{c.model.lint()}
+}
+
+// Synthesize inserts the greatest causal controller which keeps the transfer
+// of the model, see core.Synthesize. The group realizing it is put to the left
+// of the model and its series are listed in a plane view.
+func (c *Ctrl) Synthesize(precompensator, feedback bool) {
+	c.events <- &synthesisEvent{precompensator, feedback}
+}
+
+// Compare tells how the transfers of the two selected groups are ordered,
+// see core.Compare. The left group gives H1, the result is shown in a panel.
+func (c *Ctrl) Compare() {
+	var groups []*group
+	for it := range c.model.selected {
+		if g, ok := it.(*group); ok {
+			groups = append(groups, g)
+		}
+	}
+	if len(groups) != 2 {
+		c.Error(errors.New("Select two groups to compare their transfers"))
+		return
+	}
+	if groups[1].X() < groups[0].X() {
+		groups[0], groups[1] = groups[1], groups[0]
+	}
+	g1, err := groups[0].model.graph()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	g2, err := groups[1].model.graph()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	cmp, err := g1.Compare(g2)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	text := fmt.Sprintf("H1 is the transfer of %s, H2 the one of %s\n\n%s",
+		describe("group", groups[0].net.Label), describe("group", groups[1].net.Label), cmp)
+	c.actions <- actionCompare{text}
+}
+
+func (c *Ctrl) synthesize(ev *synthesisEvent) {
+	model, err := c.model.graph()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctl, err := model.Synthesize(ev.precompensator, ev.feedback)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	x0, y0, _, y1 := detectBounds(c.model.Items())
+	items, err := c.model.ConstructItems(ctl.Graph().Model())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	g, err := c.model.addGroup(items)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	g.net.Label = "controller"
+	g.updateIO()
+	g.adjustIO()
+	center := g.Center()
+	g.Shift(x0-4*GridDefaultGap-g.Width()/2-center.X, y0+(y1-y0)/2-center.Y)
+	g.Align()
+	c.model.deselectAll()
+	c.model.selectItem(g)
+	c.actions <- actionController{
+		models: controllerPlanes(ctl),
+		id:     c.model.net.Id + "_controller",
+		title:  c.Title + " controller",
+	}
+}
+
+// Focus is where the view should be centred.
+type Focus struct {
+	X, Y float64
+}
+
+// LintFocus selects the item of the diagnostic and tells where it is, the items
+// in the models of groups are shown by their groups. It is nil if the item is gone.
+func (c *Ctrl) LintFocus(i int) *Focus {
+	if c.Diagnostics == nil || i < 0 || i >= c.Diagnostics.Length {
+		return nil
+	}
+	it := c.Diagnostics.At(i).(*Diagnostic).focus
+	if !c.model.Items()[it] {
+		c.Error(errors.New("The item is no longer in the model"))
+		return nil
+	}
+	c.events <- &focusEvent{it}
+	center := it.Center()
+	return &Focus{X: center.X, Y: center.Y}
+}
+
 func (c *Ctrl) QmlError(text string) {
 	c.errors <- errors.New(text)
 }
@@ -216,17 +485,42 @@ func (c *Ctrl) handleEvents() {
 		var x0, y0 float64
 		var focused interface{}
 		var copied bool
+		c.updateHistory()
 		for {
 			switch ev := (<-c.events).(type) {
 			case *stopEvent:
 				return
 			case *keyEvent:
+				if c.ModifierKeyControl {
+					c.model.root().history.stopTyping()
+				}
+				// the edits may have come from another window
+				c.updateHistory()
 				c.handleKeyEvent(ev)
+			case *historyEvent:
+				if ev.redo {
+					c.redo()
+				} else {
+					c.undo()
+				}
+			case *simEvent:
+				c.handleSimEvent(ev)
+			case *focusEvent:
+				c.model.deselectAll()
+				c.model.selectItem(ev.it)
+				c.model.update()
+			case *synthesisEvent:
+				c.edit(func() {
+					c.synthesize(ev)
+				})
+				c.model.update()
 			case *mouseEvent:
 				x, y := c.WindowCoordsToRelativeGlobal(ev.x, ev.y)
 
 				switch ev.kind {
 				case EventMousePress:
+					c.model.root().history.stopTyping()
+					c.updateHistory()
 					x0, y0 = x, y
 					smth, found := c.model.findDrawable(x, y)
 
@@ -243,6 +537,7 @@ func (c *Ctrl) handleEvents() {
 						c.model.util.min = &geometry.Point{x, y}
 					} else if c.ModifierKeyShift && !found {
 						var it item
+						c.begin()
 						c.model.deselectAll()
 						if c.ModifierKeyControl {
 							it = c.model.addTransition(x, y)
@@ -261,6 +556,7 @@ func (c *Ctrl) handleEvents() {
 					} else {
 						focused = smth
 						if control, ok := smth.(*controlPoint); ok {
+							c.begin()
 							control.modified = true
 						} else {
 							it := smth.(item)
@@ -302,6 +598,7 @@ func (c *Ctrl) handleEvents() {
 						c.model.update()
 					} else if c.ModifierKeyShift && !copied {
 						copied = true
+						c.begin()
 						clones := c.model.cloneItems(c.model.selected)
 						c.model.deselectAll()
 						for _, v := range clones {
@@ -316,6 +613,7 @@ func (c *Ctrl) handleEvents() {
 							c.model.update()
 							continue
 						}
+						c.begin()
 						toOrder := make(map[*transition]bool, len(c.model.transitions))
 						for it := range c.model.selected {
 							if p, ok := it.(*place); ok {
@@ -412,6 +710,7 @@ func (c *Ctrl) handleEvents() {
 					}
 
 				case EventMouseRelease:
+					c.begin()
 					if c.ModifierKeyAlt && c.model.util.kind == UtilStroke {
 						if it, ok := c.model.findDrawable(x, y); focused != nil && focused != it && ok {
 							if p, ok := it.(*place); ok && p.in == nil {
@@ -475,7 +774,7 @@ func (c *Ctrl) handleEvents() {
 									}
 								}
 								for p := range toUnlink {
-									t.unlink(p, true, false)
+									t.unlink(p, true)
 								}
 								toUnlink = make(map[*place]bool, len(t.out))
 								for i, p := range t.out {
@@ -484,10 +783,9 @@ func (c *Ctrl) handleEvents() {
 									}
 								}
 								for p := range toUnlink {
-									t.unlink(p, false, false)
+									t.unlink(p, false)
 								}
 							}
-							grps := make(map[*group]bool, len(c.model.groups))
 							for _, g := range c.model.groups {
 								for _, t := range g.inputs {
 									toUnlink = make(map[*place]bool, len(t.in))
@@ -497,8 +795,7 @@ func (c *Ctrl) handleEvents() {
 										}
 									}
 									for p := range toUnlink {
-										grps[g] = true
-										t.unlink(p, true, true)
+										t.unlink(p, true)
 									}
 								}
 								for _, t := range g.outputs {
@@ -509,15 +806,10 @@ func (c *Ctrl) handleEvents() {
 										}
 									}
 									for p := range toUnlink {
-										grps[g] = true
-										t.unlink(p, false, true)
+										t.unlink(p, false)
 									}
 								}
 							}
-							for g := range grps {
-								g.updateIO()
-								g.adjustIO()
-							}
 						}
 					} else {
 						toAlign := make(map[item]bool, len(c.model.selected))
@@ -533,6 +825,7 @@ func (c *Ctrl) handleEvents() {
 					focused = nil
 					copied = false
 					c.model.util.kind = UtilNone
+					c.commit()
 					c.model.update()
 
 				case EventMouseDoubleClick:
@@ -543,26 +836,32 @@ func (c *Ctrl) handleEvents() {
 						c.model.deselectAll()
 						c.model.selectItem(focused.(item))
 						if t, ok := focused.(*transition); ok {
-							t.rotate()
-							if t.proxy != nil {
-								t.group.adjustIO()
-							}
+							c.edit(func() {
+								t.rotate()
+								if t.proxy != nil {
+									t.group.adjustIO()
+								}
+							})
 							c.model.update()
 						}
 						if p, ok := focused.(*place); ok {
 							if c.ModifierKeyAlt {
-								p.timer++
-							} else {
-								p.counter++
+								if cmd := newSetTimer(p, p.net.Timer+1); cmd != nil {
+									c.exec(cmd, false)
+								}
+							} else if cmd := newSetCounter(p, p.net.Counter+1); cmd != nil {
+								c.exec(cmd, false)
 							}
 							c.model.update()
 						}
 						if g, ok := focused.(*group); ok {
-							if g.folded {
-								c.model.unfoldGroup(g)
-							} else {
-								c.model.foldGroup(g)
-							}
+							c.edit(func() {
+								if g.net.Folded {
+									c.model.unfoldGroup(g)
+								} else {
+									c.model.foldGroup(g)
+								}
+							})
 							c.model.update()
 						}
 					}
@@ -574,10 +873,92 @@ func (c *Ctrl) handleEvents() {
 	}()
 }
 
+func (c *Ctrl) handleSimEvent(ev *simEvent) {
+	if ev.kind == SimStart && c.sim == nil {
+		sim, err := newSimulation(c.model)
+		if err != nil {
+			c.Error(err)
+		}
+		c.sim = sim
+	}
+	if c.sim == nil {
+		return
+	}
+	switch ev.kind {
+	case SimStop:
+		c.sim = nil
+		c.SimRunning = false
+	case SimReset:
+		if err := c.sim.reset(); err != nil {
+			c.Error(err)
+			c.sim = nil
+		}
+		c.SimRunning = false
+	case SimRun:
+		c.SimRunning = true
+	case SimPause:
+		c.SimRunning = false
+	case SimStep:
+		if c.sim.stale() {
+			// the marking of an edited model makes no sense anymore
+			c.SimRunning = false
+			if err := c.sim.reset(); err != nil {
+				c.Error(err)
+				c.sim = nil
+			} else {
+				c.Error(errors.New("Model has changed, simulation restarted"))
+			}
+		} else if !c.sim.Step() {
+			c.SimRunning = false
+			if c.sim.Saturated() {
+				c.Error(fmt.Errorf("Every transition fires without limit at t=%d", c.sim.Clock))
+			} else {
+				c.Error(fmt.Errorf("Deadlock at t=%d, no transition may fire", c.sim.Clock))
+			}
+		}
+	}
+	c.Simulating = c.sim != nil
+	if c.sim != nil {
+		c.SimClock = c.sim.Clock
+		c.setMarking(c.sim.Marking())
+	} else {
+		c.SimClock = 0
+		c.setMarking(nil)
+	}
+	qml.Changed(c, &c.Simulating)
+	qml.Changed(c, &c.SimRunning)
+	qml.Changed(c, &c.SimClock)
+	c.model.update()
+}
+
+func (c *Ctrl) setMarking(m *core.Marking) {
+	c.marking.Lock()
+	c.marking.m = m
+	c.marking.Unlock()
+}
+
+// currentMarking returns the marking to draw, nil when not simulating.
+func (c *Ctrl) currentMarking() *core.Marking {
+	c.marking.Lock()
+	defer c.marking.Unlock()
+	return c.marking.m
+}
+
 func (c *Ctrl) handleKeyEvent(ev *keyEvent) {
 	var updated bool
 	// log.Printf("key: %v (%v)", ev.keycode, ev.text)
 	if c.ModifierKeyControl {
+		switch ev.keycode {
+		case KeyCodeV, KeyCodeG, KeyCodeR, KeyCodeF, KeyCodeZ, KeyCodeT, 16777219, 16777223, 8:
+			c.begin()
+			defer c.commit()
+		}
+		var cmds batch
+		defer func() {
+			if len(cmds) > 0 {
+				c.exec(cmds, false)
+			}
+		}()
 		switch ev.keycode {
 		case KeyCodeA:
 			for it := range c.model.Items() {
@@ -588,6 +969,12 @@ func (c *Ctrl) handleKeyEvent(ev *keyEvent) {
 		case KeyCodeV:
 			c.model.deselectAll()
 			c.clipboardPaste()
+		case KeyCodeU:
+			c.undo()
+			return
+		case KeyCodeY:
+			c.redo()
+			return
 		case KeyCodeG:
 			proxies := 0
 			for it := range c.model.selected {
@@ -609,6 +996,14 @@ func (c *Ctrl) handleKeyEvent(ev *keyEvent) {
 				}
 			}
 			return
+		case KeyCodeR:
+			items := c.model.Items()
+			if len(c.model.selected) > 1 {
+				items = c.model.selected
+			}
+			c.model.arrange(items)
+			c.model.update()
+			return
 		}
 		for it := range c.model.selected {
 			if g, ok := it.(*group); ok {
@@ -617,14 +1012,14 @@ func (c *Ctrl) handleKeyEvent(ev *keyEvent) {
 					g.resetProperties()
 					updated = true
 				case KeyCodeZ:
-					if g.folded {
+					if g.net.Folded {
 						c.model.unfoldGroup(g)
 					} else {
 						c.model.foldGroup(g)
 					}
 					updated = true
 				case KeyCodeO:
-					c.actions <- actionEditGroup{g.model, g.label}
+					c.actions <- actionEditGroup{g.model, g.net.Label}
 					c.model.deselectItem(g)
 					updated = true
 				case 16777219, 16777223, 8:
@@ -635,29 +1030,27 @@ func (c *Ctrl) handleKeyEvent(ev *keyEvent) {
 			} else if p, ok := it.(*place); ok {
 				switch ev.keycode {
 				case KeyCodeJ:
-					p.counter++
+					if cmd := newSetCounter(p, p.net.Counter+1); cmd != nil {
+						cmds = append(cmds, cmd)
+					}
 				case KeyCodeN:
-					p.counter--
+					if cmd := newSetCounter(p, p.net.Counter-1); cmd != nil {
+						cmds = append(cmds, cmd)
+					}
 				case KeyCodeF:
 					p.resetProperties()
 				case KeyCodeK:
-					p.timer++
+					if cmd := newSetTimer(p, p.net.Timer+1); cmd != nil {
+						cmds = append(cmds, cmd)
+					}
 				case KeyCodeM:
-					p.timer--
+					if cmd := newSetTimer(p, p.net.Timer-1); cmd != nil {
+						cmds = append(cmds, cmd)
+					}
 				case 16777219, 16777223, 8:
 					c.model.deselectItem(it)
 					c.model.removePlace(p)
 				}
-				if p.counter > MaxPlaceCounter {
-					p.counter = MaxPlaceCounter
-				} else if p.counter < MinPlaceCounter {
-					p.counter = 0
-				}
-				if p.timer > MaxPlaceTimer {
-					p.timer = MaxPlaceTimer
-				} else if p.timer < MinPlaceTimer {
-					p.timer = 0
-				}
 				updated = true
 			} else if t, ok := it.(*transition); ok {
 				switch ev.keycode {
@@ -676,29 +1069,30 @@ func (c *Ctrl) handleKeyEvent(ev *keyEvent) {
 		}
 	} else {
 		// plaintext input
+		var labels batch
 		for it := range c.model.selected {
 			l := it.Label()
 			switch ev.keycode {
 			case 8, 16777219, 16777223: // backspace
 				if len(l) > 0 {
 					_, size := utf8.DecodeLastRuneInString(l)
-					it.SetLabel(l[:len(l)-size])
-					updated = true
+					labels = append(labels, &setLabel{it, l, l[:len(l)-size]})
 				}
 			case 13, 16777220, 16777221: // return
-				it.SetLabel(l + "\n")
-				updated = true
+				labels = append(labels, &setLabel{it, l, l + "\n"})
 			case 10:
-				it.SetLabel(l + " ")
-				updated = true
+				labels = append(labels, &setLabel{it, l, l + " "})
 			default:
 				rune, _ := utf8.DecodeRuneInString(ev.text)
 				if rune != utf8.RuneError && unicode.IsGraphic(rune) {
-					it.SetLabel(l + string(rune))
-					updated = true
+					labels = append(labels, &setLabel{it, l, l + string(rune)})
 				}
 			}
 		}
+		if len(labels) > 0 {
+			c.exec(labels, true)
+			updated = true
+		}
 		if !updated && c.ModifierKeyAlt {
 			// displaying links
 			updated = true
@@ -752,27 +1146,14 @@ func (c *Ctrl) alignItems(items map[item]bool) {
 func (c *Ctrl) groupItems(items map[item]bool) {
 	data := make(map[item]bool, len(items))
 	for it := range items {
-		switch it.(type) {
-		case *transition:
-			if it.(*transition).KindInGroup(items) == TransitionExposed {
-				return // no way
-			} else if it.(*transition).proxy == nil {
-				data[it] = true
-			}
-		case *place:
-			if it.(*place).KindInGroup(items) == PlaceExposed {
-				return // no way
-			}
-			data[it] = true
-		case *group:
-			if it.(*group).KindInGroup(items) == GroupExposed {
-				return // no way
-			}
+		if t, ok := it.(*transition); !ok || t.proxy == nil {
 			data[it] = true
-
 		}
 	}
-	g := c.model.addGroup(data)
+	g, err := c.model.addGroup(data)
+	if err != nil {
+		return // no way
+	}
 	g.updateIO()
 	g.adjustIO()
 	g.Align()
@@ -794,11 +1175,14 @@ func (c *Ctrl) clipboardPaste() (err error) {
 	if err != nil {
 		return
 	}
-	model := &Teg{}
+	model := &core.Model{}
 	if err = json.Unmarshal([]byte(str), model); err != nil {
 		return
 	}
-	items := c.model.ConstructItems(model)
+	items, err := c.model.ConstructItems(model)
+	if err != nil {
+		return
+	}
 	center := pt(c.CanvasWindowX-c.CanvasWidth/2, c.CanvasWindowY-c.CanvasHeight/2)
 	shift := calcItemsShift(center, items)
 	for it := range items {
@@ -817,3 +1201,95 @@ func (c *Ctrl) clipboardCopy() (err error) {
 	c.clip.WriteAll(string(buf))
 	return
 }
+
+// begin starts an edit of the model unless one is under way, the edit
+// lasts until commit so that a drag of items is undone at once.
+func (c *Ctrl) begin() {
+	if c.tracker == nil {
+		c.tracker = c.model.track()
+	}
+}
+
+// commit records the edit started by begin if it changed the model.
+func (c *Ctrl) commit() {
+	if c.tracker == nil {
+		return
+	}
+	change := c.tracker.end()
+	c.tracker = nil
+	if change != nil {
+		c.model.root().history.push(c.model, change, false)
+		c.updateHistory()
+	}
+}
+
+// edit runs fn and records the change it made to the model.
+func (c *Ctrl) edit(fn func()) {
+	c.begin()
+	fn()
+	c.commit()
+}
+
+// exec does the command and records it.
+func (c *Ctrl) exec(cmd command, typing bool) {
+	cmd.do()
+	c.model.root().history.push(c.model, cmd, typing)
+	c.updateHistory()
+}
+
+// newSetCounter returns the command setting the counter of p to n,
+// kept within the bounds, or nil if the counter is n already.
+func newSetCounter(p *place, n int) *setCounter {
+	if n > MaxPlaceCounter {
+		n = MaxPlaceCounter
+	} else if n < MinPlaceCounter {
+		n = MinPlaceCounter
+	}
+	if n == p.net.Counter {
+		return nil
+	}
+	return &setCounter{p, p.net.Counter, n}
+}
+
+// newSetTimer returns the command setting the timer of p to n,
+// kept within the bounds, or nil if the timer is n already.
+func newSetTimer(p *place, n int) *setTimer {
+	if n > MaxPlaceTimer {
+		n = MaxPlaceTimer
+	} else if n < MinPlaceTimer {
+		n = MinPlaceTimer
+	}
+	if n == p.net.Timer {
+		return nil
+	}
+	return &setTimer{p, p.net.Timer, n}
+}
+
+func (c *Ctrl) undo() {
+	c.travel(c.model.root().history.back)
+}
+
+func (c *Ctrl) redo() {
+	c.travel(c.model.root().history.forth)
+}
+
+func (c *Ctrl) travel(next func() (*entry, bool)) {
+	c.commit()
+	e, ok := next()
+	if !ok {
+		return
+	}
+	for tg := range e.tegs() {
+		if tg != c.model {
+			tg.notify()
+		}
+	}
+	c.updateHistory()
+	c.model.update()
+}
+
+func (c *Ctrl) updateHistory() {
+	c.CanUndo, c.CanRedo = c.model.root().history.state()
+	qml.Changed(c, &c.CanUndo)
+	qml.Changed(c, &c.CanRedo)
+}
@@ -0,0 +1,29 @@
+package tegview
+
+import (
+	"fmt"
+
+	"github.com/xlab/teg-workshop/planeview"
+	core "github.com/xlab/teg-workshop/teg"
+)
+
+// DaterEvents is how many events the dater trajectories are computed for.
+const DaterEvents = core.DaterEvents
+
+// DaterTable runs the daters with the series of the input planes and gives
+// the dates as a text table along with a plane for every transition.
+func (tg *teg) DaterTable(n int) (table string, planes []*planeview.Plane, err error) {
+	g, err := tg.graph()
+	if err != nil {
+		return "", nil, err
+	}
+	r := g.DaterReport(n)
+	for i, ref := range r.Refs {
+		plane := planeview.NewPlane(fmt.Sprintf("%s/%s", ref.Path, ref.Id),
+			r.Transitions[i]+" x(k)", false)
+		plane.SetColor(PlaneColors[len(planes)%9])
+		plane.SetDioid(core.Trajectory(r.Dates[i]))
+		planes = append(planes, plane)
+	}
+	return r.String(), planes, nil
+}
@@ -0,0 +1,38 @@
+package tegview
+
+import (
+	"fmt"
+
+	core "github.com/xlab/teg-workshop/teg"
+)
+
+// constructDocument reads a document of any known version, see core.Decode.
+func constructDocument(data []byte) (tg *teg, err error) {
+	model, err := core.Decode(data)
+	if err != nil {
+		return
+	}
+	return constructModel(model)
+}
+
+// constructNet reads a PNML net, see core.ReadPNML.
+func constructNet(data []byte) (tg *teg, err error) {
+	g, err := core.ReadPNML(data)
+	if err != nil {
+		return
+	}
+	return constructModel(g.Model())
+}
+
+func constructModel(model *core.Model) (tg *teg, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tg, err = nil, fmt.Errorf("constructing model: %v", r)
+		}
+	}()
+	tg = newTeg()
+	if err = tg.Construct(model); err != nil {
+		tg = nil
+	}
+	return
+}
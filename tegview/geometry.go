@@ -0,0 +1,97 @@
+package tegview
+
+import (
+	"math"
+
+	"github.com/xlab/teg-workshop/geometry"
+)
+
+const (
+	BorderTransitionDist    = 3.0
+	BorderTransitionTipDist = 2.0
+	BorderPlaceDist         = 5.0
+	BorderPlaceTipDist      = 3.0
+)
+
+func pt(x, y float64) *geometry.Point {
+	return &geometry.Point{X: x, Y: y}
+}
+
+func calcSpacing(room, each float64, count int) float64 {
+	return (room - (each * float64(count))) / (float64(count) + 1)
+}
+
+func calcCenteringMargin(room, each float64, count int) float64 {
+	c := float64(count)
+	return (room - ((c-1)*calcSpacing(room, each, count) + c*each)) / 2
+}
+
+type end struct {
+	x, y, angle, xTip, yTip float64
+}
+
+func calcBorderPointPlace(p *place, shift *geometry.Point, x, y float64) *end {
+	px, py := p.Center().X+shift.X, p.Center().Y+shift.Y
+	angle := math.Atan2(x-px, y-py)
+	radius := p.Width() / 2
+	dxTip := (radius + BorderPlaceTipDist) * math.Sin(angle)
+	dyTip := (radius + BorderPlaceTipDist) * math.Cos(angle)
+	dx := (radius + BorderPlaceDist) * math.Sin(angle)
+	dy := (radius + BorderPlaceDist) * math.Cos(angle)
+	return &end{
+		x: px + dx, y: py + dy,
+		xTip: px + dxTip, yTip: py + dyTip,
+		angle: angle,
+	}
+}
+
+func calcBorderPointTransition(t *transition, shift *geometry.Point, inbound bool, count, index int) *end {
+	thick := 2.0
+	var x, y float64
+
+	end := new(end)
+	if t.net.Horizontal && inbound {
+		x = t.X()
+		y = t.Y() - BorderTransitionDist
+	} else if inbound {
+		x = t.X() - BorderTransitionDist
+		y = t.Y()
+	} else if t.net.Horizontal {
+		x = t.X()
+		y = t.Y() + t.Height() + BorderTransitionDist
+	} else {
+		x = t.X() + t.Width() + BorderTransitionDist
+		y = t.Y()
+	}
+	x, y = x+shift.X, y+shift.Y
+	if t.net.Horizontal {
+		space := calcSpacing(t.Width(), thick, count)
+		margin := calcCenteringMargin(t.Width(), 1.0, count)
+		dx := margin + float64(index)*(thick+space)
+		end.x, end.y = x+dx, y
+		if inbound {
+			end.angle = math.Pi
+			end.xTip = x + dx
+			end.yTip = y + BorderTransitionTipDist
+		} else {
+			end.angle = math.Pi
+			end.xTip = x + dx
+			end.yTip = y - BorderTransitionTipDist
+		}
+	} else {
+		space := calcSpacing(t.Height(), thick, count)
+		margin := calcCenteringMargin(t.Height(), 1.0, count)
+		dy := margin + float64(index)*(thick+space)
+		end.x, end.y = x, y+dy
+		if inbound {
+			end.angle = -math.Pi / 2
+			end.xTip = x + BorderTransitionTipDist
+			end.yTip = y + dy
+		} else {
+			end.angle = math.Pi / 2
+			end.xTip = x - BorderTransitionTipDist
+			end.yTip = y + dy
+		}
+	}
+	return end
+}
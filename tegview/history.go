@@ -0,0 +1,570 @@
+package tegview
+
+import (
+	"sync"
+
+	"github.com/xlab/teg-workshop/geometry"
+	core "github.com/xlab/teg-workshop/teg"
+)
+
+// MaxHistory is how many edits can be undone.
+const MaxHistory = 100
+
+// command is an edit of a teg that can be undone and done again.
+// Setting a counter, a timer or a label is a command of its own,
+// holding the old and the new value. The structural edits are recorded
+// as a change, see below.
+type command interface {
+	do()
+	undo()
+}
+
+// setCounter changes the initial tokens of a place.
+type setCounter struct {
+	p        *place
+	from, to int
+}
+
+func (c *setCounter) do()   { c.p.net.Counter = c.to }
+func (c *setCounter) undo() { c.p.net.Counter = c.from }
+
+// setTimer changes the holding time of a place.
+type setTimer struct {
+	p        *place
+	from, to int
+}
+
+func (c *setTimer) do()   { c.p.net.Timer = c.to }
+func (c *setTimer) undo() { c.p.net.Timer = c.from }
+
+// setLabel changes the label of an item.
+type setLabel struct {
+	it       item
+	from, to string
+}
+
+func (c *setLabel) do()   { c.it.SetLabel(c.to) }
+func (c *setLabel) undo() { c.it.SetLabel(c.from) }
+
+// batch is a single edit made of several commands, such as a key pressed
+// with many items selected.
+type batch []command
+
+func (b batch) do() {
+	for _, c := range b {
+		c.do()
+	}
+}
+
+func (b batch) undo() {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i].undo()
+	}
+}
+
+// change is an edit reshaping the tegs: adding, linking, grouping, cloning,
+// moving or deleting items. It is not an inverse command but a pair of
+// snapshots: the items the edit touched are kept as they were before and
+// after it, and undo or redo puts them back as they were. The items left
+// as they were are not kept.
+//
+// Snapshots are used because these edits rebuild the arcs and the inputs
+// and outputs of the groups they reach, up to the root teg. An inverse
+// would have to recreate the very same transitions, places and groups,
+// since the later edits in the history refer to them, while the graph
+// makes new ones when a group is made or dissolved.
+type change struct {
+	before, after *state
+}
+
+func (c *change) do()   { c.after.apply() }
+func (c *change) undo() { c.before.apply() }
+
+// tegs returns the tegs the change lists items of.
+func (c *change) tegs() map[*teg]bool {
+	tegs := make(map[*teg]bool, len(c.before.tegs)+len(c.after.tegs))
+	for tg := range c.before.tegs {
+		tegs[tg] = true
+	}
+	for tg := range c.after.tegs {
+		tegs[tg] = true
+	}
+	return tegs
+}
+
+// tegState holds what a teg and its graph have, the items have states
+// of their own.
+type tegState struct {
+	parent      *teg
+	net         *core.Graph
+	places      []*place
+	transitions []*transition
+	groups      []*group
+}
+
+// state holds copies of tegs and their items keyed by the originals.
+type state struct {
+	tegs        map[*teg]*tegState
+	places      map[*place]*place
+	transitions map[*transition]*transition
+	groups      map[*group]*group
+}
+
+func newState() *state {
+	return &state{
+		tegs:        make(map[*teg]*tegState),
+		places:      make(map[*place]*place),
+		transitions: make(map[*transition]*transition),
+		groups:      make(map[*group]*group),
+	}
+}
+
+// capture copies the tegs along with their items, the inputs and outputs
+// of their groups included.
+func capture(tegs map[*teg]bool) *state {
+	s := newState()
+	for tg := range tegs {
+		s.tegs[tg] = &tegState{
+			parent:      tg.parent,
+			net:         copyGraph(tg.net),
+			places:      append([]*place(nil), tg.places...),
+			transitions: append([]*transition(nil), tg.transitions...),
+			groups:      append([]*group(nil), tg.groups...),
+		}
+		for _, p := range tg.places {
+			s.places[p] = copyPlace(p)
+		}
+		for _, t := range tg.transitions {
+			s.transitions[t] = copyTransition(t)
+		}
+		for _, g := range tg.groups {
+			s.groups[g] = copyGroup(g)
+			for _, t := range g.inputs {
+				s.transitions[t] = copyTransition(t)
+			}
+			for _, t := range g.outputs {
+				s.transitions[t] = copyTransition(t)
+			}
+		}
+	}
+	return s
+}
+
+// apply puts the tegs and the items back in the state. The selections
+// are dropped, the items selected may be gone.
+func (s *state) apply() {
+	for tg, ts := range s.tegs {
+		tg.parent = ts.parent
+		*tg.net = *copyGraph(ts.net)
+		tg.places = append([]*place(nil), ts.places...)
+		tg.transitions = append([]*transition(nil), ts.transitions...)
+		tg.groups = append([]*group(nil), ts.groups...)
+		tg.deselectAll()
+		tg.transferSig = ""
+	}
+	// the items keep their items of the graph, those get the state too
+	for p, c := range s.places {
+		net := p.net
+		*p = *copyPlace(c)
+		*net, p.net = *p.net, net
+	}
+	for t, c := range s.transitions {
+		net := t.net
+		*t = *copyTransition(c)
+		*net, t.net = *t.net, net
+	}
+	for g, c := range s.groups {
+		net := g.net
+		*g = *copyGroup(c)
+		*net, g.net = *g.net, net
+	}
+}
+
+// diff returns the change from before to after with only the items that
+// differ, nil if nothing does.
+func diff(before, after *state) *change {
+	c := &change{newState(), newState()}
+	for tg, ts := range before.tegs {
+		if ts2, ok := after.tegs[tg]; !ok || !sameTeg(ts, ts2) {
+			c.before.tegs[tg] = ts
+		}
+	}
+	for tg, ts := range after.tegs {
+		if ts2, ok := before.tegs[tg]; !ok || !sameTeg(ts, ts2) {
+			c.after.tegs[tg] = ts
+		}
+	}
+	for p, p1 := range before.places {
+		if p2, ok := after.places[p]; !ok || !samePlace(p1, p2) {
+			c.before.places[p] = p1
+		}
+	}
+	for p, p1 := range after.places {
+		if p2, ok := before.places[p]; !ok || !samePlace(p1, p2) {
+			c.after.places[p] = p1
+		}
+	}
+	for t, t1 := range before.transitions {
+		if t2, ok := after.transitions[t]; !ok || !sameTransition(t1, t2) {
+			c.before.transitions[t] = t1
+		}
+	}
+	for t, t1 := range after.transitions {
+		if t2, ok := before.transitions[t]; !ok || !sameTransition(t1, t2) {
+			c.after.transitions[t] = t1
+		}
+	}
+	for g, g1 := range before.groups {
+		if g2, ok := after.groups[g]; !ok || !sameGroup(g1, g2) {
+			c.before.groups[g] = g1
+		}
+	}
+	for g, g1 := range after.groups {
+		if g2, ok := before.groups[g]; !ok || !sameGroup(g1, g2) {
+			c.after.groups[g] = g1
+		}
+	}
+	if c.before.empty() && c.after.empty() {
+		return nil
+	}
+	return c
+}
+
+func (s *state) empty() bool {
+	return len(s.tegs) == 0 && len(s.places) == 0 &&
+		len(s.transitions) == 0 && len(s.groups) == 0
+}
+
+func copyRect(r *geometry.Rect) *geometry.Rect {
+	if r == nil {
+		return nil
+	}
+	return geometry.NewRect(r.X(), r.Y(), r.Width(), r.Height())
+}
+
+func copyControl(cp *controlPoint) *controlPoint {
+	if cp == nil {
+		return nil
+	}
+	return &controlPoint{copyRect(cp.Rect), cp.modified}
+}
+
+func copyGraph(g *core.Graph) *core.Graph {
+	c := *g
+	c.Places = append([]*core.Place(nil), g.Places...)
+	c.Transitions = append([]*core.Transition(nil), g.Transitions...)
+	c.Groups = append([]*core.Group(nil), g.Groups...)
+	c.Infos = append([]*core.Info(nil), g.Infos...)
+	return &c
+}
+
+func copyPlace(p *place) *place {
+	c := *p
+	c.Circle = geometry.NewCircle(p.Center().X, p.Center().Y, p.Width()/2)
+	c.inControl = copyControl(p.inControl)
+	c.outControl = copyControl(p.outControl)
+	net := *p.net
+	c.net = &net
+	return &c
+}
+
+func copyTransition(t *transition) *transition {
+	c := *t
+	c.Rect = copyRect(t.Rect)
+	c.in = append([]*place(nil), t.in...)
+	c.out = append([]*place(nil), t.out...)
+	net := *t.net
+	net.In = append([]*core.Place(nil), t.net.In...)
+	net.Out = append([]*core.Place(nil), t.net.Out...)
+	c.net = &net
+	return &c
+}
+
+func copyGroup(g *group) *group {
+	c := *g
+	c.Rect = copyRect(g.Rect)
+	c.inputs = append([]*transition(nil), g.inputs...)
+	c.outputs = append([]*transition(nil), g.outputs...)
+	net := *g.net
+	net.Inputs = append([]*core.Transition(nil), g.net.Inputs...)
+	net.Outputs = append([]*core.Transition(nil), g.net.Outputs...)
+	c.net = &net
+	c.iostate = make(map[*transition]*transition, len(g.iostate))
+	for k, v := range g.iostate {
+		c.iostate[k] = v
+	}
+	return &c
+}
+
+func sameRect(r1, r2 *geometry.Rect) bool {
+	if r1 == nil || r2 == nil {
+		return r1 == r2
+	}
+	return r1.X() == r2.X() && r1.Y() == r2.Y() &&
+		r1.Width() == r2.Width() && r1.Height() == r2.Height()
+}
+
+func sameControl(c1, c2 *controlPoint) bool {
+	if c1 == nil || c2 == nil {
+		return c1 == c2
+	}
+	return c1.modified == c2.modified && sameRect(c1.Rect, c2.Rect)
+}
+
+func samePlaces(l1, l2 []*place) bool {
+	if len(l1) != len(l2) {
+		return false
+	}
+	for i := range l1 {
+		if l1[i] != l2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameTransitions(l1, l2 []*transition) bool {
+	if len(l1) != len(l2) {
+		return false
+	}
+	for i := range l1 {
+		if l1[i] != l2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameTeg(ts1, ts2 *tegState) bool {
+	if ts1.parent != ts2.parent || ts1.net.Parent != ts2.net.Parent ||
+		len(ts1.groups) != len(ts2.groups) {
+		return false
+	}
+	for i := range ts1.groups {
+		if ts1.groups[i] != ts2.groups[i] {
+			return false
+		}
+	}
+	return samePlaces(ts1.places, ts2.places) &&
+		sameTransitions(ts1.transitions, ts2.transitions)
+}
+
+func samePlace(p1, p2 *place) bool {
+	return p1.net.Counter == p2.net.Counter && p1.net.Timer == p2.net.Timer &&
+		p1.net.Label == p2.net.Label && p1.in == p2.in && p1.out == p2.out &&
+		p1.parent == p2.parent && p1.Center().X == p2.Center().X &&
+		p1.Center().Y == p2.Center().Y && p1.Width() == p2.Width() &&
+		sameControl(p1.inControl, p2.inControl) &&
+		sameControl(p1.outControl, p2.outControl)
+}
+
+func sameTransition(t1, t2 *transition) bool {
+	return t1.net.Label == t2.net.Label && t1.net.Horizontal == t2.net.Horizontal &&
+		t1.net.Kind == t2.net.Kind && t1.proxy == t2.proxy && t1.group == t2.group &&
+		t1.parent == t2.parent && sameRect(t1.Rect, t2.Rect) &&
+		samePlaces(t1.in, t2.in) && samePlaces(t1.out, t2.out)
+}
+
+func sameGroup(g1, g2 *group) bool {
+	if len(g1.iostate) != len(g2.iostate) {
+		return false
+	}
+	for k, v := range g1.iostate {
+		if g2.iostate[k] != v {
+			return false
+		}
+	}
+	return g1.net.Label == g2.net.Label && g1.net.Folded == g2.net.Folded &&
+		g1.model == g2.model && g1.parent == g2.parent &&
+		sameRect(g1.Rect, g2.Rect) &&
+		sameTransitions(g1.inputs, g2.inputs) &&
+		sameTransitions(g1.outputs, g2.outputs)
+}
+
+// tracker follows an edit of a teg from its start, a drag of items lasts
+// for many events but is a single edit.
+type tracker struct {
+	tg     *teg
+	before *state
+}
+
+// scope returns the tegs an edit of tg may change: tg, the tegs it belongs
+// to and the ones of its groups, the items may move in and out of those.
+func (tg *teg) scope() map[*teg]bool {
+	tegs := make(map[*teg]bool)
+	for t := tg; t != nil; t = t.parent {
+		tegs[t] = true
+	}
+	for _, g := range tg.groups {
+		if g.model != nil {
+			tegs[g.model] = true
+		}
+	}
+	return tegs
+}
+
+func (tg *teg) track() *tracker {
+	return &tracker{tg, capture(tg.scope())}
+}
+
+// end returns the change made since the tracking started, nil if there is
+// none. The groups of the parent tegs are brought up to date first, the
+// change of their inputs and outputs is a part of the edit.
+func (tr *tracker) end() *change {
+	c := diff(tr.before, tr.capture())
+	if c == nil || tr.tg.parent == nil {
+		return c
+	}
+	tr.tg.updateParentGroups()
+	return diff(tr.before, tr.capture())
+}
+
+// capture copies the tegs the edit started with along with the ones it
+// may have changed since, a group made by the edit brings its teg in.
+func (tr *tracker) capture() *state {
+	tegs := tr.tg.scope()
+	for tg := range tr.before.tegs {
+		tegs[tg] = true
+	}
+	return capture(tegs)
+}
+
+// edit runs fn, an edit of tg, and returns the change it made.
+func (tg *teg) edit(fn func()) *change {
+	tr := tg.track()
+	fn()
+	return tr.end()
+}
+
+// entry is a command of the history along with the teg it was made in.
+type entry struct {
+	cmd    command
+	tg     *teg
+	typing bool
+}
+
+// tegs returns the tegs to redraw once the command is undone or done again.
+func (e *entry) tegs() map[*teg]bool {
+	tegs := make(map[*teg]bool)
+	if c, ok := e.cmd.(*change); ok {
+		tegs = c.tegs()
+	}
+	for tg := e.tg; tg != nil; tg = tg.parent {
+		tegs[tg] = true
+	}
+	return tegs
+}
+
+// history is shared by the windows editing the same tree of tegs,
+// only the history of the root teg is used.
+type history struct {
+	sync.Mutex
+	undo, redo []*entry
+}
+
+func newHistory() *history {
+	return &history{}
+}
+
+// push records a command done in tg and forgets the undone ones.
+// Consecutive typing in the same items is merged into a single edit.
+func (h *history) push(tg *teg, cmd command, typing bool) {
+	h.Lock()
+	defer h.Unlock()
+	h.redo = nil
+	if n := len(h.undo); typing && n > 0 && h.undo[n-1].typing && merge(h.undo[n-1].cmd, cmd) {
+		return
+	}
+	h.undo = append(h.undo, &entry{cmd, tg, typing})
+	if len(h.undo) > MaxHistory {
+		h.undo = h.undo[len(h.undo)-MaxHistory:]
+	}
+}
+
+// merge folds the labels set by next into prev if both set the labels
+// of the same items.
+func merge(prev, next command) bool {
+	b1, ok1 := prev.(batch)
+	b2, ok2 := next.(batch)
+	if !ok1 || !ok2 || len(b1) != len(b2) {
+		return false
+	}
+	labels := make(map[item]*setLabel, len(b1))
+	for _, c := range b1 {
+		if l, ok := c.(*setLabel); ok {
+			labels[l.it] = l
+		}
+	}
+	for _, c := range b2 {
+		if l, ok := c.(*setLabel); !ok || labels[l.it] == nil {
+			return false
+		}
+	}
+	for _, c := range b2 {
+		l := c.(*setLabel)
+		labels[l.it].to = l.to
+	}
+	return true
+}
+
+// stopTyping makes the next typing a separate edit.
+func (h *history) stopTyping() {
+	h.Lock()
+	defer h.Unlock()
+	if n := len(h.undo); n > 0 {
+		h.undo[n-1].typing = false
+	}
+}
+
+// back undoes the last edit and returns it.
+func (h *history) back() (*entry, bool) {
+	h.Lock()
+	defer h.Unlock()
+	n := len(h.undo)
+	if n < 1 {
+		return nil, false
+	}
+	e := h.undo[n-1]
+	e.typing = false
+	e.cmd.undo()
+	h.undo = h.undo[:n-1]
+	h.redo = append(h.redo, e)
+	return e, true
+}
+
+// forth does the last undone edit again and returns it.
+func (h *history) forth() (*entry, bool) {
+	h.Lock()
+	defer h.Unlock()
+	n := len(h.redo)
+	if n < 1 {
+		return nil, false
+	}
+	e := h.redo[n-1]
+	e.cmd.do()
+	h.redo = h.redo[:n-1]
+	h.undo = append(h.undo, e)
+	return e, true
+}
+
+func (h *history) state() (canUndo, canRedo bool) {
+	h.Lock()
+	defer h.Unlock()
+	return len(h.undo) > 0, len(h.redo) > 0
+}
+
+// root returns the outermost teg, the one the groups being edited belong to.
+func (tg *teg) root() *teg {
+	for tg.parent != nil {
+		tg = tg.parent
+	}
+	return tg
+}
+
+// notify asks the window of the teg to redraw it, if there is any.
+func (tg *teg) notify() {
+	select {
+	case tg.updated <- nil:
+	default:
+	}
+}
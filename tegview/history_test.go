@@ -0,0 +1,159 @@
+package tegview
+
+func (t *testSuite) TestHistoryLink() {
+	tg := newTeg()
+	u, p := tg.addTransition(0, 0), tg.addPlace(100, 0)
+	far := tg.addPlace(500, 500)
+	h := tg.history
+	change := tg.edit(func() {
+		u.link(p, false)
+	})
+	t.Not(change == nil)
+	h.push(tg, change, false)
+	// only the items linked are kept
+	_, ok := change.before.places[far]
+	t.False(ok)
+	t.Equal(1, len(change.before.places))
+	t.Equal(1, len(change.before.transitions))
+
+	_, ok = h.back()
+	t.True(ok)
+	t.True(p.in == nil)
+	t.Equal(0, len(u.out))
+	_, ok = h.forth()
+	t.True(ok)
+	t.True(p.in == u)
+	t.Equal(1, len(u.out))
+	t.True(tg.edit(func() {}) == nil)
+}
+
+func (t *testSuite) TestHistoryGroup() {
+	tg, u, p, y := chain()
+	h := tg.history
+	var g *group
+	h.push(tg, tg.edit(func() {
+		g, _ = tg.addGroup(map[item]bool{u: true, p: true, y: true})
+		g.updateIO()
+		g.adjustIO()
+	}), false)
+	t.Equal(1, len(tg.groups))
+	t.Equal(0, len(tg.places))
+	t.True(u.parent == g.model)
+
+	h.back()
+	t.Equal(0, len(tg.groups))
+	t.Equal(2, len(tg.transitions))
+	t.Equal(1, len(tg.places))
+	t.True(u.parent == tg && p.parent == tg)
+	t.True(p.in == u && p.out == y)
+	t.Equal(0, len(tg.lint()))
+
+	h.forth()
+	t.Equal(1, len(tg.groups))
+	t.True(tg.groups[0] == g)
+	t.True(u.parent == g.model)
+	t.Equal(2, len(g.model.transitions))
+
+	// ungrouping makes copies, undoing it brings the group back
+	h.push(tg, tg.edit(func() {
+		tg.flatGroup(g)
+	}), false)
+	t.Equal(0, len(tg.groups))
+	t.Equal(2, len(tg.transitions))
+	t.False(tg.transitions[0] == u)
+	h.back()
+	t.Equal(1, len(tg.groups))
+	t.True(g.model != nil && g.parent == tg)
+	t.Equal(0, len(tg.transitions))
+}
+
+func (t *testSuite) TestHistoryDelete() {
+	tg, u, p, y := chain()
+	h := tg.history
+	h.push(tg, tg.edit(func() {
+		tg.removeTransition(u)
+		tg.removePlace(p)
+	}), false)
+	t.Equal(1, len(tg.transitions))
+	t.Equal(0, len(tg.places))
+	t.True(y.in == nil || len(y.in) == 0)
+
+	h.back()
+	t.Equal(2, len(tg.transitions))
+	t.Equal(1, len(tg.places))
+	t.True(p.in == u && p.out == y)
+	t.True(u.out[0] == p && y.in[0] == p)
+	t.True(p.inControl != nil && p.outControl != nil)
+
+	h.push(tg, tg.edit(func() {
+		tg.cloneItems(map[item]bool{u: true, p: true})
+	}), false)
+	t.Equal(3, len(tg.transitions))
+	t.Equal(2, len(tg.places))
+	can, _ := h.state()
+	t.True(can)
+	h.back()
+	t.Equal(2, len(tg.transitions))
+	t.Equal(1, len(tg.places))
+	_, redo := h.state()
+	t.True(redo)
+}
+
+func (t *testSuite) TestHistoryValues() {
+	tg, _, p, y := chain()
+	h := tg.history
+	for _, to := range []string{"a", "ab"} {
+		cmd := batch{&setLabel{p, p.net.Label, to}, &setLabel{y, y.net.Label, to + "!"}}
+		cmd.do()
+		h.push(tg, cmd, true)
+	}
+	cmd := &setCounter{p, p.net.Counter, 3}
+	cmd.do()
+	h.push(tg, cmd, false)
+	t.Equal(2, len(h.undo))
+
+	h.back()
+	t.Equal(0, p.net.Counter)
+	t.Equal("ab", p.net.Label)
+	h.back()
+	t.Equal("", p.net.Label)
+	t.Equal("y", y.net.Label)
+	_, ok := h.back()
+	t.False(ok)
+	h.forth()
+	t.Equal("ab!", y.net.Label)
+}
+
+func (t *testSuite) TestHistoryGroupEditor() {
+	tg, u, p, y := chain()
+	g, _ := tg.addGroup(map[item]bool{u: true, p: true, y: true})
+	g.updateIO()
+	g.adjustIO()
+	w, x := tg.addTransition(-200, 0), tg.addPlace(-100, 0)
+	w.net.Kind = TransitionInput
+	w.link(x, false)
+	g.inputs[0].link(x, true)
+	in := g.inputs[0]
+	t.Equal(0, len(tg.lint()))
+
+	// an edit in the window of the group goes to the history of the root
+	sub := g.model
+	h := sub.root().history
+	t.True(h == tg.history)
+	h.push(sub, sub.edit(func() {
+		u.net.Kind = TransitionInternal
+		v := sub.addTransition(-100, 0)
+		q := sub.addPlace(-50, 0)
+		v.net.Kind = TransitionInput
+		v.link(q, false)
+		u.link(q, true)
+	}), false)
+	t.Equal(1, len(g.inputs))
+	t.False(g.inputs[0] == in)
+
+	h.back()
+	t.Equal(2, len(sub.transitions))
+	t.True(g.inputs[0] == in)
+	t.True(x.out == in)
+	t.Equal(0, len(tg.lint()))
+}
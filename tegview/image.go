@@ -1,4 +1,7 @@
-package util
+//go:build !headless
+// +build !headless
+
+package tegview
 
 import (
 	"image"
@@ -10,8 +13,8 @@ import (
 	"gopkg.in/qml.v1"
 )
 
-// Save an image from CanvasImageData
-func SaveCanvasImage(name string, width, height int, data *qml.Map) (err error) {
+// saveCanvasImage saves an image from CanvasImageData
+func saveCanvasImage(name string, width, height int, data *qml.Map) (err error) {
 	tmp := make(map[string]color.RGBA)
 	data.Convert(&tmp)
 
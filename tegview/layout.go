@@ -0,0 +1,94 @@
+package tegview
+
+import (
+	core "github.com/xlab/teg-workshop/teg"
+)
+
+// arrange lays the items out in layers along the places around the centre
+// of their bounds, see core.LayerLayout. The links to the other items do not
+// shape the layout. The layers go down when most of the transitions are
+// horizontal, the transitions keep their orientation. The control points
+// of the places are made anew and the arcs are ordered by the new positions.
+func (tg *teg) arrange(items map[item]bool) {
+	var list []item
+	var nodes []*core.LayerNode
+	index := make(map[item]int, len(items))
+	add := func(it item) {
+		index[it] = len(nodes)
+		list = append(list, it)
+		nodes = append(nodes, &core.LayerNode{W: it.Width(), H: it.Height()})
+	}
+	var horizontal, vertical int
+	for _, t := range tg.transitions {
+		if t.proxy == nil && items[t] {
+			if t.net.Horizontal {
+				horizontal++
+			} else {
+				vertical++
+			}
+			add(t)
+		}
+	}
+	for _, g := range tg.groups {
+		if items[g] {
+			add(g)
+			for _, t := range g.inputs {
+				index[t] = index[g]
+			}
+			for _, t := range g.outputs {
+				index[t] = index[g]
+			}
+		}
+	}
+	for _, p := range tg.places {
+		if items[p] {
+			add(p)
+		}
+	}
+	if len(nodes) < 2 {
+		return
+	}
+	for _, p := range tg.places {
+		v, ok := index[p]
+		if !ok {
+			continue
+		}
+		if p.in != nil {
+			if u, ok := index[p.in]; ok {
+				nodes[u].Next = append(nodes[u].Next, v)
+			}
+		}
+		if p.out != nil {
+			if u, ok := index[p.out]; ok {
+				nodes[v].Next = append(nodes[v].Next, u)
+			}
+		}
+	}
+
+	x0, y0, x1, y1 := detectBounds(items)
+	cx, cy := x0+(x1-x0)/2, y0+(y1-y0)/2
+	core.LayerLayout(nodes, core.FlowsDown(horizontal, vertical))
+	for i, it := range list {
+		c := it.Center()
+		it.Shift(cx+nodes[i].X-c.X, cy+nodes[i].Y-c.Y)
+		it.Align()
+	}
+
+	toOrder := make(map[*transition]bool, len(tg.transitions))
+	for _, it := range list {
+		if p, ok := it.(*place); ok {
+			if p.in != nil {
+				p.resetControlPoint(true)
+				toOrder[p.in] = true
+			}
+			if p.out != nil {
+				p.resetControlPoint(false)
+				toOrder[p.out] = true
+			}
+		}
+	}
+	for t := range toOrder {
+		t.OrderArcs(true)
+		t.OrderArcs(false)
+	}
+}
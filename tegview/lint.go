@@ -0,0 +1,138 @@
+package tegview
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severities of the diagnostics, the errors keep the analyses from
+// giving anything sensible while the warnings are only suspicious.
+const (
+	SeverityWarning = iota
+	SeverityError
+)
+
+// Diagnostic is a structural problem of the teg found by lint,
+// the item is named by Path and Id like the analyses name it.
+type Diagnostic struct {
+	Severity int
+	Path     string
+	Id       string
+	Text     string
+
+	// the item shown for the problem, the outermost group
+	// holding it when the item is in the model of a group
+	focus item
+}
+
+func (d *Diagnostic) String() string {
+	if d.Severity == SeverityError {
+		return "error: " + d.Text
+	}
+	return "warning: " + d.Text
+}
+
+type diagnostics []*Diagnostic
+
+func (d diagnostics) Len() int           { return len(d) }
+func (d diagnostics) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d diagnostics) Less(i, j int) bool { return d[i].Severity > d[j].Severity }
+
+// describe names the item in the diagnostics by its kind and label.
+func describe(kind, label string) string {
+	if label = strings.Join(strings.Fields(label), " "); len(label) > 0 {
+		return fmt.Sprintf("%s %q", kind, label)
+	}
+	return kind
+}
+
+// lint checks the structure of the teg and of the models of its groups,
+// the errors come first.
+func (tg *teg) lint() []*Diagnostic {
+	var list diagnostics
+	tg.lintAt("", "", nil, &list)
+	sort.Stable(list)
+	return list
+}
+
+// lintAt checks the model at the path, where names its group for the texts.
+func (tg *teg) lintAt(path, where string, focus item, list *diagnostics) {
+	report := func(severity int, it item, format string, args ...interface{}) {
+		d := &Diagnostic{
+			Severity: severity,
+			Path:     path,
+			Id:       it.Id(),
+			Text:     fmt.Sprintf(format, args...),
+			focus:    focus,
+		}
+		if len(path) > 0 {
+			d.Text += " in " + where
+		} else {
+			d.focus = it
+		}
+		*list = append(*list, d)
+	}
+
+	for _, p := range tg.places {
+		name := describe("place", p.net.Label)
+		if p.in == nil {
+			report(SeverityError, p, "%s has no upstream transition", name)
+		}
+		if p.out == nil {
+			report(SeverityError, p, "%s has no downstream transition", name)
+		}
+	}
+	for _, t := range tg.transitions {
+		if t.proxy != nil {
+			continue
+		}
+		name := describe("transition", t.net.Label)
+		switch {
+		case t.net.Kind == TransitionInput && len(t.in) > 0:
+			report(SeverityError, t, "%s is an input but has inbound arcs", name)
+		case t.net.Kind == TransitionOutput && len(t.out) > 0:
+			report(SeverityError, t, "%s is an output but has outbound arcs", name)
+		case len(t.in) < 1 && len(t.out) < 1:
+			report(SeverityWarning, t, "%s is isolated", name)
+		}
+	}
+	for _, g := range tg.groups {
+		name := describe("group", g.net.Label)
+		if g.model == nil {
+			report(SeverityError, g, "%s has no model", name)
+			continue
+		}
+		inner := make(map[*transition]bool, len(g.model.transitions))
+		for _, t := range g.model.transitions {
+			inner[t] = true
+		}
+		// the inputs and outputs must stand for transitions of the model
+		// of the same kinds, and iostate must lead from them back
+		check := func(io []*transition, kind int, what string) {
+			for _, t := range io {
+				if t.proxy == nil || !inner[t.proxy] || t.proxy.net.Kind != kind || g.iostate[t.proxy] != t {
+					report(SeverityError, g, "%s has an %s out of step with its model", name, what)
+				}
+			}
+		}
+		check(g.inputs, TransitionInput, "input")
+		check(g.outputs, TransitionOutput, "output")
+		for _, t := range g.model.transitions {
+			if t.proxy != nil || (t.net.Kind != TransitionInput && t.net.Kind != TransitionOutput) {
+				continue
+			}
+			if _, ok := g.iostate[t]; !ok {
+				report(SeverityError, g, "%s does not show %s", name, describe("transition", t.net.Label))
+			}
+		}
+		if len(g.iostate) != len(g.inputs)+len(g.outputs) {
+			report(SeverityError, g, "%s keeps stale inputs or outputs", name)
+		}
+		outer := focus
+		if outer == nil {
+			outer = g
+		}
+		g.model.lintAt(path+"/"+g.net.Id, name, outer, list)
+	}
+}
@@ -0,0 +1,83 @@
+package tegview
+
+import (
+	"testing"
+
+	"github.com/remogatto/prettytest"
+)
+
+type testSuite struct {
+	prettytest.Suite
+}
+
+func TestRunner(t *testing.T) {
+	prettytest.RunWithFormatter(
+		t,
+		new(prettytest.TDDFormatter),
+		new(testSuite),
+	)
+}
+
+// chain builds u → p → y in a new teg.
+func chain() (tg *teg, u *transition, p *place, y *transition) {
+	tg = newTeg()
+	u, y = tg.addTransition(0, 0), tg.addTransition(200, 0)
+	u.net.Kind, y.net.Kind = TransitionInput, TransitionOutput
+	u.net.Label, y.net.Label = "u", "y"
+	p = tg.addPlace(100, 0)
+	u.link(p, false)
+	y.link(p, true)
+	return
+}
+
+func (t *testSuite) TestLintClean() {
+	tg, _, _, _ := chain()
+	t.Equal(0, len(tg.lint()))
+}
+
+func (t *testSuite) TestLintItems() {
+	tg, _, _, y := chain()
+	p := tg.addPlace(100, 100)
+	p.net.Label = "dangling  place"
+	tg.addTransition(300, 100)
+	y.out = append(y.out, p)
+
+	list := tg.lint()
+	t.Equal(4, len(list))
+	// the errors come first, in the order of the items
+	t.Equal(`error: place "dangling place" has no upstream transition`, list[0].String())
+	t.Equal(`error: place "dangling place" has no downstream transition`, list[1].String())
+	t.Equal(`error: transition "y" is an output but has outbound arcs`, list[2].String())
+	t.Equal("warning: transition is isolated", list[3].String())
+	t.Equal("", list[0].Path)
+	t.Equal(p.Id(), list[0].Id)
+	t.True(list[0].focus == p)
+}
+
+func (t *testSuite) TestLintGroup() {
+	tg, u, p, y := chain()
+	g, _ := tg.addGroup(map[item]bool{u: true, p: true, y: true})
+	g.net.Label = "plant"
+	g.updateIO()
+	g.adjustIO()
+	t.Equal(0, len(tg.lint()))
+
+	// a place of the model loses its downstream transition
+	inner := g.model.places[0]
+	out := inner.out
+	inner.out = nil
+	list := tg.lint()
+	t.Equal(1, len(list))
+	t.Equal(`error: place has no downstream transition in group "plant"`, list[0].String())
+	t.Equal("/"+g.Id(), list[0].Path)
+	t.Equal(inner.Id(), list[0].Id)
+	t.True(list[0].focus == g)
+
+	// the outer input no longer stands for the one of the model
+	inner.out = out
+	g.inputs[0].proxy = nil
+	list = tg.lint()
+	t.Equal(1, len(list))
+	t.Equal(`error: group "plant" has an input out of step with its model`, list[0].String())
+	t.Equal("", list[0].Path)
+}
@@ -2,13 +2,13 @@ package tegview
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"math"
 	"sort"
 
 	"github.com/xlab/teg-workshop/geometry"
 	"github.com/xlab/teg-workshop/planeview"
+	core "github.com/xlab/teg-workshop/teg"
 	"github.com/xlab/teg-workshop/util"
 )
 
@@ -33,20 +33,10 @@ var (
 )
 
 const (
-	TransitionInternal = iota
-	TransitionInput
-	TransitionOutput
-	TransitionExposed
-)
-
-const (
-	PlaceInternal = iota
-	PlaceExposed
-)
-
-const (
-	GroupInternal = iota
-	GroupExposed
+	TransitionInternal = core.TransitionInternal
+	TransitionInput    = core.TransitionInput
+	TransitionOutput   = core.TransitionOutput
+	TransitionExposed  = core.TransitionExposed
 )
 
 const (
@@ -76,7 +66,6 @@ type item interface {
 	SetLabel(s string)
 	Label() string
 	Align() (float64, float64)
-	Copy() item
 	IsSelected() bool
 }
 
@@ -85,12 +74,13 @@ type controlPoint struct {
 	modified bool
 }
 
+// The items draw the places, transitions and groups of the core graph,
+// net holds what the model is made of: the ids, labels, tokens, kinds
+// and arcs. The arcs of the items and the inputs and outputs of groups
+// follow the graph, see teg.sync.
 type place struct {
 	*geometry.Circle
-	id         string
-	counter    int
-	timer      int
-	label      string
+	net        *core.Place
 	in         *transition
 	out        *transition
 	inControl  *controlPoint
@@ -100,25 +90,20 @@ type place struct {
 
 type transition struct {
 	*geometry.Rect
-	id         string
-	in         []*place
-	out        []*place
-	proxy      *transition
-	group      *group
-	label      string
-	horizontal bool
-	parent     *teg
-	kind       int
+	net    *core.Transition
+	in     []*place
+	out    []*place
+	proxy  *transition
+	group  *group
+	parent *teg
 }
 
 type group struct {
 	*geometry.Rect
-	id      string
+	net     *core.Group
 	iostate map[*transition]*transition
 	inputs  []*transition
 	outputs []*transition
-	label   string
-	folded  bool
 	model   *teg
 	parent  *teg
 }
@@ -128,95 +113,178 @@ type utility struct {
 	kind     int
 }
 
-func newPlace(x, y float64) *place {
-	return &place{
-		Circle: geometry.NewCircle(x, y, PlaceRadius),
-		id:     util.GenUUID(),
+// views maps the items of the core graphs to the items drawing them,
+// the tegs of a tree share it.
+type views map[interface{}]item
+
+func (tg *teg) wrapPlace(np *core.Place) *place {
+	p := &place{
+		Circle: geometry.NewCircle(np.X, np.Y, PlaceRadius),
+		net:    np,
+		parent: tg,
+	}
+	if np.In != nil && np.InControl != nil {
+		p.inControl = newControlPoint(np.InControl.X, np.InControl.Y, np.InControl.Modified)
+	}
+	if np.Out != nil && np.OutControl != nil {
+		p.outControl = newControlPoint(np.OutControl.X, np.OutControl.Y, np.OutControl.Modified)
 	}
+	tg.views[np] = p
+	return p
 }
 
-func newTransition(x, y float64) *transition {
-	return &transition{
-		Rect: geometry.NewRect(x-TransitionWidth/2, y-TransitionHeight/2,
-			TransitionWidth, TransitionHeight),
-		id: util.GenUUID(),
+// wrapTransition places the transition by its corner, like the models do.
+func (tg *teg) wrapTransition(nt *core.Transition) *transition {
+	t := &transition{
+		Rect:   geometry.NewRect(nt.X, nt.Y, TransitionWidth, TransitionHeight),
+		net:    nt,
+		parent: tg,
+	}
+	if nt.Horizontal {
+		t.Rect.Rotate(true)
 	}
+	tg.views[nt] = t
+	return t
 }
 
-func newGroup() *group {
-	return &group{
-		model:   newTeg(),
-		inputs:  make([]*transition, 0, 8),
-		outputs: make([]*transition, 0, 8),
-		id:      util.GenUUID(),
+func (tg *teg) wrapGroup(gr *core.Group, model *teg) *group {
+	g := &group{
+		Rect:   geometry.NewRect(gr.X, gr.Y, 0, 0),
+		net:    gr,
+		model:  model,
+		parent: tg,
 	}
+	model.parent = tg
+	tg.views[gr] = g
+	return g
 }
 
-func newControlPoint(x float64, y float64, modified bool) *controlPoint {
-	return &controlPoint{
-		geometry.NewRect(
-			x-ControlPointWidth/2,
-			y-ControlPointHeight/2,
-			ControlPointWidth, ControlPointHeight),
-		modified,
+// newModel returns the teg of a group's graph.
+func (tg *teg) newModel(net *core.Graph) *teg {
+	sub := newTeg()
+	sub.net = net
+	sub.views = tg.views
+	sub.parent = tg
+	return sub
+}
+
+func (tg *teg) placeOf(np *core.Place) *place {
+	if np == nil {
+		return nil
 	}
+	return tg.views[np].(*place)
 }
 
-type ControlPoint struct {
-	X, Y     float64
-	Modified bool
+func (tg *teg) transitionOf(nt *core.Transition) *transition {
+	if nt == nil {
+		return nil
+	}
+	return tg.views[nt].(*transition)
 }
 
-type Place struct {
-	Id         string
-	X, Y       float64
-	Counter    int
-	Timer      int
-	Label      string
-	InControl  *ControlPoint
-	OutControl *ControlPoint
+func (tg *teg) placesOf(list []*core.Place) []*place {
+	places := make([]*place, len(list))
+	for i, np := range list {
+		places[i] = tg.placeOf(np)
+	}
+	return places
 }
 
-type Transition struct {
-	Id         string
-	X, Y       float64
-	In, Out    []*Place
-	ProxyId    string
-	Label      string
-	Horizontal bool
-	Kind       int
+// sync brings the items in line with the graph after it has been edited:
+// the lists of the teg, the arcs, and the inputs and outputs of the groups.
+// The tegs of the groups are synced too.
+func (tg *teg) sync() {
+	places := make([]*place, len(tg.net.Places))
+	for i, np := range tg.net.Places {
+		places[i] = tg.placeOf(np)
+		places[i].parent = tg
+	}
+	transitions := make([]*transition, len(tg.net.Transitions))
+	for i, nt := range tg.net.Transitions {
+		transitions[i] = tg.transitionOf(nt)
+		transitions[i].parent = tg
+	}
+	groups := make([]*group, len(tg.net.Groups))
+	for i, gr := range tg.net.Groups {
+		g := tg.views[gr].(*group)
+		g.parent = tg
+		g.model.parent = tg
+		g.model.sync()
+		g.syncIO()
+		groups[i] = g
+	}
+	tg.places, tg.transitions, tg.groups = places, transitions, groups
+	for _, p := range tg.places {
+		p.in, p.out = tg.transitionOf(p.net.In), tg.transitionOf(p.net.Out)
+		if p.in == nil {
+			p.inControl = nil
+		}
+		if p.out == nil {
+			p.outControl = nil
+		}
+		p.refineControls()
+	}
+	for _, t := range tg.transitions {
+		t.in, t.out = tg.placesOf(t.net.In), tg.placesOf(t.net.Out)
+		t.refineSize()
+	}
 }
 
-type Group struct {
-	Id      string
-	X, Y    float64
-	Inputs  []*Transition
-	Outputs []*Transition
-	Iostate map[string]string
-	Label   string
-	Folded  bool
-	Model   *Teg
+// syncIO makes the items of the inputs and outputs of the group, the new
+// ones start where the transitions behind them are.
+func (g *group) syncIO() {
+	tg := g.parent
+	io := func(list []*core.Transition) []*transition {
+		ts := make([]*transition, len(list))
+		for i, nc := range list {
+			c, ok := tg.views[nc].(*transition)
+			if !ok {
+				c = tg.wrapTransition(nc)
+				if proxy := tg.transitionOf(nc.Proxy); proxy != nil {
+					c.Move(proxy.Center().X, proxy.Center().Y)
+				}
+			}
+			c.proxy = tg.transitionOf(nc.Proxy)
+			c.group = g
+			c.parent = tg
+			c.in, c.out = tg.placesOf(nc.In), tg.placesOf(nc.Out)
+			c.refineSize()
+			ts[i] = c
+		}
+		return ts
+	}
+	g.inputs, g.outputs = io(g.net.Inputs), io(g.net.Outputs)
+	g.iostate = make(map[*transition]*transition, len(g.inputs)+len(g.outputs))
+	for _, t := range g.inputs {
+		g.iostate[t.proxy] = t
+	}
+	for _, t := range g.outputs {
+		g.iostate[t.proxy] = t
+	}
 }
 
-type Teg struct {
-	Id          string
-	Places      []*Place
-	Transitions []*Transition
-	Groups      []*Group
+func newControlPoint(x float64, y float64, modified bool) *controlPoint {
+	return &controlPoint{
+		geometry.NewRect(
+			x-ControlPointWidth/2,
+			y-ControlPointHeight/2,
+			ControlPointWidth, ControlPointHeight),
+		modified,
+	}
 }
 
-func (cp *controlPoint) Model() *ControlPoint {
-	return &ControlPoint{cp.X(), cp.Y(), cp.modified}
+func (cp *controlPoint) Model() *core.ControlPoint {
+	return &core.ControlPoint{X: cp.X(), Y: cp.Y(), Modified: cp.modified}
 }
 
-func (p *place) Model() *Place {
-	model := &Place{
-		Id: p.id,
+func (p *place) Model() *core.PlaceModel {
+	model := &core.PlaceModel{
+		Id: p.net.Id,
 		X:  p.Center().X, Y: p.Center().Y,
 
-		Counter: p.counter,
-		Timer:   p.timer,
-		Label:   p.label,
+		Counter: p.net.Counter,
+		Timer:   p.net.Timer,
+		Label:   p.net.Label,
 	}
 	if p.in != nil {
 		model.InControl = p.inControl.Model()
@@ -227,20 +295,20 @@ func (p *place) Model() *Place {
 	return model
 }
 
-func (t *transition) Model() *Transition {
-	model := &Transition{
-		Id: t.id,
+func (t *transition) Model() *core.TransitionModel {
+	model := &core.TransitionModel{
+		Id: t.net.Id,
 		X:  t.X(), Y: t.Y(),
 
-		Label:      t.label,
-		Kind:       t.kind,
-		Horizontal: t.horizontal,
+		Label:      t.net.Label,
+		Kind:       t.net.Kind,
+		Horizontal: t.net.Horizontal,
 
-		In:  make([]*Place, len(t.in)),
-		Out: make([]*Place, len(t.out)),
+		In:  make([]*core.PlaceModel, len(t.in)),
+		Out: make([]*core.PlaceModel, len(t.out)),
 	}
 	if t.proxy != nil {
-		model.ProxyId = t.proxy.id
+		model.ProxyId = t.proxy.net.Id
 	}
 	for i, p := range t.in {
 		model.In[i] = p.Model()
@@ -251,17 +319,17 @@ func (t *transition) Model() *Transition {
 	return model
 }
 
-func (g *group) Model(copy bool) *Group {
-	model := &Group{
-		Id: g.id,
+func (g *group) Model(copy bool) *core.GroupModel {
+	model := &core.GroupModel{
+		Id: g.net.Id,
 		X:  g.X(), Y: g.Y(),
 
-		Label:  g.label,
-		Folded: g.folded,
+		Label:  g.net.Label,
+		Folded: g.net.Folded,
 		Model:  g.model.Model(copy),
 
-		Inputs:  make([]*Transition, len(g.inputs)),
-		Outputs: make([]*Transition, len(g.outputs)),
+		Inputs:  make([]*core.TransitionModel, len(g.inputs)),
+		Outputs: make([]*core.TransitionModel, len(g.outputs)),
 		Iostate: make(map[string]string, len(g.inputs)+len(g.outputs)),
 	}
 	if copy {
@@ -274,18 +342,18 @@ func (g *group) Model(copy bool) *Group {
 		model.Outputs[i] = t.Model()
 	}
 	for proxy, io := range g.iostate {
-		model.Iostate[io.id] = proxy.id
+		model.Iostate[io.net.Id] = proxy.net.Id
 	}
 	return model
 }
 
-func (tg *teg) Model(copy bool) *Teg {
-	model := &Teg{
-		Id: tg.id,
+func (tg *teg) Model(copy bool) *core.Model {
+	model := &core.Model{
+		Id: tg.net.Id,
 
-		Places:      make([]*Place, 0, len(tg.places)),
-		Transitions: make([]*Transition, len(tg.transitions)),
-		Groups:      make([]*Group, len(tg.groups)),
+		Places:      make([]*core.PlaceModel, 0, len(tg.places)),
+		Transitions: make([]*core.TransitionModel, len(tg.transitions)),
+		Groups:      make([]*core.GroupModel, len(tg.groups)),
 	}
 	if copy {
 		model.Id = util.GenUUID()
@@ -308,10 +376,25 @@ func (tg *teg) Model(copy bool) *Teg {
 	for i, g := range tg.groups {
 		model.Groups[i] = g.Model(copy)
 	}
+	if copy {
+		// the copied transitions get new ids
+		return model
+	}
+	for _, t := range tg.transitions {
+		if info, ok := tg.infos[t.net.Id]; ok {
+			model.Infos = append(model.Infos, &core.Info{
+				IoId:      t.net.Id,
+				Color:     info.Color(),
+				Enabled:   info.Enabled(),
+				Reference: info.IsReference(),
+				Serie:     info.Dioid(),
+			})
+		}
+	}
 	return model
 }
 
-func (tg *teg) ModelItems(items map[item]bool) *Teg {
+func (tg *teg) ModelItems(items map[item]bool) *core.Model {
 	sub := newTeg()
 	for it := range items {
 		if p, ok := it.(*place); ok {
@@ -325,11 +408,20 @@ func (tg *teg) ModelItems(items map[item]bool) *Teg {
 	return sub.Model(true)
 }
 
-func (tg *teg) ConstructItems(model *Teg) (items map[item]bool) {
+// ConstructItems adds copies of the items of the model to the teg.
+func (tg *teg) ConstructItems(model *core.Model) (items map[item]bool, err error) {
 	sub := newTeg()
-	sub.Construct(model)
-	items = sub.Items()
-	tg.transferItems(sub, items)
+	sub.views = tg.views
+	if err = sub.Construct(model); err != nil {
+		return
+	}
+	items = make(map[item]bool)
+	for _, it := range tg.cloneItems(sub.Items()) {
+		items[it] = true
+		if g, ok := it.(*group); ok {
+			g.net.Graph.Parent = tg.net
+		}
+	}
 	return
 }
 
@@ -349,31 +441,6 @@ func (tg *teg) MarshalJSON() ([]byte, error) {
 	return json.Marshal(tg.Model(false))
 }
 
-func constructTransition(model *Transition) *transition {
-	t := &transition{
-		Rect:       geometry.NewRect(model.X, model.Y, TransitionWidth, TransitionHeight),
-		id:         model.Id,
-		horizontal: model.Horizontal,
-		label:      model.Label,
-		kind:       model.Kind,
-	}
-	if t.horizontal {
-		t.Rect.Rotate(t.horizontal)
-	}
-	return t
-}
-
-func constructPlace(model *Place) *place {
-	p := &place{
-		Circle:  geometry.NewCircle(model.X, model.Y, PlaceRadius),
-		id:      model.Id,
-		timer:   model.Timer,
-		counter: model.Counter,
-		label:   model.Label,
-	}
-	return p
-}
-
 func (tg *teg) findById(id string) item {
 	for it := range tg.Items() {
 		if it.Id() == id {
@@ -383,158 +450,73 @@ func (tg *teg) findById(id string) item {
 	return nil
 }
 
-func (tg *teg) Construct(model *Teg) {
-	tg.id = model.Id
-	submodels := make(map[string]*teg, len(model.Groups))
-	madePlaces := make(map[string]*place, 256)
-	for _, g := range model.Groups {
-		gNew := newGroup()
-		gNew.id = g.Id
-		gNew.label = g.Label
-		gNew.folded = g.Folded
-		gNew.parent = tg
-
-		sub, ok := submodels[g.Model.Id]
-		if !ok {
-			sub = newTeg()
-			sub.Construct(g.Model)
-			sub.parent = tg
-			submodels[g.Model.Id] = sub
-		}
-
-		gNew.model = sub
-		gNew.Rect = geometry.NewRect(g.X, g.Y, 0, 0)
-		for _, t := range g.Inputs {
-			tNew := constructTransition(t)
-			proxId, ok := g.Iostate[t.Id]
-			if !ok {
-				panic(errors.New("group constructing: iostate broken"))
-			}
-			tNew.group = gNew
-			tNew.proxy = gNew.model.findById(proxId).(*transition)
-			tNew.parent = tg
-			for _, p := range t.In {
-				pNew, ok := madePlaces[p.Id]
-				if !ok {
-					pNew = constructPlace(p)
-					pNew.parent = tg
-					madePlaces[p.Id] = pNew
-					tg.places = append(tg.places, pNew)
-				}
-				pNew.out = tNew
-				tNew.in = append(tNew.in, pNew)
-				if p.OutControl != nil {
-					pNew.outControl = newControlPoint(p.OutControl.X, p.OutControl.Y, p.OutControl.Modified)
-				}
-				pNew.refineControls()
-			}
-			for _, p := range t.Out {
-				pInner := gNew.model.findById(p.Id).(*place)
-				tNew.out = append(tNew.out, pInner)
-			}
-			tNew.refineSize()
-			gNew.inputs = append(gNew.inputs, tNew)
+// Construct builds the graph of the model, see core.Build, and the items
+// drawing it.
+func (tg *teg) Construct(model *core.Model) error {
+	net, err := core.Build(model)
+	if err != nil {
+		return err
+	}
+	tg.wrap(net, make(map[*core.Graph]*teg))
+	for _, info := range model.Infos {
+		t, ok := tg.findById(info.IoId).(*transition)
+		if !ok || t.proxy != nil || t.net.Kind == TransitionInternal {
+			continue
 		}
-		for _, t := range g.Outputs {
-			tNew := constructTransition(t)
-			proxId := g.Iostate[t.Id]
-			tNew.group = gNew
-			tNew.proxy = gNew.model.findById(proxId).(*transition)
-			tNew.parent = tg
-			for _, p := range t.Out {
-				pNew, ok := madePlaces[p.Id]
-				if !ok {
-					pNew = constructPlace(p)
-					pNew.parent = tg
-					madePlaces[p.Id] = pNew
-					tg.places = append(tg.places, pNew)
-				}
-				pNew.in = tNew
-				tNew.out = append(tNew.out, pNew)
-				if p.InControl != nil {
-					pNew.inControl = newControlPoint(p.InControl.X, p.InControl.Y, p.InControl.Modified)
-				}
-				pNew.refineControls()
-			}
-			for _, p := range t.In {
-				pInner := gNew.model.findById(p.Id).(*place)
-				tNew.in = append(tNew.in, pInner)
-			}
-			tNew.refineSize()
-			gNew.outputs = append(gNew.outputs, tNew)
+		// labels are given by updateInfos
+		plane := planeview.NewPlane(t.net.Id, "", t.net.Kind == TransitionInput)
+		plane.SetEdits(tg.edits)
+		plane.SetColor(info.Color)
+		plane.SetEnabled(info.Enabled)
+		plane.SetReference(info.Reference)
+		if info.Serie.P != nil || info.Serie.Q != nil {
+			plane.SetDioid(info.Serie)
 		}
+		tg.infos[t.net.Id] = plane
+	}
+	return nil
+}
 
-		gNew.iostate = make(map[*transition]*transition, len(gNew.inputs)+len(gNew.outputs))
-		for _, t := range gNew.inputs {
-			if t.proxy != nil {
-				gNew.iostate[t.proxy] = t
-			}
-		}
-		for _, t := range gNew.outputs {
-			if t.proxy != nil {
-				gNew.iostate[t.proxy] = t
-			}
+// wrap makes the items of the graph, the groups sharing a graph share
+// its teg too.
+func (tg *teg) wrap(net *core.Graph, models map[*core.Graph]*teg) {
+	tg.net = net
+	for _, np := range net.Places {
+		tg.wrapPlace(np)
+	}
+	for _, nt := range net.Transitions {
+		tg.wrapTransition(nt)
+	}
+	for _, gr := range net.Groups {
+		sub, ok := models[gr.Graph]
+		if !ok {
+			sub = tg.newModel(gr.Graph)
+			sub.wrap(gr.Graph, models)
+			models[gr.Graph] = sub
 		}
-
-		tg.groups = append(tg.groups, gNew)
-		gNew.updateBounds(false)
-		gNew.updateIO()
-		gNew.adjustIO()
-		gNew.Align()
-	}
-	for _, t := range model.Transitions {
-		tNew := constructTransition(t)
-		tNew.parent = tg
-		for _, p := range t.In {
-			pNew, ok := madePlaces[p.Id]
-			if !ok {
-				pNew = constructPlace(p)
-				pNew.parent = tg
-				madePlaces[p.Id] = pNew
-				tg.places = append(tg.places, pNew)
-			}
-			pNew.out = tNew
-			tNew.in = append(tNew.in, pNew)
-			if p.OutControl != nil {
-				pNew.outControl = newControlPoint(p.OutControl.X, p.OutControl.Y, p.OutControl.Modified)
-			}
-			pNew.refineControls()
+		tg.wrapGroup(gr, sub)
+		for _, nt := range gr.Inputs {
+			tg.wrapTransition(nt)
 		}
-		for _, p := range t.Out {
-			pNew, ok := madePlaces[p.Id]
-			if !ok {
-				pNew = constructPlace(p)
-				pNew.parent = tg
-				madePlaces[p.Id] = pNew
-				tg.places = append(tg.places, pNew)
-			}
-			pNew.in = tNew
-			tNew.out = append(tNew.out, pNew)
-			if p.InControl != nil {
-				pNew.inControl = newControlPoint(p.InControl.X, p.InControl.Y, p.InControl.Modified)
-			}
-			pNew.refineControls()
+		for _, nt := range gr.Outputs {
+			tg.wrapTransition(nt)
 		}
-		tNew.refineSize()
-		tg.transitions = append(tg.transitions, tNew)
 	}
-	for _, p := range model.Places {
-		_, ok := madePlaces[p.Id]
-		if !ok {
-			pNew := constructPlace(p)
-			pNew.parent = tg
-			tg.places = append(tg.places, pNew)
-		}
+	tg.sync()
+	for _, g := range tg.groups {
+		g.updateBounds(false)
+		g.updateIO()
+		g.adjustIO()
+		g.Align()
 	}
 }
 
 func (tg *teg) UnmarshalJSON(data []byte) (err error) {
-	m := &Teg{}
+	m := &core.Model{}
 	if err = json.Unmarshal(data, m); err != nil {
 		return
 	}
-	tg.Construct(m)
-	return
+	return tg.Construct(m)
 }
 
 type places []*place
@@ -570,7 +552,7 @@ func (t transitions) Len() int      { return len(t) }
 func (t transitions) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
 func (t transitionsByMedian) Less(i, j int) bool {
 	if t.inputs {
-		h1, h2 := t.transitions[i].horizontal, t.transitions[j].horizontal
+		h1, h2 := t.transitions[i].net.Horizontal, t.transitions[j].net.Horizontal
 		i1, i2 := t.transitions[i].proxy.out, t.transitions[j].proxy.out
 		diff := places(i2).calcMedian(h2) - places(i1).calcMedian(h1)
 		if math.Abs(diff) > 1 {
@@ -579,7 +561,7 @@ func (t transitionsByMedian) Less(i, j int) bool {
 			return places(i2).calcMedian(!h2) < places(i1).calcMedian(!h1)
 		}
 	} else {
-		h1, h2 := t.transitions[i].horizontal, t.transitions[j].horizontal
+		h1, h2 := t.transitions[i].net.Horizontal, t.transitions[j].net.Horizontal
 		o1, o2 := t.transitions[i].proxy.in, t.transitions[j].proxy.in
 		diff := places(o2).calcMedian(h2) - places(o1).calcMedian(h1)
 		if math.Abs(diff) > 1 {
@@ -603,19 +585,27 @@ func (p places) calcMedian(horizontal bool) (median float64) {
 	return median / float64(len(p))
 }
 
+// OrderArcs sorts the arcs of t along its side, the arcs of the graph
+// are kept in the same order.
 func (t *transition) OrderArcs(inbound bool) {
 	if inbound {
-		if t.horizontal {
+		if t.net.Horizontal {
 			sort.Sort(placesByX{t.in})
 		} else {
 			sort.Sort(placesByY{t.in})
 		}
+		for i, p := range t.in {
+			t.net.In[i] = p.net
+		}
 	} else {
-		if t.horizontal {
+		if t.net.Horizontal {
 			sort.Sort(placesByX{t.out})
 		} else {
 			sort.Sort(placesByY{t.out})
 		}
+		for i, p := range t.out {
+			t.net.Out[i] = p.net
+		}
 	}
 }
 
@@ -636,11 +626,13 @@ func (p *place) shiftControls(dx, dy float64) {
 	}
 }
 
+// refineControls follows the transitions with the control points the user
+// did not move, the missing ones are made, PNML nets do not store them.
 func (p *place) refineControls() {
-	if p.in != nil && p.inControl != nil && !p.inControl.modified {
+	if p.in != nil && (p.inControl == nil || !p.inControl.modified) {
 		p.resetControlPoint(true)
 	}
-	if p.out != nil && p.outControl != nil && !p.outControl.modified {
+	if p.out != nil && (p.outControl == nil || !p.outControl.modified) {
 		p.resetControlPoint(false)
 	}
 }
@@ -670,55 +662,6 @@ func (g *group) Shift(dx, dy float64) {
 	}
 }
 
-func (p *place) Copy() item {
-	clonemap := make(map[item]item, 1)
-	pNew := newPlace(p.Center().X, p.Center().Y)
-	pNew.label = p.label
-	pNew.parent = p.parent
-	pNew.counter = p.counter
-	pNew.timer = p.timer
-	clonemap[p] = pNew
-	return item(pNew)
-}
-
-func (t *transition) Copy() item {
-	clonemap := make(map[item]item, 1)
-	tNew := newTransition(t.Center().X, t.Center().Y)
-	tNew.label = t.label
-	tNew.proxy = t.proxy
-	tNew.group = t.group
-	tNew.kind = t.kind
-	if t.horizontal {
-		tNew.rotate()
-	}
-	clonemap[t] = tNew
-	return item(tNew)
-}
-
-func (g *group) Copy() item {
-	gNew := newGroup()
-	/*
-		items := make(map[item]bool, len(g.model.places)+
-			len(g.model.transitions)+len(g.model.groups))
-		for _, p := range g.model.places {
-			items[p] = true
-		}
-		for _, t := range g.model.transitions {
-			items[t] = true
-		}
-		for _, g := range g.model.groups {
-			items[g] = true
-		}
-		clonemap := group.model.cloneItems(items)
-	*/
-	gNew.Rect = geometry.NewRect(g.X(), g.Y(), 0, 0)
-	gNew.model = g.model
-	gNew.label = g.label
-	gNew.parent = g.parent
-	gNew.folded = g.folded
-	return item(gNew)
-}
-
 func (t *transition) BorderPoint(inbound bool, index int) *geometry.Point {
 	var count int
 	if inbound {
@@ -788,142 +731,15 @@ func (c *controlPoint) Move(x, y float64) {
 }
 
 func (t *transition) rotate() {
-	t.horizontal = !t.horizontal
-	t.Rect.Rotate(t.horizontal)
+	t.net.Horizontal = !t.net.Horizontal
+	t.Rect.Rotate(t.net.Horizontal)
 	t.OrderArcs(true)
 	t.OrderArcs(false)
 }
 
-func (tg *teg) findProxy(t *transition) (proxy *transition, ok bool) {
-	for _, g := range tg.groups {
-		for _, t2 := range g.inputs {
-			if t2.proxy == t {
-				return t2, true
-			}
-		}
-		for _, t2 := range g.outputs {
-			if t2.proxy == t {
-				return t2, true
-			}
-		}
-	}
-	if tg.parent != nil {
-		return tg.parent.findProxy(t)
-	}
-	return nil, false
-}
-
-func (p *place) KindInGroup(items map[item]bool) int {
-	inFound, outFound := false, false
-	if p.in == nil {
-		inFound = true
-	} else if p.in.parent.parent != nil {
-		if proxy, ok := p.in.parent.parent.findProxy(p.in); ok {
-			if items[proxy.group] {
-				inFound = true
-			}
-		}
-	}
-	if p.out == nil {
-		outFound = true
-	} else if p.out.parent.parent != nil {
-		if proxy, ok := p.out.parent.parent.findProxy(p.out); ok {
-			if items[proxy.group] {
-				outFound = true
-			}
-		}
-	}
-	if !inFound || !outFound {
-		for it := range items {
-			if t, ok := it.(*transition); ok {
-				if t == p.in {
-					inFound = true
-				}
-				if t == p.out {
-					outFound = true
-				}
-			}
-		}
-	}
-	if !inFound || !outFound {
-		return PlaceExposed
-	}
-	return PlaceInternal
-}
-
-func (g *group) KindInGroup(items map[item]bool) int {
-	inFound, outFound := false, false
-	if len(g.outputs) < 1 {
-		outFound = true
-	}
-	if len(g.inputs) < 1 {
-		inFound = true
-	}
-	if !outFound || !inFound {
-		for it := range items {
-			if p, ok := it.(*place); ok {
-				for _, t := range g.inputs {
-					if t == p.out || t.proxy == p.out {
-						inFound = true
-					}
-				}
-				for _, t := range g.outputs {
-					if t == p.in || t.proxy == p.in {
-						outFound = true
-					}
-				}
-			}
-		}
-	}
-	if !outFound || !inFound {
-		return GroupExposed
-	}
-	return GroupInternal
-}
-
-func (t *transition) KindInGroup(items map[item]bool) int {
-	if t.proxy != nil {
-		return TransitionInternal
-	}
-	var interlinkIn, interlinkOut, outlinkIn, outlinkOut bool
-	for _, p := range t.in {
-		found := false
-		for it := range items {
-			if p2, ok := it.(*place); ok {
-				if p2 == p {
-					found = true
-					interlinkIn = true
-				}
-			}
-		}
-		outlinkIn = outlinkIn || !found
-	}
-	for _, p := range t.out {
-		found := false
-		for it := range items {
-			if p2, ok := it.(*place); ok {
-				if p2 == p {
-					found = true
-					interlinkOut = true
-				}
-			}
-		}
-		outlinkOut = outlinkOut || !found
-	}
-	switch {
-	case !interlinkIn && (outlinkIn || interlinkOut) && !outlinkOut:
-		return TransitionInput
-	case !interlinkOut && (outlinkOut || interlinkIn) && !outlinkIn:
-		return TransitionOutput
-	case !(outlinkIn || outlinkOut):
-		return TransitionInternal
-	}
-	return TransitionExposed
-}
-
 func (t *transition) Has(x, y float64) bool {
 	var radius float64
-	if t.horizontal {
+	if t.net.Horizontal {
 		radius = (t.Width() + 6.0) / 2
 	} else {
 		radius = (t.Height() + 6.0) / 2
@@ -940,21 +756,21 @@ func (g *group) Bound() *geometry.Rect {
 }
 
 func (t *transition) resetProperties() {
-	t.label = ""
-	if t.horizontal {
+	t.net.Label = ""
+	if t.net.Horizontal {
 		t.rotate()
 	}
 }
 
 func (g *group) resetProperties() {
-	g.label = ""
+	g.net.Label = ""
 	for _, t := range g.inputs {
-		if t.horizontal {
+		if t.net.Horizontal {
 			t.rotate()
 		}
 	}
 	for _, t := range g.outputs {
-		if t.horizontal {
+		if t.net.Horizontal {
 			t.rotate()
 		}
 	}
@@ -963,7 +779,7 @@ func (g *group) resetProperties() {
 }
 
 func (p *place) resetProperties() {
-	p.label = ""
+	p.net.Label = ""
 	if p.in != nil {
 		p.resetControlPoint(true)
 	}
@@ -998,39 +814,39 @@ func (p *place) resetControlPoint(inbound bool) {
 }
 
 func (p *place) Id() string {
-	return p.id
+	return p.net.Id
 }
 
 func (t *transition) Id() string {
-	return t.id
+	return t.net.Id
 }
 
 func (g *group) Id() string {
-	return g.id
+	return g.net.Id
 }
 
 func (p *place) Label() string {
-	return p.label
+	return p.net.Label
 }
 
 func (t *transition) Label() string {
-	return t.label
+	return t.net.Label
 }
 
 func (g *group) Label() string {
-	return g.label
+	return g.net.Label
 }
 
 func (p *place) SetLabel(s string) {
-	p.label = s
+	p.net.Label = s
 }
 
 func (t *transition) SetLabel(s string) {
-	t.label = s
+	t.net.Label = s
 }
 
 func (g *group) SetLabel(s string) {
-	g.label = s
+	g.net.Label = s
 }
 
 type teg struct {
@@ -1043,7 +859,15 @@ type teg struct {
 	infos       map[string]*planeview.Plane
 	updated     chan interface{}
 	updatedInfo chan interface{}
-	id          string
+	edits       chan string
+	failures    chan error
+	failure     string
+	built       graphCache
+	transferSig string
+	cycle       *core.CycleTime
+	history     *history
+	net         *core.Graph
+	views       views
 }
 
 func detectBounds(items map[item]bool) (x0, y0, x1, y1 float64) {
@@ -1119,7 +943,7 @@ func (tg *teg) update() {
 func (t *teg) updateParentGroups() {
 	if t.parent != nil {
 		for _, g := range t.parent.groups {
-			folded := g.folded
+			folded := g.net.Folded
 			if !folded {
 				t.parent.foldGroup(g)
 			}
@@ -1135,104 +959,14 @@ func (t *teg) updateParentGroups() {
 	}
 }
 
+// updateIO rebuilds the inputs and outputs of the group after its teg has
+// been edited, see core.Group.UpdateIO.
 func (g *group) updateIO() {
 	if g.model == nil {
 		return
 	}
-
-	g.inputs = make([]*transition, 0, len(g.model.transitions))
-	g.outputs = make([]*transition, 0, len(g.model.transitions))
-
-	for _, t := range g.model.transitions {
-		kind := t.kind
-		if kind == TransitionInternal {
-			// try to guess
-			kind = t.KindInGroup(g.model.Items())
-			if kind == TransitionInternal {
-				continue
-			}
-		}
-		c := t.Copy().(*transition)
-		c.proxy = t
-		c.group = g
-		c.parent = g.parent
-		if old, ok := g.iostate[t]; ok {
-			switch kind {
-			case TransitionInput:
-				for _, p := range old.in {
-					c.in = append(c.in, p)
-					p.out = c
-				}
-				for _, p := range t.out {
-					c.out = append(c.out, p)
-				}
-			case TransitionOutput:
-				for _, p := range old.out {
-					c.out = append(c.out, p)
-					p.in = c
-				}
-				for _, p := range t.in {
-					c.in = append(c.in, p)
-				}
-			}
-		} else {
-			in := make(map[*place]bool, len(t.in))
-			out := make(map[*place]bool, len(t.out))
-			for _, p := range t.in {
-				c.in = append(c.in, p)
-				in[p] = true
-			}
-			for _, p := range t.out {
-				c.out = append(c.out, p)
-				out[p] = true
-			}
-			switch kind {
-			case TransitionInput:
-				for p := range in {
-					t.unlink(p, true, true)
-					p.out = c
-					p.resetControlPoint(false)
-				}
-			case TransitionOutput:
-				for p := range out {
-					t.unlink(p, false, true)
-					p.in = c
-					p.resetControlPoint(true)
-				}
-			}
-		}
-		switch kind {
-		case TransitionInput:
-			t.kind = TransitionInput
-			c.kind = TransitionOutput // for parent tg
-			g.inputs = append(g.inputs, c)
-		case TransitionOutput:
-			t.kind = TransitionOutput
-			c.kind = TransitionInput // for parent tg
-			g.outputs = append(g.outputs, c)
-		}
-		if old, ok := g.iostate[t]; ok {
-			if old.horizontal != c.horizontal {
-				c.rotate()
-			}
-			if len(old.label) > 0 {
-				c.label = old.label
-			}
-		}
-	}
-
-	g.iostate = make(map[*transition]*transition, len(g.inputs)+len(g.outputs))
-
-	for _, t := range g.inputs {
-		if t.proxy != nil {
-			g.iostate[t.proxy] = t
-		}
-	}
-	for _, t := range g.outputs {
-		if t.proxy != nil {
-			g.iostate[t.proxy] = t
-		}
-	}
+	g.net.UpdateIO()
+	g.parent.sync()
 }
 
 func (g *group) adjustIO() {
@@ -1244,7 +978,7 @@ func (g *group) adjustIO() {
 	var offi, offj float64
 	for _, t := range g.inputs {
 		t.refineSize()
-		if t.horizontal {
+		if t.net.Horizontal {
 			base := g.X() + GroupMargin
 			t.Move(base+offi+t.Width()/2, g.Y())
 			offi += t.Width() + GroupIOSpacing
@@ -1260,7 +994,7 @@ func (g *group) adjustIO() {
 	offi, offj = 0.0, 0.0
 	for _, t := range g.outputs {
 		t.refineSize()
-		if t.horizontal {
+		if t.net.Horizontal {
 			base := g.X() + g.Width() - GroupMargin
 			t.Move(base-offi-t.Width()/2, g.Y()+g.Height())
 			offi += t.Width() + GroupIOSpacing
@@ -1279,7 +1013,7 @@ func (g *group) getFoldedSize() (w, h float64) {
 	var inWidth, outWidth, inHeight, outHeight float64
 	for _, t := range g.inputs {
 		t.refineSize()
-		if t.horizontal {
+		if t.net.Horizontal {
 			inWidth += t.Width() + GroupIOSpacing
 		} else {
 			inHeight += t.Height() + GroupIOSpacing
@@ -1287,7 +1021,7 @@ func (g *group) getFoldedSize() (w, h float64) {
 	}
 	for _, t := range g.outputs {
 		t.refineSize()
-		if t.horizontal {
+		if t.net.Horizontal {
 			outWidth += t.Width() + GroupIOSpacing
 		} else {
 			outHeight += t.Height() + GroupIOSpacing
@@ -1308,7 +1042,7 @@ func (g *group) updateBounds(move bool) {
 	}
 	var w, h float64
 	x0, y0, x1, y1 := detectBounds(g.model.Items())
-	if !g.folded {
+	if !g.net.Folded {
 		w, h = (x1-x0)+2*GroupMargin, (y1-y0)+2*GroupMargin
 		k := math.Ceil(w / GridDefaultGap)
 		w = k * GridDefaultGap
@@ -1331,93 +1065,89 @@ func (g *group) updateBounds(move bool) {
 }
 
 func (tg *teg) addPlace(x, y float64) *place {
-	place := newPlace(x, y)
-	place.parent = tg
-	tg.places = append(tg.places, place)
-	return place
+	p := tg.wrapPlace(tg.net.AddPlace(x, y))
+	tg.places = append(tg.places, p)
+	return p
 }
 
-func (tg *teg) addGroup(items map[item]bool) *group {
-	group := newGroup()
-	group.parent = tg
-	group.model.parent = tg
-	group.model.transferItems(tg, items)
+// pick returns the items of the teg that are in items, in the order of
+// the teg. The inputs and outputs of groups are left out.
+func (tg *teg) pick(items map[item]bool) (places []*place, transitions []*transition, groups []*group) {
+	for _, p := range tg.places {
+		if items[p] {
+			places = append(places, p)
+		}
+	}
+	for _, t := range tg.transitions {
+		if items[t] {
+			transitions = append(transitions, t)
+		}
+	}
+	for _, g := range tg.groups {
+		if items[g] {
+			groups = append(groups, g)
+		}
+	}
+	return
+}
+
+func nets(places []*place, transitions []*transition, groups []*group) (
+	[]*core.Place, []*core.Transition, []*core.Group) {
+	np := make([]*core.Place, len(places))
+	for i, p := range places {
+		np[i] = p.net
+	}
+	nt := make([]*core.Transition, len(transitions))
+	for i, t := range transitions {
+		nt[i] = t.net
+	}
+	ng := make([]*core.Group, len(groups))
+	for i, g := range groups {
+		ng[i] = g.net
+	}
+	return np, nt, ng
+}
+
+// addGroup moves the items into a new group, see core.Graph.AddGroup.
+func (tg *teg) addGroup(items map[item]bool) (*group, error) {
+	gr, err := tg.net.AddGroup(nets(tg.pick(items)))
+	if err != nil {
+		return nil, err
+	}
+	group := tg.wrapGroup(gr, tg.newModel(gr.Graph))
+	tg.sync()
 	group.updateBounds(true)
 	shift := calcItemsShift(pt(0, 0), items)
 	for it := range items {
 		it.Shift(shift.X, shift.Y)
 		it.Align()
 	}
-	tg.groups = append(tg.groups, group)
-	return group
-}
-
-type linkPair struct {
-	t  *transition
-	p  *place
-	in bool
+	return group, nil
 }
 
+// flatGroup puts copies of the items of the group in its place, see
+// core.Graph.Ungroup, and returns them.
 func (tg *teg) flatGroup(g *group) map[item]bool {
-	items := make(map[item]bool, len(g.model.Items()))
-	clones := tg.cloneItems(g.model.Items())
+	np, nt, ng := tg.net.Ungroup(g.net)
+	clones := tg.wrapClones(g.model.places, g.model.transitions, g.model.groups, np, nt, ng)
+	g.model, g.parent = nil, nil
+	items := make(map[item]bool, len(clones))
 	for _, it := range clones {
 		items[it] = true
-		if t, ok := it.(*transition); ok {
-			t.kind = TransitionInternal
-		}
 	}
-	toClean := make(map[*transition]bool)
-	toLink := make([]linkPair, 0, 32)
-	for _, t := range g.inputs {
-		if t.proxy != nil {
-			for _, p := range t.in {
-				p.out = nil
-				p.outControl = nil
-				inner := clones[t.proxy].(*transition)
-				toClean[inner] = true
-				toLink = append(toLink, linkPair{t: inner, p: p, in: true})
-			}
-			t.in = nil
-		}
-	}
-	for _, t := range g.outputs {
-		if t.proxy != nil {
-			for _, p := range t.out {
-				p.in = nil
-				p.inControl = nil
-				inner := clones[t.proxy].(*transition)
-				toClean[inner] = false
-				toLink = append(toLink, linkPair{t: inner, p: p, in: false})
-			}
-			t.out = nil
-		}
-	}
-	for t, inbound := range toClean {
-		if inbound {
-			t.in = make([]*place, 0, 8)
-		} else {
-			t.out = make([]*place, 0, 8)
-		}
-	}
-	for _, pair := range toLink {
-		pair.t.link(pair.p, pair.in)
-	}
-
 	shift := calcItemsShift(g.Center(), items)
 	for it := range items {
 		it.Shift(shift.X, shift.Y)
 	}
-	tg.removeGroup(g)
 	return items
 }
 
 func (tg *teg) foldGroup(g *group) {
-	if g.folded {
+	if g.net.Folded {
 		return
 	}
 	w1, h1 := g.Width(), g.Height()
-	g.folded = true
+	g.net.Folded = true
 	g.updateBounds(false)
 	g.adjustIO()
 	w2, h2 := g.Width(), g.Height()
@@ -1434,11 +1164,11 @@ func (tg *teg) foldGroup(g *group) {
 }
 
 func (tg *teg) unfoldGroup(g *group) {
-	if !g.folded {
+	if !g.net.Folded {
 		return
 	}
 	w1, h1 := g.Width(), g.Height()
-	g.folded = false
+	g.net.Folded = false
 	g.updateBounds(false)
 	g.adjustIO()
 	w2, h2 := g.Width(), g.Height()
@@ -1455,84 +1185,34 @@ func (tg *teg) unfoldGroup(g *group) {
 }
 
 func (tg *teg) removePlace(p *place) {
-	if p.in != nil {
-		p.in.unlink(p, false, false)
-
-	}
-	if p.out != nil {
-		p.out.unlink(p, true, false)
-	}
-	for i, place := range tg.places {
-		if place == p {
-			tg.places = append(tg.places[:i], tg.places[i+1:]...)
-			return
-		}
-	}
+	tg.net.RemovePlace(p.net)
+	tg.sync()
 }
 
 func (tg *teg) addTransition(x, y float64) *transition {
-	transition := newTransition(x, y)
-	transition.parent = tg
-	tg.transitions = append(tg.transitions, transition)
-	return transition
+	t := tg.wrapTransition(tg.net.AddTransition(x-TransitionWidth/2, y-TransitionHeight/2))
+	tg.transitions = append(tg.transitions, t)
+	return t
 }
 
+// removeTransition removes t and its arcs, the inputs and outputs of the
+// groups standing for it go away, see core.Graph.RemoveTransition.
 func (tg *teg) removeTransition(t *transition) {
 	if t.proxy != nil {
 		return
 	}
-	if proxy, ok := tg.findProxy(t); ok {
-		if proxy.kind == TransitionOutput {
-			for _, p := range proxy.in {
-				p.out = nil
-				p.outControl = nil
-			}
-		} else if proxy.kind == TransitionInput {
-			for _, p := range proxy.out {
-				p.in = nil
-				p.inControl = nil
-			}
-		}
-	}
-	for _, p := range t.in {
-		p.out = nil
-		p.outControl = nil
-	}
-	for _, p := range t.out {
-		p.in = nil
-		p.inControl = nil
-	}
-	for i, t2 := range tg.transitions {
-		if t2 == t {
-			tg.transitions = append(tg.transitions[:i], tg.transitions[i+1:]...)
-			return
-		}
+	tg.net.RemoveTransition(t.net)
+	tg.sync()
+	if tg.parent != nil {
+		tg.parent.sync()
 	}
 }
 
 func (tg *teg) removeGroup(g *group) {
-	for _, t := range g.inputs {
-		for _, p := range t.in {
-			p.out = nil
-			p.outControl = nil
-		}
-	}
-	for _, t := range g.outputs {
-		for _, p := range t.out {
-			p.in = nil
-			p.inControl = nil
-		}
-	}
-	g.inputs = nil
-	g.outputs = nil
-	g.model = nil
-	g.parent = nil
-	for i, gg := range tg.groups {
-		if gg == g {
-			tg.groups = append(tg.groups[:i], tg.groups[i+1:]...)
-			return
-		}
-	}
+	tg.net.RemoveGroup(g.net)
+	tg.sync()
+	g.inputs, g.outputs = nil, nil
+	g.model, g.parent = nil, nil
 }
 
 func (tg *teg) deselectAll() {
@@ -1584,26 +1264,26 @@ func (t *transition) nextKind() {
 	}
 	in := len(t.in) > 0
 	out := len(t.out) > 0
-	switch t.kind {
+	switch t.net.Kind {
 	case TransitionInternal:
 		if !in {
-			t.kind = TransitionInput
+			t.net.Kind = TransitionInput
 		} else if !out {
-			t.kind = TransitionOutput
+			t.net.Kind = TransitionOutput
 		}
 	case TransitionInput:
 		if !out {
-			t.kind = TransitionOutput
+			t.net.Kind = TransitionOutput
 		} else {
-			t.kind = TransitionInternal
+			t.net.Kind = TransitionInternal
 		}
 	case TransitionOutput:
 		if !in && !out {
-			t.kind = TransitionInternal
+			t.net.Kind = TransitionInternal
 		} else if !in {
-			t.kind = TransitionInput
+			t.net.Kind = TransitionInput
 		} else {
-			t.kind = TransitionInternal
+			t.net.Kind = TransitionInternal
 		}
 	}
 }
@@ -1616,7 +1296,7 @@ func (t *transition) isLinked(p *place, inbound bool) bool {
 }
 
 func (t *transition) refineSize() {
-	if t.horizontal {
+	if t.net.Horizontal {
 		w := calcTransitionHeight(len(t.in), len(t.out))
 		t.Resize(w, TransitionWidth) // rotated
 	} else {
@@ -1625,72 +1305,27 @@ func (t *transition) refineSize() {
 	}
 }
 
+// link makes an arc from t to p, or from p to t if inbound,
+// see core.Transition.Link.
 func (t *transition) link(p *place, inbound bool) {
-	if inbound && t.kind == TransitionInput {
-		return
-	} else if !inbound && t.kind == TransitionOutput {
-		return
-	}
-	if t.isLinked(p, inbound) {
+	if t.net.Link(p.net, inbound) != nil {
 		return
 	}
-	var changed bool
-	if inbound && p.out == nil {
-		p.out = t
-		t.in = append(t.in, p)
-		p.resetControlPoint(false)
-		t.OrderArcs(true)
-		changed = true
-	} else if !inbound && p.in == nil {
-		p.in = t
-		t.out = append(t.out, p)
-		t.OrderArcs(false)
-		p.resetControlPoint(true)
-		changed = true
-	}
-	if changed {
-		t.refineSize()
-		if t.proxy != nil {
-			t.group.updateIO()
-			t.group.adjustIO()
-		}
+	t.parent.sync()
+	t.OrderArcs(inbound)
+	if t.proxy != nil {
+		t.group.adjustIO()
 	}
 }
 
-func (t *transition) unlink(p *place, inbound bool, blitz bool) {
+func (t *transition) unlink(p *place, inbound bool) {
 	if !t.isLinked(p, inbound) {
 		return
 	}
-	var changed bool
-	if inbound && p.out != nil {
-		p.out = nil
-		for i, it := range t.in {
-			if it == p {
-				t.in = append(t.in[:i], t.in[i+1:]...)
-				break
-			}
-		}
-		t.OrderArcs(true)
-		p.outControl = nil
-		changed = true
-	} else if !inbound && p.in != nil {
-		p.in = nil
-		for i, it := range t.out {
-			if it == p {
-				t.out = append(t.out[:i], t.out[i+1:]...)
-				break
-			}
-		}
-		t.OrderArcs(false)
-		p.inControl = nil
-		changed = true
-	}
-	if changed {
-		t.refineSize()
-		if !blitz && t.proxy != nil {
-			t.group.updateIO()
-			t.group.adjustIO()
-		}
+	t.net.Unlink(p.net, inbound)
+	t.parent.sync()
+	if t.proxy != nil {
+		t.group.adjustIO()
 	}
 }
 
@@ -1699,168 +1334,68 @@ func calcTransitionHeight(in, out int) float64 {
 		float64(out)*TransitionHeight/2.0), TransitionHeight)
 }
 
-func (tg *teg) transferItems(tg2 *teg, items map[item]bool) {
-	var movP, movT, movG bool
-	// copy refs to tg
+// cloneItems copies the items into the teg, see core.Graph.Clone, and
+// returns the copies keyed by the originals. The items may be of another
+// teg of the tree.
+func (tg *teg) cloneItems(items map[item]bool) map[item]item {
+	var from *teg
 	for it := range items {
-		if p, ok := it.(*place); ok {
-			p.parent = tg
-			tg.places = append(tg.places, p)
-			movP = true
-		} else if t, ok := it.(*transition); ok {
-			t.parent = tg
-			tg.transitions = append(tg.transitions, t)
-			movT = true
-		} else if g, ok := it.(*group); ok {
-			g.parent = tg
-			g.model.parent = tg
-			tg.groups = append(tg.groups, g)
-			movG = true
-		}
-	}
-	// cleanup refs in tg2
-	if movP {
-		places := make([]*place, 0, 256)
-		for _, p := range tg2.places {
-			if !items[p] {
-				places = append(places, p)
-			}
+		switch it := it.(type) {
+		case *place:
+			from = it.parent
+		case *transition:
+			from = it.parent
+		case *group:
+			from = it.parent
 		}
-		tg2.places = places
+		break
 	}
-	if movT {
-		transitions := make([]*transition, 0, 256)
-		for _, t := range tg2.transitions {
-			if !items[t] {
-				transitions = append(transitions, t)
-			}
-		}
-		tg2.transitions = transitions
-	}
-	if movG {
-		groups := make([]*group, 0, 32)
-		for _, g := range tg2.groups {
-			if !items[g] {
-				groups = append(groups, g)
-			}
-		}
-		tg2.groups = groups
+	if from == nil {
+		return nil
 	}
+	places, transitions, groups := from.pick(items)
+	np, nt, ng := tg.net.Clone(nets(places, transitions, groups))
+	return tg.wrapClones(places, transitions, groups, np, nt, ng)
 }
 
-func (tg *teg) cloneItems(items map[item]bool) (clones map[item]item) {
-	clones = make(map[item]item, len(items))
-	ignore := make(map[item]bool, len(items))
-	for it := range items {
-		if t, ok := it.(*transition); ok {
-			if t.proxy != nil {
-				ignore[t] = true
-				continue
-			}
-			tNew := t.Copy().(*transition)
-			tNew.parent = tg
-			clones[t] = tNew
-			tg.transitions = append(tg.transitions, tNew)
-			for _, p := range t.in {
-				if _, ok := items[p]; ok {
-					var pNew *place
-					if pNew, ok = clones[p].(*place); !ok {
-						pNew = p.Copy().(*place)
-						pNew.parent = tg
-						clones[p] = pNew
-						tg.places = append(tg.places, pNew)
-					}
-					tNew.link(pNew, true)
-					if p.outControl.modified {
-						pNew.outControl.Move(p.outControl.Center().X, p.outControl.Center().Y)
-						pNew.outControl.modified = true
-					}
-				}
-			}
-			for _, p := range t.out {
-				if _, ok := items[p]; ok {
-					var pNew *place
-					if pNew, ok = clones[p].(*place); !ok {
-						pNew = p.Copy().(*place)
-						pNew.parent = tg
-						clones[p] = pNew
-						tg.places = append(tg.places, pNew)
-					}
-					tNew.link(pNew, false)
-					if p.inControl.modified {
-						pNew.inControl.Move(p.inControl.Center().X, p.inControl.Center().Y)
-						pNew.inControl.modified = true
-					}
-				}
-			}
-			// adjust position (size changed)
-			tNew.Move(t.Center().X, t.Center().Y)
-		}
+// wrapClones makes the items of the copies of the items, they are put
+// where the items are.
+func (tg *teg) wrapClones(places []*place, transitions []*transition, groups []*group,
+	np []*core.Place, nt []*core.Transition, ng []*core.Group) map[item]item {
+	clones := make(map[item]item, len(places)+len(transitions)+len(groups))
+	for i, p := range places {
+		c := tg.wrapPlace(np[i])
+		c.Move(p.Center().X, p.Center().Y)
+		clones[p] = c
 	}
-	for k := range ignore {
-		delete(items, k)
+	for i, t := range transitions {
+		c := tg.wrapTransition(nt[i])
+		c.Move(t.Center().X, t.Center().Y)
+		clones[t] = c
 	}
-	for k := range clones {
-		delete(items, k)
+	for i, g := range groups {
+		c := tg.wrapGroup(ng[i], g.model)
+		c.Rect = geometry.NewRect(g.X(), g.Y(), 0, 0)
+		clones[g] = c
 	}
-	for it := range items {
-		if p, ok := it.(*place); ok {
-			if pNew, ok := clones[p].(*place); !ok {
-				pNew = p.Copy().(*place)
-				pNew.parent = tg
-				clones[p] = pNew
-				tg.places = append(tg.places, pNew)
-			}
+	tg.sync()
+	for _, p := range places {
+		c := clones[p].(*place)
+		if p.inControl != nil && p.inControl.modified && c.inControl != nil {
+			c.inControl.Move(p.inControl.Center().X, p.inControl.Center().Y)
+			c.inControl.modified = true
 		}
-	}
-	for k := range clones {
-		delete(items, k)
-	}
-	for it := range items {
-		if g, ok := it.(*group); ok {
-			gNew := g.Copy().(*group)
-			gNew.parent = tg
-			clones[g] = gNew
-			tg.groups = append(tg.groups, gNew)
-			gNew.updateIO()
-
-			for _, t := range g.inputs {
-				for _, p := range t.in {
-					if newPlace, ok := clones[p].(*place); ok {
-						proxy := t.proxy
-						for _, t2 := range gNew.inputs {
-							if t2.proxy == proxy {
-								t2.link(newPlace, true)
-							}
-						}
-					}
-				}
-			}
-			for _, t := range g.outputs {
-				for _, p := range t.out {
-					if newPlace, ok := clones[p].(*place); ok {
-						proxy := t.proxy
-						for _, t2 := range gNew.outputs {
-							if t2.proxy == proxy {
-								t2.link(newPlace, false)
-							}
-						}
-					}
-				}
-			}
-			for _, t := range gNew.inputs {
-				if old, ok := g.iostate[t.proxy]; ok {
-					t.label = old.label
-					if old.horizontal != t.horizontal {
-						t.rotate()
-					}
-				}
-			}
-			gNew.updateBounds(false)
-			gNew.adjustIO()
+		if p.outControl != nil && p.outControl.modified && c.outControl != nil {
+			c.outControl.Move(p.outControl.Center().X, p.outControl.Center().Y)
+			c.outControl.modified = true
 		}
 	}
-	return
+	for _, g := range groups {
+		c := clones[g].(*group)
+		c.updateBounds(false)
+		c.adjustIO()
+	}
+	return clones
 }
 
 func (tg *teg) findDrawable(x float64, y float64) (interface{}, bool) {
@@ -1905,26 +1440,26 @@ func (tg *teg) updateInfos() {
 	var updated bool
 	for id, info := range tg.infos {
 		it := tg.findById(id)
-		if t, ok := it.(*transition); !ok || t.kind == TransitionInternal {
+		if t, ok := it.(*transition); !ok || t.net.Kind == TransitionInternal {
 			delete(tg.infos, id)
 			updated = true
 		} else if ok {
-			if t.kind == TransitionInput && !info.IsInput() {
+			if t.net.Kind == TransitionInput && !info.IsInput() {
 				delete(tg.infos, id)
 				updated = true
-			} else if t.kind == TransitionOutput && info.IsInput() {
+			} else if t.net.Kind == TransitionOutput && info.IsInput() {
 				delete(tg.infos, id)
 				updated = true
 			}
 		}
 	}
-	var k int
+	var k, l int
 	for _, t := range tg.transitions {
-		if t.kind == TransitionInput {
+		if t.net.Kind == TransitionInput {
 			k++
-			if info, ok := tg.infos[t.id]; ok {
-				label := t.label
-				if len(t.label) < 1 {
+			if info, ok := tg.infos[t.net.Id]; ok {
+				label := t.net.Label
+				if len(t.net.Label) < 1 {
 					label = fmt.Sprintf("unnamed %d", k)
 				}
 				if info.Label() != label {
@@ -1932,18 +1467,41 @@ func (tg *teg) updateInfos() {
 					updated = true
 				}
 			} else {
-				label := t.label
+				label := t.net.Label
 				if len(label) < 1 {
 					label = fmt.Sprintf("unnamed %d", k)
 				}
-				plane := planeview.NewPlane(t.id, label, true)
+				plane := planeview.NewPlane(t.net.Id, label, true)
 				// plane.FakeData()
+				plane.SetEdits(tg.edits)
 				plane.SetColor(PlaneColors[(k-1)%9])
-				tg.infos[t.id] = plane
+				tg.infos[t.net.Id] = plane
+				updated = true
+			}
+		} else if t.net.Kind == TransitionOutput {
+			l++
+			label := t.net.Label
+			if len(label) < 1 {
+				label = fmt.Sprintf("unnamed output %d", l)
+			}
+			if info, ok := tg.infos[t.net.Id]; ok {
+				if info.Label() != label {
+					info.SetLabel(label)
+					updated = true
+				}
+			} else {
+				plane := planeview.NewPlane(t.net.Id, label, false)
+				plane.SetEdits(tg.edits)
+				plane.SetColor(PlaneColors[8-(l-1)%9])
+				tg.infos[t.net.Id] = plane
+				tg.transferSig = ""
 				updated = true
 			}
 		}
 	}
+	if tg.updateTransfer() {
+		updated = true
+	}
 	if updated {
 		tg.updatedInfo <- nil
 	}
@@ -1959,6 +1517,10 @@ func newTeg() *teg {
 		selected:    make(map[item]bool, 256),
 		updated:     make(chan interface{}, 100),
 		updatedInfo: make(chan interface{}, 100),
-		id:          util.GenUUID(),
+		edits:       make(chan string, 100),
+		failures:    make(chan error, 10),
+		history:     newHistory(),
+		net:         core.New(),
+		views:       make(views),
 	}
 }
@@ -0,0 +1,77 @@
+package tegview
+
+func (t *testSuite) TestModelGroup() {
+	tg, u, p, y := chain()
+	g, err := tg.addGroup(map[item]bool{u: true, p: true, y: true})
+	t.Nil(err)
+	t.True(tg.net.Groups[0] == g.net)
+	t.True(g.model.net == g.net.Graph && p.parent == g.model)
+	t.Equal(1, len(g.inputs))
+	t.True(g.inputs[0].net == g.net.Inputs[0] && g.inputs[0].proxy == u)
+	t.True(g.outputs[0].net == g.net.Outputs[0] && g.outputs[0].proxy == y)
+	t.Nil(tg.net.Model().Validate())
+
+	a, q := tg.addTransition(0, 100), tg.addPlace(100, 100)
+	a.link(q, false)
+	_, err = tg.addGroup(map[item]bool{q: true})
+	t.Not(err == nil, "the place is linked outside")
+	tg.removeTransition(a)
+	tg.removePlace(q)
+
+	items := tg.flatGroup(g)
+	t.Equal(3, len(items))
+	t.Equal(0, len(tg.groups))
+	t.Equal(1, len(tg.net.Places))
+	t.Equal(2, len(tg.net.Transitions))
+	for _, c := range tg.transitions[1:] {
+		t.Equal(TransitionInternal, c.net.Kind)
+		t.True(items[c])
+	}
+	t.True(tg.places[0].in.net == tg.places[0].net.In)
+	t.Nil(tg.net.Model().Validate())
+}
+
+func (t *testSuite) TestModelLink() {
+	tg, u, p, y := chain()
+	y.unlink(p, true)
+	t.True(p.out == nil && p.net.Out == nil)
+	t.True(p.outControl == nil)
+	t.Equal(0, len(y.in))
+	y.link(p, true)
+	t.True(p.out == y && p.outControl != nil)
+	// an input has no input places
+	q := tg.addPlace(0, 100)
+	u.link(q, true)
+	t.True(q.out == nil)
+	tg.removeTransition(y)
+	t.True(p.out == nil)
+	t.Equal(1, len(tg.net.Transitions))
+}
+
+func (t *testSuite) TestModelClone() {
+	tg, u, _, _ := chain()
+	g, _ := tg.addGroup(map[item]bool{tg.transitions[1]: true})
+	clones := tg.cloneItems(map[item]bool{u: true, g: true})
+	c, gc := clones[u].(*transition), clones[g].(*group)
+	t.True(c.Center().X == u.Center().X)
+	t.True(gc.model == g.model, "the copies share the teg")
+	t.Not(gc.net.Id == g.net.Id)
+	t.Equal(1, len(gc.outputs))
+	t.Equal(0, len(c.out), "the place is not copied")
+	t.Nil(tg.net.Model().Validate())
+}
+
+func (t *testSuite) TestModelConstruct() {
+	tg, u, p, y := chain()
+	g, _ := tg.addGroup(map[item]bool{u: true, p: true, y: true})
+	g.net.Label = "plant"
+	tg2 := newTeg()
+	t.Nil(tg2.Construct(tg.Model(false)))
+	t.Equal(1, len(tg2.groups))
+	g2 := tg2.groups[0]
+	t.Equal("plant", g2.Label())
+	t.Equal(g.inputs[0].Id(), g2.inputs[0].Id())
+	t.Equal(u.Id(), g2.inputs[0].proxy.Id())
+	t.Equal(0, len(tg2.lint()))
+	t.Equal(tg.Model(false).Groups[0].Iostate, tg2.Model(false).Groups[0].Iostate)
+}
@@ -0,0 +1,65 @@
+package tegview
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xlab/teg-workshop/render"
+	core "github.com/xlab/teg-workshop/teg"
+)
+
+// SceneMargin is the room left around the items when the whole scene is taken.
+const SceneMargin = 100.0
+
+// pictureFormat tells the vector format of the file by its extension,
+// it is empty for the rest.
+func pictureFormat(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	for _, format := range render.PictureFormats {
+		if ext == format {
+			return format
+		}
+	}
+	return ""
+}
+
+// frame puts the point (x0, y0) of the model at the top left corner
+// of the canvas, the zoom is kept.
+func (tr *tegRenderer) frame(x0, y0 float64) {
+	tr.canvasWidth, tr.canvasHeight = 0, 0
+	tr.viewboxX, tr.viewboxY = 0, 0
+	tr.viewboxWidth = -2 * x0 * tr.zoom
+	tr.viewboxHeight = -2 * y0 * tr.zoom
+}
+
+// picture draws the whole scene, the primitives are
+// painted in the same order the canvas paints them.
+func (tr *tegRenderer) picture(model *teg) *render.Picture {
+	x0, y0, x1, y1 := detectBounds(model.Items())
+	x0, y0 = x0-SceneMargin, y0-SceneMargin
+	x1, y1 = x1+SceneMargin, y1+SceneMargin
+	tr.frame(x0, y0)
+	tr.renderModel(model, "", pt(0, 0), false)
+
+	pic := render.NewPicture(tr.scale(x1-x0), tr.scale(y1-y0))
+	buf := tr.buf
+	for _, l := range []*List{buf.RRects, buf.Circles, buf.Rects, buf.Lines,
+		buf.Bezier, buf.Polys, buf.Texts, buf.Chains} {
+		pic.Add(l.items...)
+	}
+	tr.buf = newTegBuffer()
+	return pic
+}
+
+// WritePicture draws the model like the editor does into a SVG, PDF or TikZ
+// picture, zoom scales it. Nothing is selected or simulated.
+func WritePicture(w io.Writer, model *core.Model, format string, zoom float64) error {
+	tg, err := constructModel(model)
+	if err != nil {
+		return err
+	}
+	tr := newTegRenderer()
+	tr.zoom = zoom
+	return render.WritePicture(w, tr.picture(tg), format)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/xlab/teg-workshop/geometry"
 	"github.com/xlab/teg-workshop/render"
+	core "github.com/xlab/teg-workshop/teg"
 )
 
 const (
@@ -26,6 +27,9 @@ const (
 	ColorUtilityShadow   = "#202980b9"
 	ColorControlPoint    = "#f1c40f"
 	ColorTransitionPad   = "#90bdc3c7"
+	ColorSimulation      = "#16a085"
+	ColorCritical        = "#e67e22"
+	ColorDeadlock        = "#c0392b"
 )
 
 const (
@@ -38,11 +42,6 @@ const (
 	TextFontSize  = 14.0
 	GroupFontSize = 18.0
 	GroupFrameR   = 10.0
-
-	BorderTransitionDist    = 3.0
-	BorderTransitionTipDist = 2.0
-	BorderPlaceDist         = 5.0
-	BorderPlaceTipDist      = 3.0
 )
 
 type List struct {
@@ -90,7 +89,6 @@ func newTegBuffer() *TegBuffer {
 type tegRenderer struct {
 	task   chan interface{}
 	ready  chan interface{}
-	ctrl   *Ctrl
 	buf    *TegBuffer
 	Screen *TegBuffer
 	Ready  bool
@@ -104,49 +102,36 @@ type tegRenderer struct {
 	viewboxY      float64
 
 	relateiveGlobalCenter *geometry.Point
+
+	// connections tells to draw the straight connections
+	// of the arcs, they are shown while Alt is held.
+	connections bool
+	// sim is the marking of the simulation being drawn, nil when editing.
+	sim *core.Marking
+	// cycle is the cycle time to highlight, if asked to.
+	cycle *core.CycleTime
 }
 
-func newTegRenderer(ctrl *Ctrl) *tegRenderer {
+func newTegRenderer() *tegRenderer {
 	return &tegRenderer{
 		task:   make(chan interface{}, 100),
 		ready:  make(chan interface{}, 100),
 		Screen: newTegBuffer(),
 		buf:    newTegBuffer(),
-		ctrl:   ctrl,
 		zoom:   1.0,
 	}
 }
 
-func (tr *tegRenderer) fixViewport() {
-	tr.zoom = tr.ctrl.Zoom
-
-	tr.canvasWidth = tr.ctrl.CanvasWidth
-	tr.canvasHeight = tr.ctrl.CanvasHeight
-	tr.viewboxWidth = tr.ctrl.CanvasWindowWidth
-	tr.viewboxHeight = tr.ctrl.CanvasWindowHeight
-	tr.viewboxX = tr.ctrl.CanvasWindowX
-	tr.viewboxY = tr.ctrl.CanvasWindowY
-}
-
-func (tr *tegRenderer) process(model *teg) {
-	<-tr.task
-	tr.fixViewport()
-	tr.renderModel(model, pt(0, 0), false)
-	tr.Screen = tr.buf
-	tr.buf = newTegBuffer()
-	tr.ready <- nil
-}
-
-func (tr *tegRenderer) renderModel(tg *teg, shift *geometry.Point, nested bool) {
+func (tr *tegRenderer) renderModel(tg *teg, path string, shift *geometry.Point, nested bool) {
 	for _, g := range tg.groups {
-		tr.renderGroup(g, shift, nested)
+		tr.renderGroup(g, path, shift, nested)
 	}
 	for _, p := range tg.places {
-		tr.renderPlace(p, shift, nested)
+		tr.renderPlace(p, path, shift, nested)
 	}
 	for _, t := range tg.transitions {
-		if !nested || t.kind == TransitionInternal {
-			tr.renderTransition(t, shift, nested)
+		if !nested || t.net.Kind == TransitionInternal {
+			tr.renderTransition(t, path, shift, nested)
 			for i, p := range t.in {
 				tr.renderArc(t, shift, p, shift, true, i)
 			}
@@ -155,7 +140,7 @@ func (tr *tegRenderer) renderModel(tg *teg, shift *geometry.Point, nested bool)
 			}
 		}
 	}
-	if !nested && tr.ctrl.ModifierKeyAlt {
+	if !nested && tr.connections {
 		for _, t := range tg.transitions {
 			for i, p := range t.in {
 				tr.renderConnection(t, shift, p, shift, true, i)
@@ -170,7 +155,7 @@ func (tr *tegRenderer) renderModel(tg *teg, shift *geometry.Point, nested bool)
 	}
 }
 
-func (tr *tegRenderer) renderGroup(g *group, shiftRoot *geometry.Point, nested bool) {
+func (tr *tegRenderer) renderGroup(g *group, path string, shiftRoot *geometry.Point, nested bool) {
 	gx, gy := g.X()+shiftRoot.X, g.Y()+shiftRoot.Y
 	frame := &render.RoundedRect{
 		Style: &render.Style{
@@ -198,11 +183,11 @@ func (tr *tegRenderer) renderGroup(g *group, shiftRoot *geometry.Point, nested b
 	shift := calcItemsShift(center, g.model.Items())
 
 	for _, t := range g.inputs {
-		tr.renderTransition(t, shiftRoot, nested)
-		if len(t.label) > 0 && !nested {
+		tr.renderTransition(t, path, shiftRoot, nested)
+		if len(t.net.Label) > 0 && !nested {
 			tr.renderIOText(t, true)
 		}
-		if !g.folded {
+		if !g.net.Folded {
 			for i, p := range t.out {
 				if nested {
 					tr.renderArc(t, shiftRoot, p, shift, false, i)
@@ -217,17 +202,17 @@ func (tr *tegRenderer) renderGroup(g *group, shiftRoot *geometry.Point, nested b
 			} else {
 				tr.renderArc(t, pt(0, 0), p, shiftRoot, true, i)
 			}
-			if !nested && tr.ctrl.ModifierKeyAlt {
+			if !nested && tr.connections {
 				tr.renderConnection(t, pt(0, 0), p, pt(0, 0), true, i)
 			}
 		}
 	}
 	for _, t := range g.outputs {
-		tr.renderTransition(t, shiftRoot, nested)
-		if len(t.label) > 0 && !nested {
+		tr.renderTransition(t, path, shiftRoot, nested)
+		if len(t.net.Label) > 0 && !nested {
 			tr.renderIOText(t, false)
 		}
-		if !g.folded {
+		if !g.net.Folded {
 			for i, p := range t.in {
 				if nested {
 					tr.renderArc(t, shiftRoot, p, shift, true, i)
@@ -242,16 +227,16 @@ func (tr *tegRenderer) renderGroup(g *group, shiftRoot *geometry.Point, nested b
 			} else {
 				tr.renderArc(t, pt(0, 0), p, pt(0, 0), false, i)
 			}
-			if !nested && tr.ctrl.ModifierKeyAlt {
+			if !nested && tr.connections {
 				tr.renderConnection(t, pt(0, 0), p, pt(0, 0), false, i)
 			}
 		}
 	}
-	if !g.folded {
-		tr.renderModel(g.model, shift, true)
+	if !g.net.Folded {
+		tr.renderModel(g.model, path+"/"+g.net.Id, shift, true)
 
 		// Render label
-		if len(g.label) < 1 || nested {
+		if len(g.net.Label) < 1 || nested {
 			return
 		}
 		room := g.Width() - GroupMargin*2
@@ -259,19 +244,19 @@ func (tr *tegRenderer) renderGroup(g *group, shiftRoot *geometry.Point, nested b
 		cfg := textConfig{
 			x: gx + GroupMargin, y: gy + g.Height() + TextFontSize,
 			room: room, color: ColorComments, align: render.TextAlignCenter,
-			text: fmt.Sprintf("// %s", g.label), breaklen: breaklen, oblique: true,
+			text: fmt.Sprintf("// %s", g.net.Label), breaklen: breaklen, oblique: true,
 		}
 		tr.renderText(&cfg)
 		return
 	}
 
 	// Render big label
-	if len(g.label) < 1 {
+	if len(g.net.Label) < 1 {
 		return
 	}
 	room := g.Width() - GroupMargin*2
 	breaklen := int(math.Max(room/GroupFontSize, 16))
-	text := g.label
+	text := g.net.Label
 	_, theight := calcTextFragments(text, GroupFontSize, breaklen)
 	vmargin := calcCenteringMargin(g.Height(), theight, 1)
 
@@ -290,18 +275,18 @@ func (tr *tegRenderer) renderIOText(t *transition, input bool) {
 	cfg := textConfig{
 		breaklen: 16, color: ColorComments, oblique: true,
 	}
-	_, theight := calcTextFragments("// "+t.label, TextFontSize, 16)
-	if t.horizontal {
+	_, theight := calcTextFragments("// "+t.net.Label, TextFontSize, 16)
+	if t.net.Horizontal {
 		if input {
 			cfg.x = t.X() + t.Width()/2 + theight
 			cfg.y = t.Y() - 3*Padding
 			cfg.align = render.TextAlignRight
-			cfg.text = t.label + " //"
+			cfg.text = t.net.Label + " //"
 		} else {
 			cfg.x = t.X() - t.Width() + 3*Padding
 			cfg.y = t.Y() + TextFontSize
 			cfg.align = render.TextAlignLeft
-			cfg.text = "// " + t.label
+			cfg.text = "// " + t.net.Label
 		}
 		cfg.vertical = true
 		cfg.room = GroupIOSpacing
@@ -312,12 +297,12 @@ func (tr *tegRenderer) renderIOText(t *transition, input bool) {
 			cfg.x = t.X() - 3*Padding
 			cfg.y = t.Y() + t.Height()
 			cfg.align = render.TextAlignRight
-			cfg.text = "// " + t.label
+			cfg.text = "// " + t.net.Label
 		} else {
 			cfg.x = t.X() + t.Width() + 3*Padding
 			cfg.y = t.Y() - theight
 			cfg.align = render.TextAlignLeft
-			cfg.text = t.label + " //"
+			cfg.text = t.net.Label + " //"
 		}
 		cfg.room = GroupIOSpacing
 		cfg.valign = true
@@ -359,7 +344,7 @@ func (tr *tegRenderer) renderUtility(u *utility) {
 	}
 }
 
-func (tr *tegRenderer) renderPlace(p *place, shift *geometry.Point, nested bool) {
+func (tr *tegRenderer) renderPlace(p *place, path string, shift *geometry.Point, nested bool) {
 	x, y := p.X()+shift.X, p.Y()+shift.Y
 	pad := &render.Circle{
 		Style: &render.Style{
@@ -371,6 +356,13 @@ func (tr *tegRenderer) renderPlace(p *place, shift *geometry.Point, nested bool)
 		Y: tr.absY(tr.scaleY(y)),
 		D: tr.scale(p.Width()),
 	}
+	if tr.cycle != nil {
+		if tr.cycle.DeadPlaces[p.ref(path)] {
+			pad.Style.StrokeStyle = ColorDeadlock
+		} else if tr.cycle.CriticalPlaces[p.ref(path)] {
+			pad.Style.StrokeStyle = ColorCritical
+		}
+	}
 	if p.IsSelected() {
 		makeControlPoint := func(cp *controlPoint) *render.Rect {
 			xc, yc := cp.X()+shift.X, cp.Y()+shift.Y
@@ -393,20 +385,27 @@ func (tr *tegRenderer) renderPlace(p *place, shift *geometry.Point, nested bool)
 		}
 		pad.Style.StrokeStyle = ColorSelected
 	}
+	counter := p.net.Counter
+	if tr.sim != nil {
+		counter = simCount(tr.sim, path, p)
+		if tr.sim.Changed(p.ref(path)) && !p.IsSelected() {
+			pad.Style.StrokeStyle = ColorSimulation
+		}
+	}
 	tr.buf.Circles.Put(pad)
-	tr.renderPlaceValue(x+Padding, y+Padding, p.Width()-Padding*2, p.IsSelected(), p.counter, p.timer)
-	if len(p.label) > 0 && !nested {
+	tr.renderPlaceValue(x+Padding, y+Padding, p.Width()-Padding*2, p.IsSelected(), counter, p.net.Timer)
+	if len(p.net.Label) > 0 && !nested {
 		cfg := textConfig{
 			x: x, y: y + p.Height() + TextFontSize,
 			room: p.Width(), color: ColorComments,
-			text:     fmt.Sprintf("// %s", p.label),
+			text:     fmt.Sprintf("// %s", p.net.Label),
 			breaklen: 16, oblique: true, align: render.TextAlignCenter,
 		}
 		tr.renderText(&cfg)
 	}
 }
 
-func (tr *tegRenderer) renderTransition(t *transition, shift *geometry.Point, nested bool) {
+func (tr *tegRenderer) renderTransition(t *transition, path string, shift *geometry.Point, nested bool) {
 	x, y := t.X()+shift.X, t.Y()+shift.Y
 	rect := &render.Rect{
 		Style: &render.Style{
@@ -420,7 +419,7 @@ func (tr *tegRenderer) renderTransition(t *transition, shift *geometry.Point, ne
 	}
 	var knob *render.Rect
 	kw, kh := Margin/5, Margin/17
-	if t.proxy == nil && t.kind == TransitionInput {
+	if t.proxy == nil && t.net.Kind == TransitionInput {
 		rect.Style.FillStyle = ColorTransitionIO
 		knob = &render.Rect{
 			Style: &render.Style{
@@ -428,7 +427,7 @@ func (tr *tegRenderer) renderTransition(t *transition, shift *geometry.Point, ne
 				FillStyle: ColorTransitionIO,
 			},
 		}
-		if t.horizontal {
+		if t.net.Horizontal {
 			kw, kh = kh, kw
 			knob.X = tr.scaleX(t.Center().X + shift.X - kw/2)
 			knob.Y = tr.scaleY(t.Center().Y + shift.Y - kh)
@@ -441,7 +440,7 @@ func (tr *tegRenderer) renderTransition(t *transition, shift *geometry.Point, ne
 			knob.H = tr.scale(kh)
 		}
 		knob.X, knob.Y = tr.absX(knob.X), tr.absY(knob.Y)
-	} else if t.proxy == nil && t.kind == TransitionOutput {
+	} else if t.proxy == nil && t.net.Kind == TransitionOutput {
 		rect.Style.FillStyle = ColorTransitionIO
 		knob = &render.Rect{
 			Style: &render.Style{
@@ -449,7 +448,7 @@ func (tr *tegRenderer) renderTransition(t *transition, shift *geometry.Point, ne
 				FillStyle: ColorTransitionIO,
 			},
 		}
-		if t.horizontal {
+		if t.net.Horizontal {
 			kw, kh = kh, kw
 			knob.X = tr.scaleX(t.Center().X + shift.X - kw/2)
 			knob.Y = tr.scaleY(t.Center().Y + shift.Y)
@@ -465,7 +464,7 @@ func (tr *tegRenderer) renderTransition(t *transition, shift *geometry.Point, ne
 	}
 	if t.IsSelected() {
 		var d float64
-		if t.horizontal {
+		if t.net.Horizontal {
 			d = t.Width() + 3*Padding
 		} else {
 			d = t.Height() + 3*Padding
@@ -484,18 +483,28 @@ func (tr *tegRenderer) renderTransition(t *transition, shift *geometry.Point, ne
 		if knob != nil {
 			knob.Style.FillStyle = ColorSelected
 		}
+	} else if tr.sim != nil && tr.sim.Fired(t.ref(path)) {
+		rect.Style.FillStyle = ColorSimulation
+		if knob != nil {
+			knob.Style.FillStyle = ColorSimulation
+		}
+	} else if tr.cycle != nil && tr.cycle.CriticalTransitions[t.ref(path)] {
+		rect.Style.FillStyle = ColorCritical
+		if knob != nil {
+			knob.Style.FillStyle = ColorCritical
+		}
 	}
 	if knob != nil {
 		tr.buf.Rects.Put(knob)
 	}
 	tr.buf.Rects.Put(rect)
-	if len(t.label) > 0 && !nested && t.proxy == nil {
+	if len(t.net.Label) > 0 && !nested && t.proxy == nil {
 		cfg := textConfig{
-			text:     fmt.Sprintf("// %s", t.label),
+			text:     fmt.Sprintf("// %s", t.net.Label),
 			breaklen: 16, color: ColorComments, oblique: true,
 			align: render.TextAlignCenter,
 		}
-		if t.horizontal {
+		if t.net.Horizontal {
 			cfg.x = x + t.Width() + TextFontSize/2
 			cfg.y = y + t.Height()/2
 			cfg.room = t.Height()
@@ -538,13 +547,13 @@ func (tr *tegRenderer) renderArc(t *transition, shiftT *geometry.Point,
 
 	var xyC2 *geometry.Point
 	if inbound {
-		if t.horizontal {
+		if t.net.Horizontal {
 			xyC2 = pt(endT.x, endT.y-Margin)
 		} else {
 			xyC2 = pt(endT.x-Margin, endT.y)
 		}
 	} else {
-		if t.horizontal {
+		if t.net.Horizontal {
 			xyC2 = pt(endT.x, endT.y+Margin)
 		} else {
 			xyC2 = pt(endT.x+Margin, endT.y)
@@ -606,7 +615,7 @@ func (tr *tegRenderer) renderConnection(t *transition, shiftT *geometry.Point,
 	var xyC2 *geometry.Point
 	var start, end *geometry.Point
 	if inbound {
-		if t.horizontal {
+		if t.net.Horizontal {
 			xyC2 = pt(endT.x, endT.y-Margin)
 		} else {
 			xyC2 = pt(endT.x-Margin, endT.y)
@@ -614,7 +623,7 @@ func (tr *tegRenderer) renderConnection(t *transition, shiftT *geometry.Point,
 		start = pt(endP.x, endP.y)
 		end = pt(endT.xTip, endT.yTip)
 	} else {
-		if t.horizontal {
+		if t.net.Horizontal {
 			xyC2 = pt(endT.x, endT.y+Margin)
 		} else {
 			xyC2 = pt(endT.x+Margin, endT.y)
@@ -975,89 +984,6 @@ func (tr *tegRenderer) absPoint(p *geometry.Point) *render.Point {
 		tr.canvasHeight/2+tr.viewboxHeight/2+p.Y)
 }
 
-func pt(x, y float64) *geometry.Point {
-	return &geometry.Point{x, y}
-}
-
 func rpt(x, y float64) *render.Point {
 	return &render.Point{x, y}
 }
-
-func calcSpacing(room, each float64, count int) float64 {
-	return (room - (each * float64(count))) / (float64(count) + 1)
-}
-
-func calcCenteringMargin(room, each float64, count int) float64 {
-	c := float64(count)
-	return (room - ((c-1)*calcSpacing(room, each, count) + c*each)) / 2
-}
-
-type end struct {
-	x, y, angle, xTip, yTip float64
-}
-
-func calcBorderPointPlace(p *place, shift *geometry.Point, x, y float64) *end {
-	px, py := p.Center().X+shift.X, p.Center().Y+shift.Y
-	angle := math.Atan2(x-px, y-py)
-	radius := p.Width() / 2
-	dxTip := (radius + BorderPlaceTipDist) * math.Sin(angle)
-	dyTip := (radius + BorderPlaceTipDist) * math.Cos(angle)
-	dx := (radius + BorderPlaceDist) * math.Sin(angle)
-	dy := (radius + BorderPlaceDist) * math.Cos(angle)
-	return &end{
-		x: px + dx, y: py + dy,
-		xTip: px + dxTip, yTip: py + dyTip,
-		angle: angle,
-	}
-}
-
-func calcBorderPointTransition(t *transition, shift *geometry.Point, inbound bool, count, index int) *end {
-	thick := 2.0
-	var x, y float64
-
-	end := new(end)
-	if t.horizontal && inbound {
-		x = t.X()
-		y = t.Y() - BorderTransitionDist
-	} else if inbound {
-		x = t.X() - BorderTransitionDist
-		y = t.Y()
-	} else if t.horizontal {
-		x = t.X()
-		y = t.Y() + t.Height() + BorderTransitionDist
-	} else {
-		x = t.X() + t.Width() + BorderTransitionDist
-		y = t.Y()
-	}
-	x, y = x+shift.X, y+shift.Y
-	if t.horizontal {
-		space := calcSpacing(t.Width(), thick, count)
-		margin := calcCenteringMargin(t.Width(), 1.0, count)
-		dx := margin + float64(index)*(thick+space)
-		end.x, end.y = x+dx, y
-		if inbound {
-			end.angle = math.Pi
-			end.xTip = x + dx
-			end.yTip = y + BorderTransitionTipDist
-		} else {
-			end.angle = math.Pi
-			end.xTip = x + dx
-			end.yTip = y - BorderTransitionTipDist
-		}
-	} else {
-		space := calcSpacing(t.Height(), thick, count)
-		margin := calcCenteringMargin(t.Height(), 1.0, count)
-		dy := margin + float64(index)*(thick+space)
-		end.x, end.y = x, y+dy
-		if inbound {
-			end.angle = -math.Pi / 2
-			end.xTip = x + BorderTransitionTipDist
-			end.yTip = y + dy
-		} else {
-			end.angle = math.Pi / 2
-			end.xTip = x - BorderTransitionTipDist
-			end.yTip = y + dy
-		}
-	}
-	return end
-}
@@ -1,13 +1,18 @@
+//go:build !headless
+// +build !headless
+
 package tegview
 
 import (
-	"encoding/json"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"strings"
 	"sync"
 
 	"github.com/xlab/teg-workshop/planeview"
+	core "github.com/xlab/teg-workshop/teg"
 	"github.com/xlab/teg-workshop/workspace"
 	"gopkg.in/qml.v1"
 	"gopkg.in/xlab/clipboard.v2"
@@ -40,11 +45,22 @@ type actionPlaneView struct {
 	models    []*planeview.Plane
 	id, title string
 }
+type actionDaters struct {
+	table     string
+	models    []*planeview.Plane
+	id, title string
+}
+type actionLint struct{ diagnostics []*Diagnostic }
+type actionCompare struct{ text string }
+type actionController struct {
+	models    []*planeview.Plane
+	id, title string
+}
 
 func NewView() *View {
 	engine := qml.NewEngine()
 	model := newTeg()
-	renderer := newTegRenderer(nil)
+	renderer := newTegRenderer()
 	engine.Context().SetVar("tegRenderer", renderer)
 	qml.RegisterTypes("TegCtrl", 1, 0, []qml.TypeSpec{
 		{
@@ -55,8 +71,6 @@ func NewView() *View {
 				ctrl.errors = make(chan error, 100)
 				ctrl.clip = clipboard.New(engine)
 
-				renderer.ctrl = ctrl
-
 				ctrl.Title = DefaultTitle
 			},
 		},
@@ -78,14 +92,13 @@ func NewView() *View {
 		closed:   make(chan struct{}),
 		stop:     make(chan struct{}),
 		control:  control,
-		id:       model.id,
+		id:       model.net.Id,
 	}
 
 	var once sync.Once
 	quitcode := func() {
 		view.control.stopHandling()
 		view.model.deselectAll()
-		view.model.updateParentGroups()
 		close(view.stop)
 		close(view.childs)
 		close(view.closed)
@@ -100,7 +113,7 @@ func NewView() *View {
 }
 
 func (v *View) setModel(model *teg) {
-	v.id = model.id
+	v.id = model.net.Id
 	v.model = model
 	v.control.model = model
 }
@@ -120,29 +133,46 @@ func (v *View) newWindow(title string) (view *View) {
 	return
 }
 
-func (v *View) saveModel(path string) (err error) {
-	f, err := os.Create(path)
+// isPNML tells whether the file is a PNML net rather than a document.
+func isPNML(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".pnml")
+}
+
+func (v *View) saveModel(name string) (err error) {
+	var g *core.Graph
+	if isPNML(name) {
+		if g, err = core.Build(v.model.Model(false)); err != nil {
+			return
+		}
+	}
+	f, err := os.Create(name)
 	if err != nil {
 		return
 	}
 	defer f.Close()
-	enc := json.NewEncoder(f)
-	if err = enc.Encode(v.model); err != nil {
-		return
+	if g != nil {
+		return core.WritePNML(f, g)
 	}
-	return
+	return core.Encode(f, v.model.Model(false))
 }
 
-func (v *View) loadModel(path string) (err error) {
-	f, err := os.Open(path)
+// loadModel reads a document of any known version or a PNML net, the model
+// of the view is replaced only when it has been constructed completely.
+func (v *View) loadModel(name string) (err error) {
+	data, err := ioutil.ReadFile(name)
 	if err != nil {
 		return
 	}
-	defer f.Close()
-	dec := json.NewDecoder(f)
-	if err = dec.Decode(v.model); err != nil {
+	var model *teg
+	if isPNML(name) {
+		model, err = constructNet(data)
+	} else {
+		model, err = constructDocument(data)
+	}
+	if err != nil {
 		return
 	}
+	v.setModel(model)
 	return
 }
 
@@ -163,15 +193,27 @@ func (v *View) editGroup(model *teg, label string) {
 	return
 }
 
+// saveFile saves the model and lints it, the panel of diagnostics
+// pops up only when something is wrong but is cleared anyway.
 func (v *View) saveFile(name string) (err error) {
 	err = v.saveModel(name)
 	if err != nil {
 		return
 	}
 	v.SetTitle(path.Base(name))
+	v.showDiagnostics(v.model.lint())
 	return
 }
 
+func (v *View) showDiagnostics(diagnostics []*Diagnostic) {
+	its := make([]interface{}, len(diagnostics))
+	for i, d := range diagnostics {
+		its[i] = d
+	}
+	v.control.Diagnostics = list(its)
+	qml.Changed(v.control, &v.control.Diagnostics)
+}
+
 func (v *View) updatePlaneViewer() {
 
 }
@@ -223,6 +265,25 @@ func (v *View) Show() chan struct{} {
 					view.SetModels(info.models)
 					view.SetTitle(info.title)
 					v.childs <- view
+				case actionDaters:
+					info := act.(actionDaters)
+					v.control.DaterText = info.table
+					qml.Changed(v.control, &v.control.DaterText)
+					view := planeview.NewView(info.id)
+					view.SetModels(info.models)
+					view.SetTitle(info.title)
+					v.childs <- view
+				case actionLint:
+					v.showDiagnostics(act.(actionLint).diagnostics)
+				case actionCompare:
+					v.control.CompareText = act.(actionCompare).text
+					qml.Changed(v.control, &v.control.CompareText)
+				case actionController:
+					info := act.(actionController)
+					view := planeview.NewView(info.id)
+					view.SetModels(info.models)
+					view.SetTitle(info.title)
+					v.childs <- view
 				}
 			}
 		}
@@ -236,7 +297,16 @@ func (v *View) Show() chan struct{} {
 				qml.Changed(v.renderer, &v.renderer.Screen)
 			case <-v.model.updated:
 				v.renderer.task <- nil
+			case <-v.model.edits:
+				// the outputs follow the inputs
+				v.model.updateInfos()
+			case err := <-v.model.failures:
+				v.control.Error(err)
 			case <-v.model.updatedInfo:
+				if v.model.cycle != nil && v.control.CycleText != v.model.cycle.String() {
+					v.control.CycleText = v.model.cycle.String()
+					qml.Changed(v.control, &v.control.CycleText)
+				}
 				if v.planeView != nil {
 					infos := make([]*planeview.Plane, 0, len(v.model.infos))
 					for _, p := range v.model.infos {
@@ -253,7 +323,7 @@ func (v *View) Show() chan struct{} {
 			case <-v.stop:
 				return
 			default:
-				v.renderer.process(v.model)
+				v.renderer.process(v.model, v.control)
 			}
 		}
 	}()
@@ -0,0 +1,13 @@
+package util
+
+import "strings"
+
+var texReplacer = strings.NewReplacer(
+	"\\", "\\textbackslash{}", "{", "\\{", "}", "\\}", "$", "\\$", "&", "\\&",
+	"#", "\\#", "_", "\\_", "%", "\\%", "~", "\\textasciitilde{}", "^", "\\textasciicircum{}",
+)
+
+// TexEscape keeps the text from being read as LaTeX commands.
+func TexEscape(s string) string {
+	return texReplacer.Replace(s)
+}